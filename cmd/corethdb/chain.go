@@ -0,0 +1,197 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	transactionHistoryFlag = &cli.Uint64Flag{
+		Name:  "transaction-history",
+		Usage: "Number of recent blocks for which to keep the transaction lookup indices (0 = all blocks)",
+	}
+)
+
+var (
+	inspectCommand = &cli.Command{
+		Name:   "inspect",
+		Usage:  "Inspect the storage size of each type of chain data",
+		Action: inspect,
+	}
+	compactCommand = &cli.Command{
+		Name:   "compact",
+		Usage:  "Compact the chain data to reclaim the space of deleted entries",
+		Action: compact,
+	}
+	repairTxIndexCommand = &cli.Command{
+		Name:  "repair-tx-index",
+		Usage: "Rebuild the transaction lookup indices of the accepted blocks",
+		Description: `Indexes again the transactions of the blocks above the transaction index tail,
+which restores the lookups lost by an unclean shutdown, and removes the
+lookups of the blocks older than --transaction-history.`,
+		Flags:  []cli.Flag{transactionHistoryFlag},
+		Action: repairTxIndex,
+	}
+	checkAcceptorTipCommand = &cli.Command{
+		Name:  "check-acceptor-tip",
+		Usage: "Check that the acceptor processed every accepted block",
+		Description: `Compares the acceptor tip, the last block whose indices are fully written,
+with the last accepted block, and reports the blocks the node reprocesses on
+startup. It fails if the state to resume from is missing.`,
+		Action: checkAcceptorTip,
+	}
+)
+
+func inspect(c *cli.Context) error {
+	db, err := openChainDatabase(c, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return customrawdb.InspectDatabase(noAncientsDatabase{db.chaindb}, nil, nil)
+}
+
+func compact(c *cli.Context) error {
+	db, err := openChainDatabase(c, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	start := time.Now()
+	log.Info("Compacting chain data")
+	if err := db.chaindb.Compact(nil, nil); err != nil {
+		return fmt.Errorf("failed to compact chain data: %w", err)
+	}
+	log.Info("Compacted chain data", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func repairTxIndex(c *cli.Context) error {
+	db, err := openChainDatabase(c, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	hash, err := db.lastAccepted()
+	if err != nil {
+		return err
+	}
+	number := rawdb.ReadHeaderNumber(db.chaindb, hash)
+	if number == nil {
+		return fmt.Errorf("missing last accepted block %s", hash)
+	}
+	var (
+		head    = *number
+		limit   = c.Uint64(transactionHistoryFlag.Name)
+		oldTail uint64
+		newTail uint64
+	)
+	if tail := rawdb.ReadTxIndexTail(db.chaindb); tail != nil {
+		oldTail = *tail
+	}
+	if limit != 0 && head+1 > limit {
+		newTail = head - limit + 1
+	}
+	log.Info("Repairing transaction index", "head", head, "oldTail", oldTail, "newTail", newTail)
+	rawdb.UnindexTransactions(db.chaindb, oldTail, newTail, nil, true)
+	rawdb.IndexTransactions(db.chaindb, max(oldTail, newTail), head+1, nil, true)
+	rawdb.WriteTxIndexTail(db.chaindb, max(oldTail, newTail))
+	return nil
+}
+
+func checkAcceptorTip(c *cli.Context) error {
+	db, err := openChainDatabase(c, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	lastAccepted, err := db.lastAccepted()
+	if err != nil {
+		return err
+	}
+	acceptorTip, err := customrawdb.ReadAcceptorTip(db.chaindb)
+	if err != nil {
+		return err
+	}
+	out := c.App.Writer
+	fmt.Fprintf(out, "Last accepted block: %s\n", lastAccepted)
+	fmt.Fprintf(out, "Head block:          %s\n", rawdb.ReadHeadBlockHash(db.chaindb))
+	fmt.Fprintf(out, "Acceptor tip:        %s\n", acceptorTip)
+
+	// The acceptor tip is up to date either if it matches the last accepted
+	// block, or it has not been initialized.
+	tip := acceptorTip
+	if tip == (common.Hash{}) {
+		tip = lastAccepted
+	}
+	tipNumber := rawdb.ReadHeaderNumber(db.chaindb, tip)
+	if tipNumber == nil {
+		return fmt.Errorf("missing block of the acceptor tip %s", tip)
+	}
+	number := rawdb.ReadHeaderNumber(db.chaindb, lastAccepted)
+	if number == nil {
+		return fmt.Errorf("missing last accepted block %s", lastAccepted)
+	}
+	if *tipNumber > *number {
+		return fmt.Errorf("acceptor tip %d is above the last accepted block %d", *tipNumber, *number)
+	}
+	if rawdb.ReadCanonicalHash(db.chaindb, *tipNumber) != tip {
+		return fmt.Errorf("acceptor tip %s is not canonical", tip)
+	}
+
+	// Mirror the reprocessing on startup, which resumes from the most recent
+	// state available at or below the acceptor tip.
+	var (
+		tdb   = db.triedb()
+		state = *tipNumber
+	)
+	defer tdb.Close()
+	for {
+		header := rawdb.ReadHeader(db.chaindb, rawdb.ReadCanonicalHash(db.chaindb, state), state)
+		if header == nil {
+			return fmt.Errorf("missing accepted block %d", state)
+		}
+		if hasState(tdb, header.Root) {
+			break
+		}
+		if state == 0 {
+			return errors.New("no state available at or below the acceptor tip")
+		}
+		state--
+	}
+	fmt.Fprintf(out, "Acceptor tip height: %d, last accepted height: %d\n", *tipNumber, *number)
+	fmt.Fprintf(out, "Most recent state at or below the acceptor tip: %d\n", state)
+	if state == *number && *tipNumber == *number {
+		fmt.Fprintln(out, "The acceptor tip is up to date")
+	} else {
+		fmt.Fprintf(out, "%d blocks are reprocessed on startup\n", *number-state)
+	}
+	return nil
+}
+
+// noAncientsDatabase reports an empty ancient store, since coreth does not
+// keep one, so the inspection does not fail on the unsupported operations.
+type noAncientsDatabase struct {
+	ethdb.Database
+}
+
+func (noAncientsDatabase) Ancients() (uint64, error) { return 0, nil }
+
+func (noAncientsDatabase) Tail() (uint64, error) { return 0, nil }
+
+func (noAncientsDatabase) AncientSize(string) (uint64, error) { return 0, nil }
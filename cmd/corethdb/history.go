@@ -0,0 +1,101 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ava-labs/coreth/cmd/utils"
+	"github.com/ava-labs/coreth/internal/era"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	networkFlag = &cli.StringFlag{
+		Name:  "network",
+		Usage: "Network name prefixing the Era1 file names",
+		Value: "mainnet",
+	}
+	stepFlag = &cli.Uint64Flag{
+		Name:  "step",
+		Usage: "Number of blocks per Era1 file",
+		Value: uint64(era.MaxEra1Size),
+	}
+)
+
+var (
+	exportHistoryCommand = &cli.Command{
+		Name:      "export-history",
+		Usage:     "Export the accepted blocks and receipts into Era1 archives",
+		ArgsUsage: "<dir> <first> [<last>]",
+		Description: `Writes the accepted blocks [<first>, <last>] (default <last> = last accepted
+block), with their receipts and total difficulties, into Era1 archives in <dir>
+along with their checksums in <dir>/checksums.txt.`,
+		Flags:  []cli.Flag{networkFlag, stepFlag},
+		Action: exportHistory,
+	}
+	importHistoryCommand = &cli.Command{
+		Name:      "import-history",
+		Usage:     "Import the blocks and receipts of Era1 archives without executing them",
+		ArgsUsage: "<dir>",
+		Description: `Verifies the Era1 archives in <dir> against their checksums, accumulators and
+block headers, then writes their blocks, receipts and transaction lookups as
+canonical blocks. The imported range must agree with the canonical blocks of
+the database, the last accepted block and the state are left untouched.`,
+		Flags:  []cli.Flag{networkFlag},
+		Action: importHistory,
+	}
+)
+
+func exportHistory(c *cli.Context) error {
+	if c.NArg() < 2 || c.NArg() > 3 {
+		return errors.New("expected <dir> <first> [<last>]")
+	}
+	first, err := strconv.ParseUint(c.Args().Get(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid first block: %w", err)
+	}
+	db, err := openChainDatabase(c, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	lastAccepted, err := db.lastAccepted()
+	if err != nil {
+		return err
+	}
+	number := rawdb.ReadHeaderNumber(db.chaindb, lastAccepted)
+	if number == nil {
+		return fmt.Errorf("missing last accepted block %s", lastAccepted)
+	}
+	last := *number
+	if c.NArg() == 3 {
+		if last, err = strconv.ParseUint(c.Args().Get(2), 10, 64); err != nil {
+			return fmt.Errorf("invalid last block: %w", err)
+		}
+		if last > *number {
+			log.Warn("Last block beyond the last accepted block, setting last = last accepted", "lastAccepted", *number, "last", last)
+			last = *number
+		}
+	}
+	return utils.ExportHistory(db.chaindb, c.Args().First(), c.String(networkFlag.Name), first, last, c.Uint64(stepFlag.Name))
+}
+
+func importHistory(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("expected <dir>")
+	}
+	db, err := openChainDatabase(c, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return utils.ImportHistory(db.chaindb, c.Args().First(), c.String(networkFlag.Name))
+}
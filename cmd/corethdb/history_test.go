@@ -0,0 +1,74 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	require := require.New(t)
+
+	chainID := ids.GenerateTestID()
+	dir, blocks := newTestChain(t, chainID)
+
+	// Export the genesis and the 10 blocks into 3 archives.
+	exportDir := t.TempDir()
+	_, err := runCommand(dir, chainID, "export-history", "--network", "testnet", "--step", "4", exportDir, "0")
+	require.NoError(err)
+	files, err := filepath.Glob(filepath.Join(exportDir, "testnet-*.era1"))
+	require.NoError(err)
+	require.Len(files, 3)
+
+	// The archives agree with the chain they are exported from.
+	_, err = runCommand(dir, chainID, "import-history", "--network", "testnet", exportDir)
+	require.NoError(err)
+
+	// Import them into an empty database, twice as importing is idempotent.
+	importDir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		_, err = runCommand(importDir, chainID, "import-history", "--network", "testnet", exportDir)
+		require.NoError(err)
+	}
+	chaindb, closeDB := openTestChain(t, importDir, chainID)
+	require.Equal(blocks[0].ParentHash(), rawdb.ReadCanonicalHash(chaindb, 0))
+	for _, block := range blocks {
+		number := block.NumberU64()
+		require.Equal(block.Hash(), rawdb.ReadCanonicalHash(chaindb, number))
+		imported := rawdb.ReadBlock(chaindb, block.Hash(), number)
+		require.NotNil(imported)
+		require.Equal(block.Hash(), imported.Hash())
+		require.Len(imported.Transactions(), len(block.Transactions()))
+		require.Len(rawdb.ReadRawReceipts(chaindb, block.Hash(), number), len(block.Transactions()))
+		for _, tx := range block.Transactions() {
+			require.Equal(number, *rawdb.ReadTxLookupEntry(chaindb, tx.Hash()))
+		}
+	}
+	require.NoError(closeDB())
+
+	// A partial export only holds the blocks of the requested range.
+	partialDir := t.TempDir()
+	_, err = runCommand(dir, chainID, "export-history", "--network", "testnet", "--step", "4", partialDir, "5", "6")
+	require.NoError(err)
+	importDir = t.TempDir()
+	_, err = runCommand(importDir, chainID, "import-history", "--network", "testnet", partialDir)
+	require.NoError(err)
+	chaindb, closeDB = openTestChain(t, importDir, chainID)
+	require.Equal(common.Hash{}, rawdb.ReadCanonicalHash(chaindb, 4))
+	require.Equal(blocks[4].Hash(), rawdb.ReadCanonicalHash(chaindb, 5))
+	require.Equal(blocks[5].Hash(), rawdb.ReadCanonicalHash(chaindb, 6))
+	require.NoError(closeDB())
+
+	// Archives are rejected if they do not match their checksums.
+	require.NoError(os.WriteFile(filepath.Join(exportDir, "checksums.txt"), []byte("0x00\n0x00\n0x00"), 0o644))
+	_, err = runCommand(t.TempDir(), chainID, "import-history", "--network", "testnet", exportDir)
+	require.ErrorContains(err, "checksum mismatch")
+}
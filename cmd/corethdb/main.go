@@ -0,0 +1,160 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// corethdb is a maintenance tool for the C-Chain data held in the database of
+// a stopped avalanchego node.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	avalanchedatabase "github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/leveldb"
+	"github.com/ava-labs/avalanchego/database/pebbledb"
+	"github.com/ava-labs/avalanchego/database/prefixdb"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/coreth/internal/flags"
+	"github.com/ava-labs/coreth/plugin/evm/database"
+	"github.com/ava-labs/coreth/triedb/pathdb"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/ava-labs/libevm/triedb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/urfave/cli/v2"
+)
+
+// The layout of the chain data, which must match the one of avalanchego and
+// of the VM in plugin/evm.
+var (
+	vmDBPrefix      = []byte("vm")
+	ethDBPrefix     = []byte("ethdb")
+	acceptedPrefix  = []byte("snowman_accepted")
+	lastAcceptedKey = []byte("last_accepted_key")
+)
+
+var (
+	dbDirFlag = &cli.StringFlag{
+		Name:     "db-dir",
+		Usage:    "Path to the versioned database directory of the node (e.g. ~/.avalanchego/db/mainnet/v1.4.5)",
+		Required: true,
+	}
+	dbTypeFlag = &cli.StringFlag{
+		Name:  "db-type",
+		Usage: "Type of the node database (leveldb or pebbledb)",
+		Value: leveldb.Name,
+	}
+	chainIDFlag = &cli.StringFlag{
+		Name:     "chain-id",
+		Usage:    "Blockchain ID of the C-Chain",
+		Required: true,
+	}
+)
+
+var app = flags.NewApp("coreth database maintenance tool")
+
+func init() {
+	app.Name = "corethdb"
+	app.Flags = []cli.Flag{
+		dbDirFlag,
+		dbTypeFlag,
+		chainIDFlag,
+	}
+	app.Commands = []*cli.Command{
+		inspectCommand,
+		pruneCommand,
+		verifyStateCommand,
+		dumpAccountCommand,
+		exportSnapshotCommand,
+		compactCommand,
+		repairTxIndexCommand,
+		checkAcceptorTipCommand,
+		exportHistoryCommand,
+		importHistoryCommand,
+	}
+}
+
+func main() {
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LevelInfo, true)))
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// chainDatabase is the data of the C-Chain in the node database.
+type chainDatabase struct {
+	base     avalanchedatabase.Database
+	chaindb  ethdb.Database
+	accepted avalanchedatabase.Database
+}
+
+// openChainDatabase opens the C-Chain data selected by the global flags. If
+// [readOnly] is set, the writes are buffered in memory and never persisted.
+func openChainDatabase(c *cli.Context, readOnly bool) (*chainDatabase, error) {
+	chainID, err := ids.FromString(c.String(chainIDFlag.Name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid chain ID: %w", err)
+	}
+	var (
+		path = c.String(dbDirFlag.Name)
+		base avalanchedatabase.Database
+	)
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	switch dbType := c.String(dbTypeFlag.Name); dbType {
+	case leveldb.Name:
+		base, err = leveldb.New(path, nil, logging.NoLog{}, prometheus.NewRegistry())
+	case pebbledb.Name:
+		base, err = pebbledb.New(path, nil, logging.NoLog{}, prometheus.NewRegistry())
+	default:
+		return nil, fmt.Errorf("unknown database type %q", dbType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database at %s: %w", path, err)
+	}
+	var db avalanchedatabase.Database = prefixdb.New(vmDBPrefix, prefixdb.New(chainID[:], base))
+	if readOnly {
+		db = versiondb.New(db)
+	}
+	// The VM nests its own prefixes, the accepted block database being on top
+	// of a versiondb rather than of the prefixed database it is given.
+	return &chainDatabase{
+		base:     base,
+		chaindb:  rawdb.NewDatabase(database.WrapDatabase(prefixdb.NewNested(ethDBPrefix, db))),
+		accepted: prefixdb.NewNested(acceptedPrefix, db),
+	}, nil
+}
+
+func (db *chainDatabase) Close() error {
+	return db.base.Close()
+}
+
+// lastAccepted returns the hash of the last block accepted by the VM.
+func (db *chainDatabase) lastAccepted() (common.Hash, error) {
+	blkID, err := db.accepted.Get(lastAcceptedKey)
+	if errors.Is(err, avalanchedatabase.ErrNotFound) {
+		return common.Hash{}, errors.New("no last accepted block, the chain is not initialized")
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(blkID), nil
+}
+
+// triedb opens a read-only trie database in the scheme of the stored states.
+func (db *chainDatabase) triedb() *triedb.Database {
+	if rawdb.ReadStateScheme(db.chaindb) == rawdb.PathScheme {
+		return triedb.NewDatabase(db.chaindb, &triedb.Config{
+			DBOverride: pathdb.Config{ReadOnly: true}.BackendConstructor,
+		})
+	}
+	return triedb.NewDatabase(db.chaindb, triedb.HashDefaults)
+}
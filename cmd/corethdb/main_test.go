@@ -0,0 +1,170 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/leveldb"
+	"github.com/ava-labs/avalanchego/database/prefixdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/logging"
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/database"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is the key of the account funding the transfers of the test chain.
+var testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+
+var (
+	testAddr     = common.Address{2}
+	testContract = common.Address{3}
+)
+
+// newTestChain writes a chain of 10 accepted blocks, each transferring to
+// [testAddr], to a new node database in the layout of avalanchego. It returns
+// the directory of the database and the blocks.
+func newTestChain(t *testing.T, chainID ids.ID) (string, []*types.Block) {
+	require := require.New(t)
+
+	var (
+		dir   = t.TempDir()
+		addr  = crypto.PubkeyToAddress(testKey.PublicKey)
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				testContract: {
+					Balance: common.Big0,
+					Code:    []byte{0x60, 0x00},
+					Storage: map[common.Hash]common.Hash{common.HexToHash("0x01"): common.HexToHash("0x02")},
+				},
+			},
+		}
+	)
+	base, err := leveldb.New(dir, nil, logging.NoLog{}, prometheus.NewRegistry())
+	require.NoError(err)
+	defer base.Close()
+	vmDB := prefixdb.New(vmDBPrefix, prefixdb.New(chainID[:], base))
+	chaindb := rawdb.NewDatabase(database.WrapDatabase(prefixdb.NewNested(ethDBPrefix, vmDB)))
+
+	cacheConfig := *core.DefaultCacheConfig
+	cacheConfig.SnapshotWait = true
+	blockchain, err := core.NewBlockChain(chaindb, &cacheConfig, gspec, dummy.NewCoinbaseFaker(), vm.Config{}, common.Hash{}, false)
+	require.NoError(err)
+	signer := types.LatestSigner(gspec.Config)
+	_, blocks, _, err := core.GenerateChainWithGenesis(gspec, dummy.NewCoinbaseFaker(), 10, 10, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(addr), testAddr, big.NewInt(10000), params.TxGas, gen.BaseFee(), nil), signer, testKey)
+		require.NoError(err)
+		gen.AddTx(tx)
+	})
+	require.NoError(err)
+	_, err = blockchain.InsertChain(blocks)
+	require.NoError(err)
+	for _, block := range blocks {
+		require.NoError(blockchain.Accept(block))
+	}
+	blockchain.DrainAcceptorQueue()
+	blockchain.Stop()
+
+	last := blocks[len(blocks)-1]
+	require.NoError(prefixdb.NewNested(acceptedPrefix, vmDB).Put(lastAcceptedKey, last.Hash().Bytes()))
+	return dir, blocks
+}
+
+// openTestChain opens the chain data of the node database in [dir], which
+// must be closed with the returned function.
+func openTestChain(t *testing.T, dir string, chainID ids.ID) (ethdb.Database, func() error) {
+	base, err := leveldb.New(dir, nil, logging.NoLog{}, prometheus.NewRegistry())
+	require.NoError(t, err)
+	return rawdb.NewDatabase(database.WrapDatabase(prefixdb.NewNested(ethDBPrefix, prefixdb.New(vmDBPrefix, prefixdb.New(chainID[:], base))))), base.Close
+}
+
+// runCommand runs corethdb with [args] on the chain [chainID] of the node
+// database in [dir] and returns its output.
+func runCommand(dir string, chainID ids.ID, args ...string) (string, error) {
+	var out bytes.Buffer
+	app.Writer = &out
+	err := app.Run(append([]string{"corethdb", "--db-dir", dir, "--chain-id", chainID.String()}, args...))
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	require := require.New(t)
+
+	chainID := ids.GenerateTestID()
+	dir, blocks := newTestChain(t, chainID)
+	last := blocks[len(blocks)-1]
+
+	// Lose a transaction lookup, which is restored by repair-tx-index.
+	lostTx := blocks[5].Transactions()[0].Hash()
+	chaindb, closeDB := openTestChain(t, dir, chainID)
+	rawdb.DeleteTxLookupEntry(chaindb, lostTx)
+	require.NoError(closeDB())
+
+	run := func(args ...string) (string, error) {
+		return runCommand(dir, chainID, args...)
+	}
+
+	out, err := run("check-acceptor-tip")
+	require.NoError(err)
+	require.Contains(out, "The acceptor tip is up to date")
+
+	out, err = run("verify-state")
+	require.NoError(err)
+	require.Contains(out, "State "+last.Root().Hex()+" is valid: 4 accounts, 1 storage slots")
+
+	out, err = run("dump-account", "--storage", testContract.Hex())
+	require.NoError(err)
+	var account state.DumpAccount
+	require.NoError(json.Unmarshal([]byte(out), &account))
+	require.Equal([]byte{0x60, 0x00}, []byte(account.Code))
+	require.Equal(map[common.Hash]string{crypto.Keccak256Hash(common.HexToHash("0x01").Bytes()): "02"}, account.Storage)
+
+	out, err = run("dump-account", testAddr.Hex())
+	require.NoError(err)
+	require.NoError(json.Unmarshal([]byte(out), &account))
+	require.Equal("100000", account.Balance)
+
+	out, err = run("export-snapshot", "-")
+	require.NoError(err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(lines, 5)
+	require.JSONEq(`{"root":"`+last.Root().Hex()+`"}`, lines[0])
+
+	_, err = run("inspect")
+	require.NoError(err)
+	_, err = run("repair-tx-index")
+	require.NoError(err)
+	_, err = run("prune", "--pruning-data-dir", t.TempDir(), "--bloom-filter-size", "1")
+	require.NoError(err)
+	_, err = run("compact")
+	require.NoError(err)
+	out, err = run("verify-state")
+	require.NoError(err)
+	require.Contains(out, "is valid")
+
+	// The commands refuse to run on an unknown chain.
+	_, err = runCommand(dir, ids.GenerateTestID(), "verify-state")
+	require.ErrorContains(err, "no last accepted block")
+
+	chaindb, closeDB = openTestChain(t, dir, chainID)
+	defer closeDB()
+	require.NotNil(rawdb.ReadTxLookupEntry(chaindb, lostTx))
+}
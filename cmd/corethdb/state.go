@@ -0,0 +1,458 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/state/pruner"
+	"github.com/ava-labs/coreth/core/state/snapshot"
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/ava-labs/libevm/rlp"
+	"github.com/ava-labs/libevm/trie"
+	"github.com/ava-labs/libevm/triedb"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	pruningDataDirFlag = &cli.StringFlag{
+		Name:     "pruning-data-dir",
+		Usage:    "Directory of the bloom filter persisted by the pruner, used to resume an interrupted run",
+		Required: true,
+	}
+	bloomFilterSizeFlag = &cli.Uint64Flag{
+		Name:  "bloom-filter-size",
+		Usage: "Megabytes of memory allocated to the bloom filter of the pruner",
+		Value: 512,
+	}
+	rootFlag = &cli.StringFlag{
+		Name:  "root",
+		Usage: "State root to read (default = state of the last accepted block)",
+	}
+	storageFlag = &cli.BoolFlag{
+		Name:  "storage",
+		Usage: "Include the storage of the account",
+	}
+	excludeStorageFlag = &cli.BoolFlag{
+		Name:  "exclude-storage",
+		Usage: "Exclude the storage of the accounts",
+	}
+	excludeCodeFlag = &cli.BoolFlag{
+		Name:  "exclude-code",
+		Usage: "Exclude the code of the accounts",
+	}
+)
+
+var (
+	pruneCommand = &cli.Command{
+		Name:  "prune",
+		Usage: "Prune the stale state of the hash based scheme offline",
+		Description: `Deletes the trie nodes which are not part of the state of the last accepted
+block or of the genesis state. The snapshot of the last accepted state must be
+complete, and the acceptor must have processed every accepted block.`,
+		Flags:  []cli.Flag{pruningDataDirFlag, bloomFilterSizeFlag},
+		Action: prune,
+	}
+	verifyStateCommand = &cli.Command{
+		Name:      "verify-state",
+		Usage:     "Verify that a state is complete and uncorrupted",
+		ArgsUsage: "[<root>]",
+		Description: `Iterates the account and storage tries of the state <root> (default = state of
+the last accepted block), checking that every node is present and matches its
+hash and that every contract code is present.`,
+		Action: verifyState,
+	}
+	dumpAccountCommand = &cli.Command{
+		Name:      "dump-account",
+		Usage:     "Print an account of a state in JSON",
+		ArgsUsage: "<address>",
+		Flags:     []cli.Flag{rootFlag, storageFlag},
+		Action:    dumpAccount,
+	}
+	exportSnapshotCommand = &cli.Command{
+		Name:      "export-snapshot",
+		Usage:     "Export the state snapshot in JSON lines",
+		ArgsUsage: "<file>",
+		Description: `Writes the accounts of the persisted state snapshot to <file> (- for stdout),
+one JSON object per line, after a first line holding the state root. The
+accounts and storage slots are keyed by the hash of their address and slot.`,
+		Flags:  []cli.Flag{excludeStorageFlag, excludeCodeFlag},
+		Action: exportSnapshot,
+	}
+)
+
+func prune(c *cli.Context) error {
+	db, err := openChainDatabase(c, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if rawdb.ReadStateScheme(db.chaindb) == rawdb.PathScheme {
+		return errors.New("offline pruning is only supported with the hash scheme")
+	}
+	lastAccepted, err := db.lastAccepted()
+	if err != nil {
+		return err
+	}
+	// The pruner keeps the state of the head block, which must be the last
+	// accepted one with all its indices written.
+	if head := rawdb.ReadHeadBlockHash(db.chaindb); head != lastAccepted {
+		return fmt.Errorf("head block %s is not the last accepted block %s, start the node once to recover it", head, lastAccepted)
+	}
+	if tip, err := customrawdb.ReadAcceptorTip(db.chaindb); err != nil {
+		return err
+	} else if tip != (common.Hash{}) && tip != lastAccepted {
+		return fmt.Errorf("acceptor tip %s is behind the last accepted block %s, start the node once to process it", tip, lastAccepted)
+	}
+	root, err := db.lastAcceptedRoot()
+	if err != nil {
+		return err
+	}
+	dataDir := c.String(pruningDataDirFlag.Name)
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return err
+	}
+	p, err := pruner.NewPruner(db.chaindb, pruner.Config{
+		Datadir:   dataDir,
+		BloomSize: c.Uint64(bloomFilterSizeFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.Prune(root); err != nil {
+		return fmt.Errorf("failed to prune state with target root %s: %w", root, err)
+	}
+	return nil
+}
+
+func verifyState(c *cli.Context) error {
+	if c.NArg() > 1 {
+		return errors.New("too many arguments")
+	}
+	db, err := openChainDatabase(c, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var root common.Hash
+	if c.NArg() == 1 {
+		root, err = parseHash(c.Args().First())
+	} else {
+		root, err = db.lastAcceptedRoot()
+	}
+	if err != nil {
+		return err
+	}
+	tdb := db.triedb()
+	defer tdb.Close()
+
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts int
+		slots    int
+		nodes    int
+		codes    int
+	)
+	// verifyTrie iterates the trie [id], checks the hash of each node and
+	// calls [onLeaf] for each leaf.
+	verifyTrie := func(id *trie.ID, onLeaf func(key, value []byte) error) error {
+		t, err := trie.NewStateTrie(id, tdb)
+		if err != nil {
+			return err
+		}
+		it, err := t.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		for it.Next(true) {
+			// Embedded nodes don't have hash.
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				if crypto.Keccak256Hash(it.NodeBlob()) != hash {
+					return fmt.Errorf("corrupted trie node %s in trie %s at path %x", hash, id.Root, it.Path())
+				}
+				nodes++
+			}
+			if it.Leaf() {
+				if err := onLeaf(it.LeafKey(), it.LeafBlob()); err != nil {
+					return err
+				}
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Verifying state", "root", root, "accounts", accounts, "slots", slots, "nodes", nodes,
+					"elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+		return it.Error()
+	}
+	err = verifyTrie(trie.StateTrieID(root), func(key, value []byte) error {
+		accounts++
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(value, &acc); err != nil {
+			return fmt.Errorf("invalid account %x: %w", key, err)
+		}
+		if acc.Root != types.EmptyRootHash {
+			id := trie.StorageTrieID(root, common.BytesToHash(key), acc.Root)
+			if err := verifyTrie(id, func(_, _ []byte) error { slots++; return nil }); err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+			code := rawdb.ReadCode(db.chaindb, common.BytesToHash(acc.CodeHash))
+			if len(code) == 0 {
+				return fmt.Errorf("missing code %x of account %x", acc.CodeHash, key)
+			}
+			if crypto.Keccak256Hash(code) != common.BytesToHash(acc.CodeHash) {
+				return fmt.Errorf("corrupted code %x of account %x", acc.CodeHash, key)
+			}
+			codes++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("state %s is not valid: %w", root, err)
+	}
+	fmt.Fprintf(c.App.Writer, "State %s is valid: %d accounts, %d storage slots, %d trie nodes, %d codes\n", root, accounts, slots, nodes, codes)
+	return nil
+}
+
+func dumpAccount(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("expected an address")
+	}
+	if !common.IsHexAddress(c.Args().First()) {
+		return fmt.Errorf("invalid address %q", c.Args().First())
+	}
+	addr := common.HexToAddress(c.Args().First())
+
+	db, err := openChainDatabase(c, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var root common.Hash
+	if c.IsSet(rootFlag.Name) {
+		root, err = parseHash(c.String(rootFlag.Name))
+	} else {
+		root, err = db.lastAcceptedRoot()
+	}
+	if err != nil {
+		return err
+	}
+	tdb := db.triedb()
+	defer tdb.Close()
+
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), tdb)
+	if err != nil {
+		return err
+	}
+	acc, err := t.GetAccount(addr)
+	if err != nil {
+		return err
+	}
+	if acc == nil {
+		return fmt.Errorf("account %s not found in state %s", addr, root)
+	}
+	dump := state.DumpAccount{
+		Balance:     acc.Balance.String(),
+		Nonce:       acc.Nonce,
+		Root:        acc.Root[:],
+		CodeHash:    acc.CodeHash,
+		Code:        rawdb.ReadCode(db.chaindb, common.BytesToHash(acc.CodeHash)),
+		Address:     &addr,
+		AddressHash: crypto.Keccak256(addr[:]),
+	}
+	if c.Bool(storageFlag.Name) && acc.Root != types.EmptyRootHash {
+		dump.Storage, err = dumpStorage(tdb, trie.StorageTrieID(root, crypto.Keccak256Hash(addr[:]), acc.Root))
+		if err != nil {
+			return err
+		}
+	}
+	out, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.App.Writer, string(out))
+	return nil
+}
+
+// dumpStorage returns the slots of the storage trie [id], keyed by the hash
+// of the slot.
+func dumpStorage(tdb *triedb.Database, id *trie.ID) (map[common.Hash]string, error) {
+	t, err := trie.NewStateTrie(id, tdb)
+	if err != nil {
+		return nil, err
+	}
+	nodeIt, err := t.NodeIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		storage = make(map[common.Hash]string)
+		it      = trie.NewIterator(nodeIt)
+	)
+	for it.Next() {
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return nil, err
+		}
+		storage[common.BytesToHash(it.Key)] = common.Bytes2Hex(content)
+	}
+	return storage, it.Err
+}
+
+func exportSnapshot(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("expected an output file")
+	}
+	db, err := openChainDatabase(c, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tdb := db.triedb()
+	defer tdb.Close()
+
+	root := rawdb.ReadSnapshotRoot(db.chaindb)
+	snaptree, err := snapshot.New(snapshot.Config{
+		CacheSize:  256,
+		NoBuild:    true,
+		SkipVerify: true,
+	}, db.chaindb, tdb, customrawdb.ReadSnapshotBlockHash(db.chaindb), root)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	var out io.Writer = c.App.Writer
+	if file := c.Args().First(); file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	n, err := writeSnapshot(out, db.chaindb, snaptree, root, !c.Bool(excludeStorageFlag.Name), !c.Bool(excludeCodeFlag.Name))
+	if err != nil {
+		return err
+	}
+	log.Info("Exported snapshot", "root", root, "accounts", n)
+	return nil
+}
+
+// writeSnapshot writes the accounts of the snapshot [root] to [w] in JSON
+// lines and returns their number.
+func writeSnapshot(w io.Writer, db ethdb.KeyValueReader, snaptree *snapshot.Tree, root common.Hash, withStorage, withCode bool) (int, error) {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(struct {
+		Root common.Hash `json:"root"`
+	}{root}); err != nil {
+		return 0, err
+	}
+	accIt, err := snaptree.AccountIterator(root, common.Hash{}, false)
+	if err != nil {
+		return 0, err
+	}
+	defer accIt.Release()
+
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		accounts int
+	)
+	for accIt.Next() {
+		acc, err := types.FullAccount(accIt.Account())
+		if err != nil {
+			return accounts, err
+		}
+		dump := state.DumpAccount{
+			Balance:     acc.Balance.String(),
+			Nonce:       acc.Nonce,
+			Root:        acc.Root[:],
+			CodeHash:    acc.CodeHash,
+			AddressHash: accIt.Hash().Bytes(),
+		}
+		if withCode {
+			dump.Code = rawdb.ReadCode(db, common.BytesToHash(acc.CodeHash))
+		}
+		if withStorage && acc.Root != types.EmptyRootHash {
+			dump.Storage = make(map[common.Hash]string)
+			storageIt, err := snaptree.StorageIterator(root, accIt.Hash(), common.Hash{})
+			if err != nil {
+				return accounts, err
+			}
+			for storageIt.Next() {
+				_, content, _, err := rlp.Split(storageIt.Slot())
+				if err != nil {
+					storageIt.Release()
+					return accounts, err
+				}
+				dump.Storage[storageIt.Hash()] = common.Bytes2Hex(content)
+			}
+			err = storageIt.Error()
+			storageIt.Release()
+			if err != nil {
+				return accounts, err
+			}
+		}
+		if err := enc.Encode(dump); err != nil {
+			return accounts, err
+		}
+		accounts++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting snapshot", "root", root, "accounts", accounts, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	return accounts, accIt.Error()
+}
+
+// hasState returns whether the state [root] is available in [tdb].
+func hasState(tdb *triedb.Database, root common.Hash) bool {
+	_, err := trie.NewStateTrie(trie.StateTrieID(root), tdb)
+	return err == nil
+}
+
+// lastAcceptedRoot returns the state root of the last accepted block.
+func (db *chainDatabase) lastAcceptedRoot() (common.Hash, error) {
+	hash, err := db.lastAccepted()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	number := rawdb.ReadHeaderNumber(db.chaindb, hash)
+	if number == nil {
+		return common.Hash{}, fmt.Errorf("missing last accepted block %s", hash)
+	}
+	header := rawdb.ReadHeader(db.chaindb, hash, *number)
+	if header == nil {
+		return common.Hash{}, fmt.Errorf("missing last accepted block %s", hash)
+	}
+	return header.Root, nil
+}
+
+func parseHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid hash %q", s)
+	}
+	return common.BytesToHash(b), nil
+}
@@ -0,0 +1,385 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+//
+// This file is a derived work, based on the go-ethereum library whose original
+// notices appear below.
+//
+// It is distributed under a license compatible with the licensing terms of the
+// original code from which it is derived.
+//
+// Much love to the original authors for their work.
+// **********
+// Copyright 2014 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ava-labs/coreth/internal/era"
+	"github.com/ava-labs/coreth/plugin/evm/customtypes"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/ava-labs/libevm/rlp"
+	"github.com/ava-labs/libevm/trie"
+)
+
+// ExportHistory exports the canonical blocks [first, last] of [db] along with
+// their receipts into Era1 archives of [step] blocks, aligned on multiples of
+// [step], and writes the checksum of each archive to checksums.txt.
+//
+// The bodies and receipts are encoded as coreth stores them, so the bodies
+// hold the extra data of the blocks (the atomic transactions).
+func ExportHistory(db ethdb.Database, dir, network string, first, last, step uint64) error {
+	if first > last {
+		return fmt.Errorf("invalid range [%d, %d]", first, last)
+	}
+	if step == 0 || step > uint64(era.MaxEra1Size) {
+		return fmt.Errorf("invalid step %d, must be in [1, %d]", step, era.MaxEra1Size)
+	}
+	log.Info("Exporting blockchain history", "dir", dir, "first", first, "last", last)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	// coreth does not store the total difficulty, it is accumulated from the
+	// genesis instead.
+	td := new(big.Int)
+	for n := uint64(0); n < first; n++ {
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, n), n)
+		if header == nil {
+			return fmt.Errorf("export failed on #%d: header not found", n)
+		}
+		td.Add(td, header.Difficulty)
+	}
+	var (
+		start     = time.Now()
+		reported  = time.Now()
+		h         = sha256.New()
+		buf       = bytes.NewBuffer(nil)
+		checksums []string
+	)
+	for epoch := first / step; epoch <= last/step; epoch++ {
+		err := func() error {
+			filename := path.Join(dir, era.Filename(network, int(epoch), common.Hash{}))
+			f, err := os.Create(filename)
+			if err != nil {
+				return fmt.Errorf("could not create era file: %w", err)
+			}
+			defer f.Close()
+
+			w := era.NewBuilder(f)
+			for n := max(first, epoch*step); n <= min(last, epoch*step+step-1); n++ {
+				hash := rawdb.ReadCanonicalHash(db, n)
+				header := rawdb.ReadHeader(db, hash, n)
+				if header == nil {
+					return fmt.Errorf("export failed on #%d: not found", n)
+				}
+				body := rawdb.ReadBody(db, hash, n)
+				if body == nil {
+					return fmt.Errorf("export failed on #%d: body not found", n)
+				}
+				receipts := rawdb.ReadRawReceipts(db, hash, n)
+				if receipts == nil {
+					return fmt.Errorf("export failed on #%d: receipts not found", n)
+				}
+				if len(receipts) != len(body.Transactions) {
+					return fmt.Errorf("export failed on #%d: %d receipts for %d transactions", n, len(receipts), len(body.Transactions))
+				}
+				// The type is not stored, but part of the consensus encoding.
+				for i, tx := range body.Transactions {
+					receipts[i].Type = tx.Type()
+				}
+				encReceipts, err := rlp.EncodeToBytes(receipts)
+				if err != nil {
+					return err
+				}
+				// The builder keeps the total difficulties it is given.
+				td = new(big.Int).Add(td, header.Difficulty)
+				if err := w.AddRLP(rawdb.ReadHeaderRLP(db, hash, n), rawdb.ReadBodyRLP(db, hash, n), encReceipts, n, hash, td, header.Difficulty); err != nil {
+					return err
+				}
+			}
+			root, err := w.Finalize()
+			if err != nil {
+				return fmt.Errorf("export failed to finalize %d: %w", epoch, err)
+			}
+			// Set correct filename with root.
+			if err := os.Rename(filename, path.Join(dir, era.Filename(network, int(epoch), root))); err != nil {
+				return err
+			}
+			// Compute checksum of entire Era1.
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := io.Copy(h, f); err != nil {
+				return fmt.Errorf("unable to calculate checksum: %w", err)
+			}
+			checksums = append(checksums, common.BytesToHash(h.Sum(buf.Bytes()[:])).Hex())
+			h.Reset()
+			buf.Reset()
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", min(last, epoch*step+step-1), "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := os.WriteFile(path.Join(dir, "checksums.txt"), []byte(strings.Join(checksums, "\n")), os.ModePerm); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain history", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ImportHistory writes the blocks and receipts of the Era1 archives in [dir]
+// to [db] without executing them. The archives are checked against
+// checksums.txt and their accumulators, and every block against its header
+// and its parent, so the imported range must extend the canonical blocks of
+// [db] or agree with them. The head markers and the state are left untouched.
+func ImportHistory(db ethdb.Database, dir, network string) error {
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no %s era1 files in %s", network, dir)
+	}
+	checksums, err := readList(path.Join(dir, "checksums.txt"))
+	if err != nil {
+		return fmt.Errorf("unable to read checksums.txt: %w", err)
+	}
+	if len(checksums) != len(entries) {
+		return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+	}
+	first, last, err := historyRange(dir, entries)
+	if err != nil {
+		return err
+	}
+	if first.NumberU64() > 0 {
+		if parent := rawdb.ReadCanonicalHash(db, first.NumberU64()-1); parent != (common.Hash{}) && parent != first.ParentHash() {
+			return fmt.Errorf("block #%d does not extend the canonical block %s", first.NumberU64(), parent)
+		}
+	}
+	if child := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, last.NumberU64()+1), last.NumberU64()+1); child != nil && child.ParentHash != last.Hash() {
+		return fmt.Errorf("block #%d is not the parent of the canonical block %s", last.NumberU64(), child.Hash())
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported = 0
+		parent   = first.ParentHash()
+		h        = sha256.New()
+		buf      = bytes.NewBuffer(nil)
+		batch    = db.NewBatch()
+	)
+	for i, filename := range entries {
+		err := func() error {
+			f, err := os.Open(path.Join(dir, filename))
+			if err != nil {
+				return fmt.Errorf("unable to open era: %w", err)
+			}
+			defer f.Close()
+
+			// Validate checksum.
+			if _, err := io.Copy(h, f); err != nil {
+				return fmt.Errorf("unable to recalculate checksum: %w", err)
+			}
+			if have, want := common.BytesToHash(h.Sum(buf.Bytes()[:])).Hex(), checksums[i]; have != want {
+				return fmt.Errorf("checksum mismatch: have %s, want %s", have, want)
+			}
+			h.Reset()
+			buf.Reset()
+
+			e, err := era.From(f)
+			if err != nil {
+				return fmt.Errorf("error opening era: %w", err)
+			}
+			it, err := era.NewIterator(e)
+			if err != nil {
+				return fmt.Errorf("error making era reader: %w", err)
+			}
+			var (
+				hashes []common.Hash
+				tds    []*big.Int
+			)
+			for it.Next() {
+				block, receipts, err := it.BlockAndReceipts()
+				if err != nil {
+					return fmt.Errorf("error reading block %d: %w", it.Number(), err)
+				}
+				td, err := it.TotalDifficulty()
+				if err != nil {
+					return fmt.Errorf("error reading total difficulty %d: %w", it.Number(), err)
+				}
+				if block.ParentHash() != parent {
+					return fmt.Errorf("block #%d does not extend %s", block.NumberU64(), parent)
+				}
+				if err := verifyBlockData(block, receipts); err != nil {
+					return fmt.Errorf("invalid block #%d: %w", block.NumberU64(), err)
+				}
+				hashes, tds = append(hashes, block.Hash()), append(tds, td)
+				parent = block.Hash()
+
+				if err := writeBlockData(db, batch, block, receipts); err != nil {
+					return fmt.Errorf("error importing block #%d: %w", block.NumberU64(), err)
+				}
+				if batch.ValueSize() >= ethdb.IdealBatchSize {
+					if err := batch.Write(); err != nil {
+						return err
+					}
+					batch.Reset()
+				}
+				imported++
+
+				// Give the user some feedback that something is happening.
+				if time.Since(reported) >= 8*time.Second {
+					log.Info("Importing Era files", "head", it.Number(), "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+					reported = time.Now()
+				}
+			}
+			if err := it.Error(); err != nil {
+				return fmt.Errorf("error reading era: %w", err)
+			}
+			// The accumulator commits to the hashes the archive was built
+			// with. The blocks written so far are valid regardless, since
+			// each of them is checked against its header and its parent.
+			root, err := era.ComputeAccumulator(hashes, tds)
+			if err != nil {
+				return err
+			}
+			if want, err := e.Accumulator(); err != nil {
+				return err
+			} else if root != want {
+				return fmt.Errorf("accumulator mismatch: have %s, want %s", root, want)
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			return nil
+		}()
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	// The imported lookups extend the transaction index down to the first
+	// imported block if they reach its tail.
+	if tail := rawdb.ReadTxIndexTail(db); tail != nil && *tail > first.NumberU64() && *tail <= last.NumberU64()+1 {
+		rawdb.WriteTxIndexTail(db, first.NumberU64())
+	}
+	log.Info("Imported blockchain history", "first", first.NumberU64(), "last", last.NumberU64(), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// historyRange returns the first and last blocks of the Era1 archives
+// [entries] in [dir].
+func historyRange(dir string, entries []string) (*types.Block, *types.Block, error) {
+	read := func(filename string, first bool) (*types.Block, error) {
+		e, err := era.Open(path.Join(dir, filename))
+		if err != nil {
+			return nil, fmt.Errorf("error opening era: %w", err)
+		}
+		defer e.Close()
+
+		number := e.Start()
+		if !first {
+			number += e.Count() - 1
+		}
+		block, err := e.GetBlockByNumber(number)
+		if err != nil {
+			return nil, fmt.Errorf("error reading block %d: %w", number, err)
+		}
+		return block, nil
+	}
+	first, err := read(entries[0], true)
+	if err != nil {
+		return nil, nil, err
+	}
+	last, err := read(entries[len(entries)-1], false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return first, last, nil
+}
+
+// verifyBlockData checks that the body and receipts of [block] are the ones
+// committed to by its header.
+func verifyBlockData(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("transaction root mismatch: have %s, want %s", hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("uncle root mismatch: have %s, want %s", hash, header.UncleHash)
+	}
+	// The extra data is only committed to since Apricot Phase 1.
+	if want := customtypes.GetHeaderExtra(header).ExtDataHash; want != (common.Hash{}) {
+		if hash := customtypes.CalcExtDataHash(customtypes.BlockExtData(block)); hash != want {
+			return fmt.Errorf("extra data hash mismatch: have %s, want %s", hash, want)
+		}
+	}
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("%d receipts for %d transactions", len(receipts), len(block.Transactions()))
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != header.ReceiptHash {
+		return fmt.Errorf("receipt root mismatch: have %s, want %s", hash, header.ReceiptHash)
+	}
+	if bloom := types.CreateBloom(receipts); bloom != header.Bloom {
+		return errors.New("bloom mismatch")
+	}
+	return nil
+}
+
+// writeBlockData writes [block], its receipts and transaction lookups to
+// [batch] as a canonical block, unless [db] holds another canonical block at
+// the same height.
+func writeBlockData(db ethdb.Reader, batch ethdb.Batch, block *types.Block, receipts types.Receipts) error {
+	hash, number := block.Hash(), block.NumberU64()
+	if canonical := rawdb.ReadCanonicalHash(db, number); canonical != (common.Hash{}) && canonical != hash {
+		return fmt.Errorf("conflicts with canonical block %s", canonical)
+	}
+	rawdb.WriteHeader(batch, block.Header())
+	rawdb.WriteBody(batch, hash, number, block.Body())
+	rawdb.WriteReceipts(batch, hash, number, receipts)
+	rawdb.WriteCanonicalHash(batch, hash, number)
+	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	return nil
+}
+
+// readList reads the lines of [filename].
+func readList(filename string) ([]string, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(b), "\n"), nil
+}
@@ -0,0 +1,252 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package addressindex indexes the transactions of the accepted chain by the
+// addresses appearing in them.
+package addressindex
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/params"
+)
+
+// backfillThrottling is the time to wait between processing two consecutive
+// backfill sections, to keep the backfill from hogging the disk.
+const backfillThrottling = 100 * time.Millisecond
+
+// Chain is the accepted chain indexed by the Indexer
+type Chain interface {
+	core.AcceptedChain
+	Config() *params.ChainConfig
+	GetBlockByNumber(number uint64) *types.Block
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+}
+
+// TraceFunc returns the addresses touched by the call frames of each tx of
+// [block], by tx index.
+type TraceFunc func(block *types.Block) ([][]common.Address, error)
+
+// Status is the progress of the Indexer
+type Status struct {
+	SectionSize uint64 `json:"sectionSize"`
+	// Sections is the number of sections indexed by the backfill, which
+	// covers the blocks [0, Sections*SectionSize).
+	Sections uint64 `json:"sections"`
+	// LiveFrom is the first block indexed as it was accepted
+	LiveFrom uint64 `json:"liveFrom"`
+	// Complete is true once every accepted block is indexed
+	Complete bool `json:"complete"`
+}
+
+// Indexer indexes the appearances of addresses in the txs of accepted
+// blocks: the sender, the recipient or created contract, the emitters of
+// the logs and, if a TraceFunc is given, the addresses touched by internal
+// calls. The blocks accepted before the Indexer started are indexed in the
+// background by a ChainIndexer, one section at a time.
+type Indexer struct {
+	db       ethdb.Database
+	chain    Chain
+	trace    TraceFunc
+	backfill *core.ChainIndexer
+	size     uint64
+	liveFrom uint64
+
+	// tracingFailed warns once that a block could not be traced
+	tracingFailed sync.Once
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New starts indexing the address appearances of [chain] into [db]. [trace]
+// may be nil.
+func New(db ethdb.Database, chain Chain, sectionSize uint64, trace TraceFunc) *Indexer {
+	idx := &Indexer{
+		db:       db,
+		chain:    chain,
+		trace:    trace,
+		size:     sectionSize,
+		liveFrom: chain.LastAcceptedBlock().NumberU64() + 1,
+		quit:     make(chan struct{}),
+	}
+	idx.wg.Add(1)
+	go idx.indexAccepted()
+
+	table := rawdb.NewTable(db, string(sectionsPrefix))
+	idx.backfill = core.NewChainIndexer(db, table, &backfillBackend{idx: idx}, sectionSize, 0, backfillThrottling, "addresses")
+	idx.backfill.StartAccepted(chain)
+	return idx
+}
+
+// indexAccepted indexes the blocks accepted from [idx.liveFrom] on. Tracing
+// a block may take a while, so the accepted events only signal that new
+// blocks are available, which keeps the acceptor from waiting on the index.
+func (idx *Indexer) indexAccepted() {
+	defer idx.wg.Done()
+
+	accepted := make(chan core.ChainEvent, 1)
+	sub := idx.chain.SubscribeChainAcceptedEvent(accepted)
+	defer sub.Unsubscribe()
+	wake := make(chan struct{}, 1)
+	idx.wg.Add(1)
+	go func() {
+		defer idx.wg.Done()
+		for {
+			select {
+			case <-accepted:
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			case <-idx.quit:
+				return
+			}
+		}
+	}()
+
+	next := idx.liveFrom
+	for {
+		for last := idx.chain.LastAcceptedBlock().NumberU64(); next <= last; next++ {
+			select {
+			case <-idx.quit:
+				return
+			default:
+			}
+			block := idx.chain.GetBlockByNumber(next)
+			if block == nil {
+				log.Error("accepted block not found", "number", next)
+				break
+			}
+			batch := idx.db.NewBatch()
+			if err := idx.index(batch, block); err != nil {
+				log.Error("failed to index address appearances", "number", next, "err", err)
+				break
+			}
+			if err := batch.Write(); err != nil {
+				log.Error("failed to write address appearances", "number", next, "err", err)
+				break
+			}
+		}
+		select {
+		case <-wake:
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// index writes the address appearances of [block] to [db]
+func (idx *Indexer) index(db ethdb.KeyValueWriter, block *types.Block) error {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return nil
+	}
+	receipts := idx.chain.GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(txs) {
+		return fmt.Errorf("receipts of block %d not found", block.NumberU64())
+	}
+	signer := types.MakeSigner(idx.chain.Config(), block.Number(), block.Time())
+	addresses := make([][]common.Address, len(txs))
+	for i, tx := range txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		addresses[i] = append(addresses[i], from)
+		if to := tx.To(); to != nil {
+			addresses[i] = append(addresses[i], *to)
+		} else {
+			addresses[i] = append(addresses[i], receipts[i].ContractAddress)
+		}
+		for _, receiptLog := range receipts[i].Logs {
+			addresses[i] = append(addresses[i], receiptLog.Address)
+		}
+	}
+	if idx.trace != nil {
+		traced, err := idx.trace(block)
+		if err == nil && len(traced) == len(txs) {
+			for i := range traced {
+				addresses[i] = append(addresses[i], traced[i]...)
+			}
+		} else {
+			// The parent state of old blocks may not be available to trace
+			idx.tracingFailed.Do(func() {
+				log.Warn("failed to trace block, only indexing the addresses of its receipts", "number", block.NumberU64(), "err", err)
+			})
+		}
+	}
+	return WriteAppearances(db, block.NumberU64(), addresses)
+}
+
+// Status returns the progress of the indexer
+func (idx *Indexer) Status() Status {
+	sections, _, _ := idx.backfill.Sections()
+	return Status{
+		SectionSize: idx.size,
+		Sections:    sections,
+		LiveFrom:    idx.liveFrom,
+		Complete:    sections*idx.size >= idx.liveFrom,
+	}
+}
+
+// Close stops indexing
+func (idx *Indexer) Close() error {
+	close(idx.quit)
+	idx.wg.Wait()
+	return idx.backfill.Close()
+}
+
+// backfillBackend implements core.ChainIndexerBackend, indexing the
+// appearances of a section of the accepted chain.
+type backfillBackend struct {
+	idx   *Indexer
+	batch ethdb.Batch
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section
+func (b *backfillBackend) Reset(ctx context.Context, section uint64, prevHead common.Hash) error {
+	b.batch = b.idx.db.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, indexing the appearances of
+// [header]'s block.
+func (b *backfillBackend) Process(ctx context.Context, header *types.Header) error {
+	block := b.idx.chain.GetBlockByNumber(header.Number.Uint64())
+	if block == nil || block.Hash() != header.Hash() {
+		return fmt.Errorf("block %d (%s) not found", header.Number, header.Hash())
+	}
+	if err := b.idx.index(b.batch, block); err != nil {
+		return err
+	}
+	if b.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := b.batch.Write(); err != nil {
+		return err
+	}
+	b.batch.Reset()
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the remaining
+// appearances of the section.
+func (b *backfillBackend) Commit() error {
+	return b.batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, the index is never pruned
+func (b *backfillBackend) Prune(threshold uint64) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package addressindex
+
+import (
+	"encoding/binary"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/ethdb"
+)
+
+var (
+	// appearancePrefix + address + block number + tx index -> nil
+	appearancePrefix = []byte("addridx_a")
+	// sectionsPrefix is the prefix of the chain indexer metadata
+	sectionsPrefix = []byte("addridx_s")
+)
+
+// appearanceLength is the length of the block number and the tx index
+// following the address in an appearance key
+const appearanceLength = 8 + 4
+
+// firstBeforeWindow is the number of blocks scanned first, going back from
+// the block before which appearances are read. The window doubles until
+// enough appearances are found.
+const firstBeforeWindow = 1024
+
+// Appearance is a transaction in which an address appears
+type Appearance struct {
+	BlockNumber uint64
+	TxIndex     uint
+}
+
+func appearanceKey(addr common.Address, number uint64, txIndex uint) []byte {
+	key := make([]byte, 0, len(appearancePrefix)+common.AddressLength+appearanceLength)
+	key = append(key, appearancePrefix...)
+	key = append(key, addr[:]...)
+	key = binary.BigEndian.AppendUint64(key, number)
+	return binary.BigEndian.AppendUint32(key, uint32(txIndex))
+}
+
+// WriteAppearances stores the appearances of the addresses of each tx of
+// block [number], by tx index.
+func WriteAppearances(db ethdb.KeyValueWriter, number uint64, txAddresses [][]common.Address) error {
+	for i, addresses := range txAddresses {
+		for _, addr := range addresses {
+			if err := db.Put(appearanceKey(addr, number, uint(i)), nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReadAppearancesAfter returns the appearances of [addr] from block [from]
+// on, in ascending order. Once [limit] appearances are read, the remaining
+// appearances of the same block are returned too, so that a page always
+// ends with a whole block. The returned bool is true if there are more.
+func ReadAppearancesAfter(db ethdb.Iteratee, addr common.Address, from uint64, limit int) ([]Appearance, bool, error) {
+	prefix := appearanceKey(addr, 0, 0)[:len(appearancePrefix)+common.AddressLength]
+	it := db.NewIterator(prefix, binary.BigEndian.AppendUint64(nil, from))
+	defer it.Release()
+
+	var appearances []Appearance
+	for it.Next() {
+		a, ok := decodeAppearance(it.Key()[len(prefix):])
+		if !ok {
+			continue
+		}
+		if len(appearances) >= limit && a.BlockNumber != appearances[len(appearances)-1].BlockNumber {
+			return appearances, true, it.Error()
+		}
+		appearances = append(appearances, a)
+	}
+	return appearances, false, it.Error()
+}
+
+// ReadAppearancesBefore returns the appearances of [addr] in the blocks
+// before [before], in descending order. Like ReadAppearancesAfter, a page
+// always ends with a whole block, and the returned bool is true if there
+// are more.
+func ReadAppearancesBefore(db ethdb.Iteratee, addr common.Address, before uint64, limit int) ([]Appearance, bool, error) {
+	var (
+		appearances []Appearance
+		end         = before
+		window      = uint64(firstBeforeWindow)
+	)
+	// The iterators only go forward, so the blocks are scanned in windows
+	// going back from [before].
+	for end > 0 {
+		start := end - min(window, end)
+		found, err := readAppearances(db, addr, start, end)
+		if err != nil {
+			return nil, false, err
+		}
+		for i := len(found) - 1; i >= 0; i-- {
+			a := found[i]
+			if len(appearances) >= limit && a.BlockNumber != appearances[len(appearances)-1].BlockNumber {
+				return appearances, true, nil
+			}
+			appearances = append(appearances, a)
+		}
+		end = start
+		window *= 2
+	}
+	return appearances, false, nil
+}
+
+// readAppearances returns the appearances of [addr] in the blocks
+// [start, end), in ascending order
+func readAppearances(db ethdb.Iteratee, addr common.Address, start, end uint64) ([]Appearance, error) {
+	prefix := appearanceKey(addr, 0, 0)[:len(appearancePrefix)+common.AddressLength]
+	it := db.NewIterator(prefix, binary.BigEndian.AppendUint64(nil, start))
+	defer it.Release()
+
+	var appearances []Appearance
+	for it.Next() {
+		a, ok := decodeAppearance(it.Key()[len(prefix):])
+		if !ok {
+			continue
+		}
+		if a.BlockNumber >= end {
+			break
+		}
+		appearances = append(appearances, a)
+	}
+	return appearances, it.Error()
+}
+
+func decodeAppearance(suffix []byte) (Appearance, bool) {
+	if len(suffix) != appearanceLength {
+		return Appearance{}, false
+	}
+	return Appearance{
+		BlockNumber: binary.BigEndian.Uint64(suffix),
+		TxIndex:     uint(binary.BigEndian.Uint32(suffix[8:])),
+	}, true
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package addressindex
+
+import (
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAppearances(t *testing.T) {
+	var (
+		db    = rawdb.NewMemoryDatabase()
+		alice = common.HexToAddress("0xa1")
+		bob   = common.HexToAddress("0xb0")
+	)
+	// alice appears in 2 txs of block 5, in tx 1 of blocks 2000 and 5000
+	// and with bob in tx 0 of block 5000
+	require.NoError(t, WriteAppearances(db, 5, [][]common.Address{{alice}, {bob}, {alice}}))
+	require.NoError(t, WriteAppearances(db, 2000, [][]common.Address{nil, {alice}}))
+	require.NoError(t, WriteAppearances(db, 5000, [][]common.Address{{alice, bob}, {alice}}))
+
+	after, more, err := ReadAppearancesAfter(db, alice, 0, 1)
+	require.NoError(t, err)
+	require.True(t, more)
+	require.Equal(t, []Appearance{{5, 0}, {5, 2}}, after, "a page ends with a whole block")
+	after, more, err = ReadAppearancesAfter(db, alice, 6, 10)
+	require.NoError(t, err)
+	require.False(t, more)
+	require.Equal(t, []Appearance{{2000, 1}, {5000, 0}, {5000, 1}}, after)
+
+	before, more, err := ReadAppearancesBefore(db, alice, 5001, 3)
+	require.NoError(t, err)
+	require.True(t, more)
+	require.Equal(t, []Appearance{{5000, 1}, {5000, 0}, {2000, 1}}, before)
+	before, more, err = ReadAppearancesBefore(db, alice, 2000, 3)
+	require.NoError(t, err)
+	require.False(t, more)
+	require.Equal(t, []Appearance{{5, 2}, {5, 0}}, before)
+
+	before, more, err = ReadAppearancesBefore(db, bob, 10_000, 10)
+	require.NoError(t, err)
+	require.False(t, more)
+	require.Equal(t, []Appearance{{5000, 0}, {5, 1}}, before)
+}
@@ -43,11 +43,9 @@ import (
 	"github.com/ava-labs/coreth/consensus"
 	"github.com/ava-labs/coreth/consensus/misc/eip4844"
 	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/state/pruner"
 	"github.com/ava-labs/coreth/core/state/snapshot"
 	"github.com/ava-labs/coreth/internal/version"
-	"github.com/ava-labs/coreth/kclients/pause"
-	"github.com/ava-labs/coreth/kclients/syncstatus"
-	"github.com/ava-labs/coreth/kclients/tracecache"
 	"github.com/ava-labs/coreth/params"
 	"github.com/ava-labs/coreth/plugin/evm/customlogs"
 	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
@@ -131,6 +129,8 @@ var (
 	errCacheConfigNotSpecified = errors.New("must specify cache config")
 	errInvalidOldChain         = errors.New("invalid old chain")
 	errInvalidNewChain         = errors.New("invalid new chain")
+
+	errHistoricStateUnsupported = errors.New("historical states are only available with the path scheme")
 )
 
 const (
@@ -195,8 +195,14 @@ type CacheConfig struct {
 	TransactionHistory              uint64  // Number of recent blocks for which to maintain transaction lookup indices
 	SkipTxIndexing                  bool    // Whether to skip transaction indexing
 	StateHistory                    uint64  // Number of blocks from head whose state histories are reserved.
+	HistoricStateDepth              uint64  // Maximum number of state histories reverted to serve a historical state, 0 means no limit
 	StateScheme                     string  // Scheme used to store ethereum states and merkle tree nodes on top
 
+	OnlinePruning           bool          // Whether to prune the stale trie nodes while the chain is running
+	OnlinePruningInterval   time.Duration // Minimum time between the completion and the start of two online pruning rounds
+	OnlinePruningBloomSize  uint64        // Memory allowance (MB) for the bloom filter of an online pruning round
+	OnlinePruningBatchDelay time.Duration // Pause between two batches of trie nodes deleted by the online pruner
+
 	SnapshotNoBuild bool // Whether the background generation is allowed
 	SnapshotWait    bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -214,8 +220,10 @@ func (c *CacheConfig) triedbConfig() *triedb.Config {
 	if c.StateScheme == rawdb.PathScheme {
 		config.DBOverride = pathdb.Config{
 			StateHistory:   c.StateHistory,
+			HistoricDepth:  c.HistoricStateDepth,
 			CleanCacheSize: c.TrieCleanLimit * 1024 * 1024,
 			DirtyCacheSize: c.TrieDirtyLimit * 1024 * 1024,
+			ManualFlatten:  true, // Layers are flattened as blocks are accepted
 		}.BackendConstructor
 	}
 	return config
@@ -269,11 +277,13 @@ type BlockChain struct {
 	chainConfig *params.ChainConfig // Chain & network configuration
 	cacheConfig *CacheConfig        // Cache configuration for pruning
 
-	db           ethdb.Database   // Low level persistent database to store final content in
-	snaps        *snapshot.Tree   // Snapshot tree for fast trie leaf access
-	triedb       *triedb.Database // The database handler for maintaining trie nodes.
-	stateCache   state.Database   // State database to reuse between imports (contains state cache)
-	txIndexer    *txIndexer       // Transaction indexer, might be nil if not enabled
+	db           ethdb.Database       // Low level persistent database to store final content in
+	snaps        *snapshot.Tree       // Snapshot tree for fast trie leaf access
+	triedb       *triedb.Database     // The database handler for maintaining trie nodes.
+	pathdb       *pathdb.Database     // The backend of [triedb] in path mode, used to serve historical states
+	onlinePruner *pruner.OnlinePruner // Tracks the trie nodes written by [triedb], nil if online pruning is disabled
+	stateCache   state.Database       // State database to reuse between imports (contains state cache)
+	txIndexer    *txIndexer           // Transaction indexer, might be nil if not enabled
 	stateManager TrieWriter
 
 	hc                *HeaderChain
@@ -354,6 +364,17 @@ type BlockChain struct {
 
 	// [txIndexTailLock] is used to synchronize the updating of the tx index tail.
 	txIndexTailLock sync.Mutex
+
+	// [stateDiffHook] receives the state diff of every written block if set.
+	// It is guarded by [chainmu].
+	stateDiffHook StateDiffHook
+
+	// [insertedHeight] is the height of the highest block whose state was
+	// written. It is guarded by [chainmu].
+	insertedHeight uint64
+
+	// [onlinePruningOnce] ensures the online pruning rounds are started once.
+	onlinePruningOnce sync.Once
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -367,7 +388,28 @@ func NewBlockChain(
 		return nil, errCacheConfigNotSpecified
 	}
 	// Open trie database with provided config
-	triedb := triedb.NewDatabase(db, cacheConfig.triedbConfig())
+	triedbConfig := cacheConfig.triedbConfig()
+	var onlinePruner *pruner.OnlinePruner
+	if construct := triedbConfig.DBOverride; cacheConfig.OnlinePruning && cacheConfig.Pruning && cacheConfig.StateScheme != rawdb.PathScheme {
+		// Record the trie nodes written while pruning, so they are kept.
+		onlinePruner = pruner.NewOnlinePruner(db, pruner.OnlineConfig{
+			BloomSize:  cacheConfig.OnlinePruningBloomSize,
+			BatchDelay: cacheConfig.OnlinePruningBatchDelay,
+		})
+		triedbConfig.DBOverride = func(diskdb ethdb.Database) triedb.DBOverride {
+			return construct(onlinePruner.Track(diskdb))
+		}
+	}
+	var pathDB *pathdb.Database
+	if construct := triedbConfig.DBOverride; cacheConfig.StateScheme == rawdb.PathScheme {
+		// Keep a handle on the path database to rebuild historical states
+		// from its state histories.
+		triedbConfig.DBOverride = func(diskdb ethdb.Database) triedb.DBOverride {
+			pathDB = construct(diskdb).(*pathdb.Database)
+			return pathDB
+		}
+	}
+	triedb := triedb.NewDatabase(db, triedbConfig)
 
 	// Setup the genesis block, commit the provided genesis specification
 	// to database if the genesis block is not present yet, or load the
@@ -392,6 +434,8 @@ func NewBlockChain(
 		cacheConfig:       cacheConfig,
 		db:                db,
 		triedb:            triedb,
+		pathdb:            pathDB,
+		onlinePruner:      onlinePruner,
 		bodyCache:         lru.NewCache[common.Hash, *types.Body](bodyCacheLimit),
 		receiptsCache:     lru.NewCache[common.Hash, []*types.Receipt](receiptsCacheLimit),
 		blockCache:        lru.NewCache[common.Hash, *types.Block](blockCacheLimit),
@@ -420,7 +464,7 @@ func NewBlockChain(
 	bc.currentBlock.Store(nil)
 
 	// Create the state manager
-	bc.stateManager = NewTrieWriter(bc.triedb, cacheConfig)
+	bc.stateManager = bc.newTrieWriter()
 
 	// Re-generate current block state if it is missing
 	if err := bc.loadLastState(lastAcceptedHash); err != nil {
@@ -475,10 +519,6 @@ func NewBlockChain(
 		bc.txIndexer = newTxIndexer(bc.cacheConfig.TransactionHistory, bc)
 	}
 
-	syncstatus.Start()
-	tracecache.Start(context.Background())
-	pause.Start()
-
 	return bc, nil
 }
 
@@ -968,7 +1008,6 @@ func (bc *BlockChain) stopWithoutSaving() {
 // Stop stops the blockchain service. If any imports are currently in progress
 // it will abort them using the procInterrupt.
 func (bc *BlockChain) Stop() {
-	pause.Stop()
 	bc.stopWithoutSaving()
 
 	// Ensure that the entirety of the state snapshot is journaled to disk.
@@ -992,10 +1031,25 @@ func (bc *BlockChain) Stop() {
 		log.Error("Failed to close trie database", "err", err)
 	}
 
-	tracecache.Stop()
 	log.Info("Blockchain stopped")
 }
 
+// newTrieWriter returns the TrieWriter matching the state scheme.
+func (bc *BlockChain) newTrieWriter() TrieWriter {
+	if bc.pathdb != nil {
+		return NewPathTrieWriter(bc.pathdb, bc.cacheConfig)
+	}
+	return NewTrieWriter(bc.triedb, bc.cacheConfig)
+}
+
+// SetStateDiffHook makes [hook] receive the state diff of every block written
+// from now on. A nil [hook] stops computing state diffs.
+func (bc *BlockChain) SetStateDiffHook(hook StateDiffHook) {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+	bc.stateDiffHook = hook
+}
+
 // SetPreference attempts to update the head block to be the provided block and
 // emits a ChainHeadEvent if successful. This function will handle all reorg
 // side effects, if necessary.
@@ -1207,6 +1261,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, parentRoot common.
 	if err := blockBatch.Write(); err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
+	bc.insertedHeight = max(bc.insertedHeight, block.NumberU64())
 
 	// Commit all cached state changes into underlying memory database.
 	_, err := bc.commitWithSnap(block, parentRoot, state)
@@ -1352,6 +1407,9 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool, tracers []vm.
 	// Enable prefetching to pull in trie node paths while processing transactions
 	statedb.StartPrefetcher("chain", state.WithConcurrentWorkers(bc.cacheConfig.TriePrefetcherParallelism))
 	defer statedb.StopPrefetcher()
+	if writes && bc.stateDiffHook != nil {
+		statedb.TrackTouched()
+	}
 
 	// Process block using the parent state as reference point
 	pstart := time.Now()
@@ -1411,6 +1469,13 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool, tracers []vm.
 	snapshotCommitTimer.Inc(statedb.SnapshotCommits.Milliseconds()) // Snapshot commits are complete, we can mark them
 	triedbCommitTimer.Inc(statedb.TrieDBCommits.Milliseconds())     // Trie database commits are complete, we can mark them
 	blockWriteTimer.Inc((time.Since(wstart) - statedb.AccountCommits - statedb.StorageCommits - statedb.SnapshotCommits - statedb.TrieDBCommits).Milliseconds())
+
+	// The state diff is diagnostic only, so it must not fail a valid block.
+	if bc.stateDiffHook != nil {
+		if err := bc.runStateDiffHook(block, parent.Root, statedb.Touched()); err != nil {
+			log.Warn("Failed to compute state diff", "number", block.Number(), "hash", block.Hash(), "err", err)
+		}
+	}
 	blockInsertTimer.Inc(time.Since(start).Milliseconds())
 
 	log.Debug("Inserted new block", "number", block.Number(), "hash", block.Hash(),
@@ -1427,6 +1492,20 @@ func (bc *BlockChain) insertBlock(block *types.Block, writes bool, tracers []vm.
 	return nil
 }
 
+// runStateDiffHook hands the diff between the committed [parentRoot] and
+// [block] states over the accounts and slots [touched] to the state diff hook.
+func (bc *BlockChain) runStateDiffHook(block *types.Block, parentRoot common.Hash, touched map[common.Address][]common.Hash) error {
+	parentState, err := state.New(parentRoot, bc.stateCache, bc.snaps)
+	if err != nil {
+		return err
+	}
+	postState, err := state.New(block.Root(), bc.stateCache, bc.snaps)
+	if err != nil {
+		return err
+	}
+	return bc.stateDiffHook(block, NewStateDiff(parentState, postState, touched))
+}
+
 // collectUnflattenedLogs collects the logs that were generated or removed during
 // the processing of a block.
 func (bc *BlockChain) collectUnflattenedLogs(b *types.Block, removed bool) [][]*types.Log {
@@ -2127,7 +2206,7 @@ func (bc *BlockChain) ResetToStateSyncedBlock(block *types.Block) error {
 		return err
 	}
 	// Create the state manager
-	bc.stateManager = NewTrieWriter(bc.triedb, bc.cacheConfig)
+	bc.stateManager = bc.newTrieWriter()
 
 	// Make sure the state associated with the block is available
 	head := bc.CurrentBlock()
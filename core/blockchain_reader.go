@@ -28,6 +28,8 @@
 package core
 
 import (
+	"context"
+
 	"github.com/ava-labs/coreth/consensus"
 	"github.com/ava-labs/coreth/core/state"
 	"github.com/ava-labs/coreth/core/state/snapshot"
@@ -36,6 +38,7 @@ import (
 	"github.com/ava-labs/libevm/core/rawdb"
 	"github.com/ava-labs/libevm/core/types"
 	"github.com/ava-labs/libevm/core/vm"
+	"github.com/ava-labs/libevm/ethdb"
 	"github.com/ava-labs/libevm/event"
 	"github.com/ava-labs/libevm/triedb"
 )
@@ -258,6 +261,23 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache, bc.snaps)
 }
 
+// HistoricState returns a state for [root] that is no longer held by the path
+// database, rebuilt from its state histories. Rebuilding stops once [ctx] is
+// done. Mutations of the returned state can't be committed.
+func (bc *BlockChain) HistoricState(ctx context.Context, root common.Hash) (*state.StateDB, error) {
+	if bc.pathdb == nil {
+		return nil, errHistoricStateUnsupported
+	}
+	hdb, err := bc.pathdb.Historic(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	tdb := triedb.NewDatabase(bc.db, &triedb.Config{
+		DBOverride: func(ethdb.Database) triedb.DBOverride { return hdb },
+	})
+	return state.New(root, state.NewDatabaseWithNodeDB(bc.db, tdb), nil)
+}
+
 // Config retrieves the chain's fork configuration.
 func (bc *BlockChain) Config() *params.ChainConfig { return bc.chainConfig }
 
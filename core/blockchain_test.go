@@ -28,6 +28,8 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -167,6 +169,104 @@ func TestPruningBlockChainSnapsDisabled(t *testing.T) {
 	}
 }
 
+func TestPathBlockChain(t *testing.T) {
+	create := func(db ethdb.Database, gspec *Genesis, lastAcceptedHash common.Hash) (*BlockChain, error) {
+		return createBlockChain(
+			db,
+			&CacheConfig{
+				TrieCleanLimit:            256,
+				TrieDirtyLimit:            256,
+				TrieDirtyCommitTarget:     20,
+				TriePrefetcherParallelism: 4,
+				Pruning:                   true,
+				CommitInterval:            4096,
+				SnapshotLimit:             256,
+				AcceptorQueueLimit:        64,
+				StateScheme:               rawdb.PathScheme,
+			},
+			gspec,
+			lastAcceptedHash,
+		)
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tt.testFunc(t, create)
+		})
+	}
+}
+
+func TestPathBlockChainHistoricState(t *testing.T) {
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		chainDB = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  types.GenesisAlloc{addr1: {Balance: big.NewInt(1000000000)}},
+		}
+		cacheConfig = DefaultCacheConfigWithScheme(rawdb.PathScheme)
+	)
+	blockchain, err := createBlockChain(chainDB, cacheConfig, gspec, common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	numBlocks := 2 * TipBufferSize
+	signer := types.HomesteadSigner{}
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, numBlocks, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range chain {
+		if err := blockchain.Accept(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blockchain.DrainAcceptorQueue()
+
+	// Recent states are still held in the diff layers.
+	for _, block := range chain[numBlocks-TipBufferSize:] {
+		if _, err := blockchain.StateAt(block.Root()); err != nil {
+			t.Fatalf("state of block %d is not available: %v", block.NumberU64(), err)
+		}
+	}
+	// Older states are rebuilt from the state histories.
+	for _, block := range chain[:numBlocks-TipBufferSize-1] {
+		if _, err := blockchain.StateAt(block.Root()); err == nil {
+			t.Fatalf("state of block %d should have been flattened", block.NumberU64())
+		}
+		statedb, err := blockchain.HistoricState(context.Background(), block.Root())
+		if err != nil {
+			t.Fatalf("failed to rebuild state of block %d: %v", block.NumberU64(), err)
+		}
+		want := new(big.Int).Mul(big.NewInt(10000), new(big.Int).SetUint64(block.NumberU64()))
+		if got := statedb.GetBalance(addr2).ToBig(); got.Cmp(want) != 0 {
+			t.Fatalf("unexpected balance at block %d, want %d, got %d", block.NumberU64(), want, got)
+		}
+		if got := statedb.GetNonce(addr1); got != block.NumberU64() {
+			t.Fatalf("unexpected nonce at block %d, want %d, got %d", block.NumberU64(), block.NumberU64(), got)
+		}
+	}
+	// Historic states are not served with the hash scheme.
+	hashChain, err := createBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hashChain.Stop()
+	if _, err := hashChain.HistoricState(context.Background(), chain[0].Root()); !errors.Is(err, errHistoricStateUnsupported) {
+		t.Fatalf("unexpected error, want %v, got %v", errHistoricStateUnsupported, err)
+	}
+}
+
 type wrappedStateManager struct {
 	TrieWriter
 }
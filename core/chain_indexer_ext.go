@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/event"
+)
+
+// AcceptedChain is the chain whose accepted blocks are indexed by a
+// ChainIndexer started with StartAccepted.
+type AcceptedChain interface {
+	LastAcceptedBlock() *types.Block
+	SubscribeChainAcceptedEvent(ch chan<- ChainEvent) event.Subscription
+}
+
+// StartAccepted is Start for the accepted chain: the accepted blocks are fed
+// to the indexer as chain heads, so that preferred blocks which may still be
+// rejected are never indexed.
+func (c *ChainIndexer) StartAccepted(chain AcceptedChain) {
+	c.Start(acceptedIndexerChain{chain})
+}
+
+// acceptedIndexerChain implements ChainIndexerChain over the accepted chain
+type acceptedIndexerChain struct {
+	AcceptedChain
+}
+
+func (c acceptedIndexerChain) CurrentHeader() *types.Header {
+	return c.LastAcceptedBlock().Header()
+}
+
+func (c acceptedIndexerChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Subscription {
+	accepted := make(chan ChainEvent, 1)
+	sub := c.SubscribeChainAcceptedEvent(accepted)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-accepted:
+				select {
+				case ch <- ChainHeadEvent{Block: ev.Block}:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
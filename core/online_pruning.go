@@ -0,0 +1,120 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/coreth/core/state/pruner"
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+	"github.com/ava-labs/libevm/log"
+)
+
+// StartOnlinePruning starts the rounds of the online state pruner if it is
+// enabled. It must be called once the state is synced, since the trie nodes
+// written by the state syncer are not tracked by the pruner.
+func (bc *BlockChain) StartOnlinePruning() {
+	if bc.onlinePruner == nil {
+		return
+	}
+	bc.onlinePruningOnce.Do(func() {
+		if bc.stopping.Load() {
+			return
+		}
+		log.Info("Starting online pruning", "interval", bc.cacheConfig.OnlinePruningInterval)
+		bc.wg.Add(1)
+		go func() {
+			defer bc.wg.Done()
+			bc.onlinePruningLoop()
+		}()
+	})
+}
+
+// onlinePruningLoop runs a round of online pruning once the configured
+// interval has elapsed since the last one completed, until the chain stops.
+// An interrupted round is resumed right away.
+func (bc *BlockChain) onlinePruningLoop() {
+	var delay time.Duration
+	if pending, err := bc.onlinePruner.Pending(); err != nil {
+		log.Error("Failed to read online pruning progress", "err", err)
+		return
+	} else if !pending {
+		if last, err := customrawdb.ReadOnlinePruning(bc.db); err == nil {
+			delay = bc.cacheConfig.OnlinePruningInterval - time.Since(last)
+		}
+	}
+	for {
+		select {
+		case <-time.After(delay):
+		case <-bc.quit:
+			return
+		}
+		err := bc.onlinePruningRound()
+		if errors.Is(err, pruner.ErrAborted) {
+			return
+		}
+		if err != nil {
+			log.Error("Online pruning round failed", "err", err)
+		}
+		delay = bc.cacheConfig.OnlinePruningInterval
+	}
+}
+
+// onlinePruningRound prunes the trie nodes which are not part of the first
+// state committed at or above every block inserted when the round starts.
+// The blocks inserted before the round may have flushed nodes to disk which
+// are not recorded by the pruner, but they are all ancestors of the target
+// or rejected, so their nodes are either part of the target state or stale.
+//
+// The accepted states kept in memory by the tip buffer may still reference
+// disk nodes replaced before the target, so the sweep only starts once the
+// target is the oldest state of the tip buffer.
+func (bc *BlockChain) onlinePruningRound() error {
+	bc.chainmu.Lock()
+	err := bc.onlinePruner.Begin()
+	height := max(bc.insertedHeight, bc.lastAccepted.NumberU64())
+	bc.chainmu.Unlock()
+	if err != nil {
+		return err
+	}
+	interval := bc.cacheConfig.CommitInterval
+	target := (height + interval - 1) / interval * interval
+	if err := bc.waitAccepted(target); err != nil {
+		bc.onlinePruner.Abort()
+		return err
+	}
+	block := bc.GetBlockByNumber(target)
+	if block == nil {
+		bc.onlinePruner.Abort()
+		return fmt.Errorf("missing accepted block %d", target)
+	}
+	if err := bc.waitAccepted(target + TipBufferSize - 1); err != nil {
+		bc.onlinePruner.Abort()
+		return err
+	}
+	return bc.onlinePruner.Prune(block.Root(), bc.quit)
+}
+
+// waitAccepted blocks until the acceptor has processed the block at [height],
+// so its state is committed.
+func (bc *BlockChain) waitAccepted(height uint64) error {
+	var (
+		acceptedCh = make(chan ChainEvent, 1)
+		sub        = bc.SubscribeChainAcceptedEvent(acceptedCh)
+	)
+	defer sub.Unsubscribe()
+
+	for bc.LastAcceptedBlock().NumberU64() < height {
+		select {
+		case <-acceptedCh:
+		case err := <-sub.Err():
+			return err
+		case <-bc.quit:
+			return pruner.ErrAborted
+		}
+	}
+	return nil
+}
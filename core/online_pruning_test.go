@@ -0,0 +1,146 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnlinePruning(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		key2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+		addr3   = common.Address{3}
+		chainDB = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{
+			Config: &params.ChainConfig{HomesteadBlock: new(big.Int)},
+			Alloc:  types.GenesisAlloc{addr1: {Balance: big.NewInt(1000000000)}},
+		}
+		cacheConfig = &CacheConfig{
+			TrieCleanLimit:         0, // read the pruned nodes from disk
+			TrieDirtyLimit:         256,
+			TrieDirtyCommitTarget:  20,
+			Pruning:                true,
+			CommitInterval:         4,
+			AcceptorQueueLimit:     64,
+			OnlinePruning:          true,
+			OnlinePruningBloomSize: 1,
+		}
+	)
+	blockchain, err := createBlockChain(chainDB, cacheConfig, gspec, common.Hash{})
+	require.NoError(err)
+	defer blockchain.Stop()
+
+	signer := types.HomesteadSigner{}
+	_, chain, _, err := GenerateChainWithGenesis(gspec, blockchain.engine, 60, 10, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr2, big.NewInt(10000), params.TxGas, nil, nil), signer, key1)
+		gen.AddTx(tx)
+		// [addr3] is left unchanged from block 20 to block 24
+		if i == 19 || i == 23 {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr1), addr3, common.Big1, params.TxGas, nil, nil), signer, key1)
+			gen.AddTx(tx)
+		}
+	})
+	require.NoError(err)
+	insertAndAccept := func(blocks types.Blocks) {
+		_, err := blockchain.InsertChain(blocks)
+		require.NoError(err)
+		for _, block := range blocks {
+			require.NoError(blockchain.Accept(block))
+		}
+		blockchain.DrainAcceptorQueue()
+	}
+	insertAndAccept(chain[:20])
+
+	// The states committed every [CommitInterval] blocks are on disk.
+	for _, block := range chain[:20] {
+		if block.NumberU64()%cacheConfig.CommitInterval == 0 {
+			require.True(rawdb.HasLegacyTrieNode(chainDB, block.Root()), "state of block %d", block.NumberU64())
+		}
+	}
+	// A stale node is deleted, unless it is written during the round.
+	var (
+		stale   = crypto.Keccak256Hash([]byte("stale"))
+		written = crypto.Keccak256Hash([]byte("written"))
+	)
+	rawdb.WriteLegacyTrieNode(chainDB, stale, []byte("stale"))
+	rawdb.WriteLegacyTrieNode(chainDB, written, []byte("written"))
+
+	require.NoError(blockchain.onlinePruner.Begin())
+	pending, err := blockchain.onlinePruner.Pending()
+	require.NoError(err)
+	require.True(pending)
+	rawdb.WriteLegacyTrieNode(blockchain.onlinePruner.Track(chainDB), written, []byte("written"))
+	require.NoError(blockchain.onlinePruner.Prune(chain[19].Root(), nil))
+
+	require.False(rawdb.HasLegacyTrieNode(chainDB, stale))
+	require.True(rawdb.HasLegacyTrieNode(chainDB, written))
+	for _, block := range chain[:19] {
+		require.False(rawdb.HasLegacyTrieNode(chainDB, block.Root()), "state of block %d", block.NumberU64())
+	}
+	require.True(rawdb.HasLegacyTrieNode(chainDB, blockchain.Genesis().Root()))
+	pending, err = blockchain.onlinePruner.Pending()
+	require.NoError(err)
+	require.False(pending)
+	_, err = customrawdb.ReadOnlinePruning(chainDB)
+	require.NoError(err)
+
+	// The chain keeps processing blocks on top of the pruned state, and a
+	// round targets the next committed state. The sweep waits until the
+	// target is the oldest state kept in memory.
+	checkBalance := func(block *types.Block) {
+		statedb, err := blockchain.StateAt(block.Root())
+		require.NoError(err, "state of block %d", block.NumberU64())
+		require.Equal(new(big.Int).Mul(big.NewInt(10000), new(big.Int).SetUint64(block.NumberU64())), statedb.GetBalance(addr2).ToBig())
+	}
+	insertAndAccept(chain[20:23])
+	_, err = blockchain.InsertChain(chain[23:24])
+	require.NoError(err)
+	done := make(chan error, 1)
+	go func() { done <- blockchain.onlinePruningRound() }()
+	require.Eventually(func() bool {
+		pending, err := blockchain.onlinePruner.Pending()
+		return err == nil && pending
+	}, time.Second, time.Millisecond)
+	require.NoError(blockchain.Accept(chain[23]))
+	blockchain.DrainAcceptorQueue()
+
+	// The target is committed, but the states below it are still in memory
+	// and reference nodes replaced by the target, so they are not swept yet.
+	require.Never(func() bool { return len(done) > 0 }, 100*time.Millisecond, time.Millisecond)
+	for _, block := range chain[20:23] {
+		checkBalance(block)
+		statedb, err := blockchain.StateAt(block.Root())
+		require.NoError(err)
+		require.Equal(common.Big1, statedb.GetBalance(addr3).ToBig(), "state of block %d", block.NumberU64())
+	}
+	insertAndAccept(chain[24 : 23+TipBufferSize])
+	require.NoError(<-done)
+
+	require.False(rawdb.HasLegacyTrieNode(chainDB, chain[19].Root()))
+	require.True(rawdb.HasLegacyTrieNode(chainDB, chain[23].Root()))
+
+	// The states of the last accepted blocks are still readable.
+	for _, block := range chain[23 : 23+TipBufferSize] {
+		checkBalance(block)
+	}
+	insertAndAccept(chain[23+TipBufferSize:])
+	for _, block := range chain[len(chain)-TipBufferSize:] {
+		checkBalance(block)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pruner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/ava-labs/libevm/metrics"
+)
+
+// ErrAborted is returned when pruning is interrupted before completion.
+var ErrAborted = errors.New("pruning aborted")
+
+// sweepBatchKeys is the maximum number of keys visited in a sweep batch, so
+// the progress is persisted and throttled even if few nodes are stale.
+const sweepBatchKeys = 100_000
+
+var (
+	onlineRunningGauge   = metrics.NewRegisteredGauge("state/pruner/online/running", nil)
+	onlineProgressGauge  = metrics.NewRegisteredGauge("state/pruner/online/progress", nil)
+	onlineRoundsCounter  = metrics.NewRegisteredCounter("state/pruner/online/rounds", nil)
+	onlineMarkedCounter  = metrics.NewRegisteredCounter("state/pruner/online/marked", nil)
+	onlineSweptCounter   = metrics.NewRegisteredCounter("state/pruner/online/swept", nil)
+	onlineDeletedCounter = metrics.NewRegisteredCounter("state/pruner/online/deleted", nil)
+	onlineBytesCounter   = metrics.NewRegisteredCounter("state/pruner/online/deleted/bytes", nil)
+)
+
+// OnlineConfig includes the configurations for online pruning.
+type OnlineConfig struct {
+	BloomSize  uint64        // The Megabytes of memory allocated to the bloom filter of a round
+	BatchDelay time.Duration // Pause between two deletion batches, throttling the disk usage
+}
+
+// OnlinePruner deletes the stale trie nodes of the hash based scheme while
+// the node keeps processing blocks. A pruning round works as follows:
+//
+//   - [OnlinePruner.Begin] starts recording the trie nodes written through
+//     the databases returned by [OnlinePruner.Track]
+//   - [OnlinePruner.Prune] marks the nodes of the target state and of the
+//     genesis state, then iterates the database in batches and deletes the
+//     trie nodes which were neither marked nor recorded
+//
+// The caller must pick a target state such that every state which may still
+// be committed is either a descendant of the target or only references nodes
+// written after [OnlinePruner.Begin]. The progress of the sweep is persisted,
+// an interrupted round is resumed by a new round with a fresh target.
+//
+// Contract code is left in place since it is not written through the trie
+// database.
+type OnlinePruner struct {
+	config OnlineConfig
+	db     ethdb.Database
+
+	// [lock] is held for reading while recording nodes and for writing while
+	// deleting a batch, so a node is never deleted after being recorded.
+	lock  sync.RWMutex
+	bloom *stateBloom // Marked and recorded nodes of the running round, nil if there is none
+}
+
+// NewOnlinePruner creates an online pruner of the trie nodes in [db].
+func NewOnlinePruner(db ethdb.Database, config OnlineConfig) *OnlinePruner {
+	return &OnlinePruner{
+		config: config,
+		db:     db,
+	}
+}
+
+// Track wraps [db] so the trie nodes written through it are kept by the
+// running round. It must wrap the database of the trie database writing the
+// new states.
+func (p *OnlinePruner) Track(db ethdb.Database) ethdb.Database {
+	return &trackedDatabase{Database: db, pruner: p}
+}
+
+// Begin starts a pruning round by recording the trie nodes written from now
+// on. If the last round was interrupted, it is resumed where it stopped.
+func (p *OnlinePruner) Begin() error {
+	bloom, err := newStateBloomWithSize(p.config.BloomSize)
+	if err != nil {
+		return err
+	}
+	progress, err := customrawdb.ReadOnlinePruningProgress(p.db)
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		progress = new(customrawdb.OnlinePruningProgress)
+		if err := customrawdb.WriteOnlinePruningProgress(p.db, progress); err != nil {
+			return err
+		}
+	}
+	p.lock.Lock()
+	p.bloom = bloom
+	p.lock.Unlock()
+
+	onlineRunningGauge.Update(1)
+	log.Info("Started online pruning round", "next", common.Bytes2Hex(progress.Next), "deleted", progress.Deleted)
+	return nil
+}
+
+// Abort ends the running round, which is resumed by the next one.
+func (p *OnlinePruner) Abort() {
+	p.lock.Lock()
+	p.bloom = nil
+	p.lock.Unlock()
+
+	onlineRunningGauge.Update(0)
+}
+
+// Pending returns whether a round was started and did not complete.
+func (p *OnlinePruner) Pending() (bool, error) {
+	progress, err := customrawdb.ReadOnlinePruningProgress(p.db)
+	return progress != nil, err
+}
+
+// Prune completes the running round by deleting the trie nodes which belong
+// neither to the state [root] nor to the genesis state, unless they were
+// written since [OnlinePruner.Begin]. It returns [ErrAborted] once [quit] is
+// closed, the round can then be resumed.
+func (p *OnlinePruner) Prune(root common.Hash, quit <-chan struct{}) error {
+	defer p.Abort()
+
+	p.lock.RLock()
+	bloom := p.bloom
+	p.lock.RUnlock()
+	if bloom == nil {
+		return errors.New("online pruning round not started")
+	}
+	if !rawdb.HasLegacyTrieNode(p.db, root) {
+		return fmt.Errorf("associated state[%x] is not present", root)
+	}
+	start := time.Now()
+	log.Info("Marking state for online pruning", "root", root)
+	nodes, err := extractState(p.db, root, bloom, quit)
+	onlineMarkedCounter.Inc(int64(nodes))
+	if err != nil {
+		return err
+	}
+	if err := extractGenesis(p.db, bloom); err != nil {
+		return err
+	}
+	log.Info("Marked state for online pruning", "root", root, "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	if err := p.sweep(bloom, quit); err != nil {
+		return err
+	}
+	if err := customrawdb.DeleteOnlinePruningProgress(p.db); err != nil {
+		return err
+	}
+	if err := customrawdb.WriteOnlinePruning(p.db); err != nil {
+		return err
+	}
+	onlineRoundsCounter.Inc(1)
+	log.Info("Online pruning round completed", "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// sweep deletes the trie nodes missing from [bloom], starting from the
+// persisted progress.
+func (p *OnlinePruner) sweep(bloom *stateBloom, quit <-chan struct{}) error {
+	progress, err := customrawdb.ReadOnlinePruningProgress(p.db)
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		progress = new(customrawdb.OnlinePruningProgress)
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		stale  []staleNode
+		size   int
+	)
+	for {
+		// Collect a batch of stale trie nodes, the iterator is recreated for
+		// every batch to allow the underlying compactor to delete the entries.
+		var (
+			iter    = p.db.NewIterator(nil, progress.Next)
+			done    = true
+			visited int
+		)
+		for iter.Next() {
+			key := iter.Key()
+			visited++
+			if len(key) == common.HashLength && !bloom.Contain(key) {
+				stale = append(stale, staleNode{key: common.CopyBytes(key), size: len(key) + len(iter.Value())})
+				size += len(key) + len(iter.Value())
+			}
+			if size >= ethdb.IdealBatchSize || visited >= sweepBatchKeys {
+				progress.Next = append(common.CopyBytes(key), 0)
+				done = false
+				break
+			}
+		}
+		onlineSweptCounter.Inc(int64(visited))
+		err := iter.Error()
+		iter.Release()
+		if err != nil {
+			return fmt.Errorf("failed to iterate db during online pruning: %w", err)
+		}
+		if done {
+			progress.Next = nil
+		}
+		if err := p.deleteStale(bloom, stale, progress); err != nil {
+			return err
+		}
+		stale, size = stale[:0], 0
+
+		if len(progress.Next) >= 2 {
+			onlineProgressGauge.Update(int64(binary.BigEndian.Uint16(progress.Next)) * 100 / 65536)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Pruning state data online", "deleted", progress.Deleted, "next", common.Bytes2Hex(progress.Next),
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		if done {
+			onlineProgressGauge.Update(100)
+			log.Info("Pruned state data online", "deleted", progress.Deleted, "elapsed", common.PrettyDuration(time.Since(start)))
+			return nil
+		}
+		select {
+		case <-quit:
+			return ErrAborted
+		case <-time.After(p.config.BatchDelay):
+		}
+	}
+}
+
+// deleteStale deletes the [stale] trie nodes which have not been recorded in
+// the meantime and persists [progress] atomically.
+func (p *OnlinePruner) deleteStale(bloom *stateBloom, stale []staleNode, progress *customrawdb.OnlinePruningProgress) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	batch := p.db.NewBatch()
+	for _, node := range stale {
+		if bloom.Contain(node.key) {
+			continue
+		}
+		if err := batch.Delete(node.key); err != nil {
+			return err
+		}
+		progress.Deleted++
+		onlineDeletedCounter.Inc(1)
+		onlineBytesCounter.Inc(int64(node.size))
+	}
+	if err := customrawdb.WriteOnlinePruningProgress(batch, progress); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// staleNode is a trie node found stale by the sweep.
+type staleNode struct {
+	key  []byte
+	size int
+}
+
+// keep records [key] in the running round if it is a trie node.
+func (p *OnlinePruner) keep(key []byte) {
+	if len(key) != common.HashLength {
+		return
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.bloom != nil {
+		p.bloom.Put(key, nil)
+	}
+}
+
+// trackedDatabase records the trie nodes written to the wrapped database in
+// the running round of [pruner].
+type trackedDatabase struct {
+	ethdb.Database
+	pruner *OnlinePruner
+}
+
+func (db *trackedDatabase) Put(key []byte, value []byte) error {
+	db.pruner.keep(key)
+	return db.Database.Put(key, value)
+}
+
+func (db *trackedDatabase) NewBatch() ethdb.Batch {
+	return &trackedBatch{Batch: db.Database.NewBatch(), pruner: db.pruner}
+}
+
+func (db *trackedDatabase) NewBatchWithSize(size int) ethdb.Batch {
+	return &trackedBatch{Batch: db.Database.NewBatchWithSize(size), pruner: db.pruner}
+}
+
+// trackedBatch records the trie nodes written to the wrapped batch. The nodes
+// are recorded before being written, so a concurrent sweep either keeps them
+// or deletes them before they are written.
+type trackedBatch struct {
+	ethdb.Batch
+	pruner *OnlinePruner
+}
+
+func (b *trackedBatch) Put(key []byte, value []byte) error {
+	b.pruner.keep(key)
+	return b.Batch.Put(key, value)
+}
@@ -350,46 +350,62 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 	if genesis == nil {
 		return errors.New("missing genesis block")
 	}
-	t, err := trie.NewStateTrie(trie.StateTrieID(genesis.Root()), triedb.NewDatabase(db, triedb.HashDefaults))
+	_, err := extractState(db, genesis.Root(), stateBloom, nil)
+	return err
+}
+
+// extractState loads the state of [root] and commits all the state entries
+// into the given writer, returning the number of trie nodes committed. The
+// traversal is aborted with [ErrAborted] once [quit] is closed.
+func extractState(db ethdb.Database, root common.Hash, stateBloom ethdb.KeyValueWriter, quit <-chan struct{}) (int, error) {
+	t, err := trie.NewStateTrie(trie.StateTrieID(root), triedb.NewDatabase(db, triedb.HashDefaults))
 	if err != nil {
-		return err
+		return 0, err
 	}
 	accIter, err := t.NodeIterator(nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	var nodes int
 	for accIter.Next(true) {
 		hash := accIter.Hash()
 
 		// Embedded nodes don't have hash.
 		if hash != (common.Hash{}) {
 			stateBloom.Put(hash.Bytes(), nil)
+			nodes++
 		}
 		// If it's a leaf node, yes we are touching an account,
 		// dig into the storage trie further.
 		if accIter.Leaf() {
+			select {
+			case <-quit:
+				return nodes, ErrAborted
+			default:
+			}
 			var acc types.StateAccount
 			if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
-				return err
+				return nodes, err
 			}
 			if acc.Root != types.EmptyRootHash {
-				id := trie.StorageTrieID(genesis.Root(), common.BytesToHash(accIter.LeafKey()), acc.Root)
+				id := trie.StorageTrieID(root, common.BytesToHash(accIter.LeafKey()), acc.Root)
 				storageTrie, err := trie.NewStateTrie(id, triedb.NewDatabase(db, triedb.HashDefaults))
 				if err != nil {
-					return err
+					return nodes, err
 				}
 				storageIter, err := storageTrie.NodeIterator(nil)
 				if err != nil {
-					return err
+					return nodes, err
 				}
 				for storageIter.Next(true) {
 					hash := storageIter.Hash()
 					if hash != (common.Hash{}) {
 						stateBloom.Put(hash.Bytes(), nil)
+						nodes++
 					}
 				}
 				if storageIter.Error() != nil {
-					return storageIter.Error()
+					return nodes, storageIter.Error()
 				}
 			}
 			if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
@@ -397,7 +413,7 @@ func extractGenesis(db ethdb.Database, stateBloom *stateBloom) error {
 			}
 		}
 	}
-	return accIter.Error()
+	return nodes, accIter.Error()
 }
 
 func bloomFilterName(datadir string, hash common.Hash) string {
@@ -59,6 +59,9 @@ type StateDB struct {
 	// Some fields remembered as they are used in tests
 	db    Database
 	snaps ethstate.SnapshotTree
+
+	// touched records the writes if not nil, see TrackTouched
+	touched touchedSet
 }
 
 // New creates a new state from a given trie.
@@ -105,6 +108,7 @@ func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
 
 // AddBalance adds amount to the account associated with addr.
 func (s *StateDB) AddBalanceMultiCoin(addr common.Address, coinID common.Hash, amount *big.Int) {
+	s.touchMultiCoin(addr, coinID, amount)
 	if amount.Sign() == 0 {
 		s.AddBalance(addr, new(uint256.Int)) // used to cause touch
 		return
@@ -119,6 +123,7 @@ func (s *StateDB) AddBalanceMultiCoin(addr common.Address, coinID common.Hash, a
 
 // SubBalance subtracts amount from the account associated with addr.
 func (s *StateDB) SubBalanceMultiCoin(addr common.Address, coinID common.Hash, amount *big.Int) {
+	s.touchMultiCoin(addr, coinID, amount)
 	if amount.Sign() == 0 {
 		return
 	}
@@ -135,6 +140,7 @@ func (s *StateDB) SubBalanceMultiCoin(addr common.Address, coinID common.Hash, a
 
 func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
 	NormalizeStateKey(&key)
+	s.touchSlot(addr, key)
 	s.StateDB.SetState(addr, key, value)
 }
 
@@ -159,6 +165,7 @@ func (s *StateDB) Copy() *StateDB {
 		snaps:   s.snaps,
 		thash:   s.thash,
 		txIndex: s.txIndex,
+		touched: s.touched.copy(),
 	}
 }
 
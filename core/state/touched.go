@@ -0,0 +1,149 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/holiman/uint256"
+)
+
+// touchedSet holds the accounts written through a StateDB and, per account,
+// the storage keys written, as stored ie. normalized.
+type touchedSet map[common.Address]map[common.Hash]struct{}
+
+// copy returns a deep copy of [t], nil if [t] is
+func (t touchedSet) copy() touchedSet {
+	if t == nil {
+		return nil
+	}
+	cpy := make(touchedSet, len(t))
+	for addr, slots := range t {
+		cpySlots := make(map[common.Hash]struct{}, len(slots))
+		for key := range slots {
+			cpySlots[key] = struct{}{}
+		}
+		cpy[addr] = cpySlots
+	}
+	return cpy
+}
+
+func (t touchedSet) account(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := t[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		t[addr] = slots
+	}
+	return slots
+}
+
+// TrackTouched makes the StateDB record every account and storage slot
+// written from now on, including writes which are reverted later. Copy
+// carries the recorded set over.
+func (s *StateDB) TrackTouched() {
+	s.touched = make(touchedSet)
+}
+
+// Touched returns the accounts written since TrackTouched was called, mapped
+// to the storage keys written. Keys are normalized, so multicoin balances are
+// the keys with the lowest bit of the first byte set. It returns nil if the
+// StateDB does not track writes.
+func (s *StateDB) Touched() map[common.Address][]common.Hash {
+	if s.touched == nil {
+		return nil
+	}
+	touched := make(map[common.Address][]common.Hash, len(s.touched))
+	for addr, slots := range s.touched {
+		keys := make([]common.Hash, 0, len(slots))
+		for key := range slots {
+			keys = append(keys, key)
+		}
+		touched[addr] = keys
+	}
+	return touched
+}
+
+// touch records a write of [addr]
+func (s *StateDB) touch(addr common.Address) {
+	if s.touched != nil {
+		s.touched.account(addr)
+	}
+}
+
+// touchSlot records a write of the normalized storage [key] of [addr]
+func (s *StateDB) touchSlot(addr common.Address, key common.Hash) {
+	if s.touched != nil {
+		s.touched.account(addr)[key] = struct{}{}
+	}
+}
+
+func (s *StateDB) CreateAccount(addr common.Address) {
+	s.touch(addr)
+	s.StateDB.CreateAccount(addr)
+}
+
+func (s *StateDB) AddBalance(addr common.Address, amount *uint256.Int) {
+	s.touch(addr)
+	s.StateDB.AddBalance(addr, amount)
+}
+
+func (s *StateDB) SubBalance(addr common.Address, amount *uint256.Int) {
+	s.touch(addr)
+	s.StateDB.SubBalance(addr, amount)
+}
+
+func (s *StateDB) SetBalance(addr common.Address, amount *uint256.Int) {
+	s.touch(addr)
+	s.StateDB.SetBalance(addr, amount)
+}
+
+func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	s.touch(addr)
+	s.StateDB.SetNonce(addr, nonce)
+}
+
+func (s *StateDB) SetCode(addr common.Address, code []byte) {
+	s.touch(addr)
+	s.StateDB.SetCode(addr, code)
+}
+
+func (s *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	if s.touched != nil {
+		for key := range storage {
+			s.touchSlot(addr, key)
+		}
+		s.touch(addr)
+	}
+	s.StateDB.SetStorage(addr, storage)
+}
+
+func (s *StateDB) SelfDestruct(addr common.Address) {
+	s.touch(addr)
+	s.StateDB.SelfDestruct(addr)
+}
+
+func (s *StateDB) Selfdestruct6780(addr common.Address) {
+	s.touch(addr)
+	s.StateDB.Selfdestruct6780(addr)
+}
+
+// multiCoinKey returns the storage key of the balance of [coinID]
+func multiCoinKey(coinID common.Hash) common.Hash {
+	NormalizeCoinID(&coinID)
+	return coinID
+}
+
+// IsMultiCoinKey returns whether the normalized storage [key] holds a
+// multicoin balance rather than contract storage.
+func IsMultiCoinKey(key common.Hash) bool {
+	return key[0]&0x01 == 1
+}
+
+// touchMultiCoin records a write of the balance of [coinID] of [addr]
+func (s *StateDB) touchMultiCoin(addr common.Address, coinID common.Hash, amount *big.Int) {
+	if s.touched != nil && amount.Sign() != 0 {
+		s.touchSlot(addr, multiCoinKey(coinID))
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+
+	"github.com/ava-labs/coreth/core/state"
+)
+
+// StateDiffHook receives the state changes of every block written to the
+// chain, whether the block is accepted later or not. An error returned by the
+// hook is logged and does not fail the insertion of the block.
+type StateDiffHook func(block *types.Block, diff *StateDiff) error
+
+// StateDiff holds the accounts changed by a block, sorted by address.
+type StateDiff struct {
+	Accounts []*AccountDiff `json:"accounts"`
+}
+
+// Change is the value of a field before and after a block
+type Change[T any] struct {
+	From T `json:"from"`
+	To   T `json:"to"`
+}
+
+// AccountDiff holds the changed fields of an account. The storage of a
+// destroyed account is cleared, only the slots written by the block are
+// listed.
+type AccountDiff struct {
+	Address   common.Address `json:"address"`
+	Created   bool           `json:"created,omitempty"`
+	Destroyed bool           `json:"destroyed,omitempty"`
+
+	Balance *Change[*hexutil.Big]   `json:"balance,omitempty"`
+	Nonce   *Change[hexutil.Uint64] `json:"nonce,omitempty"`
+	// Code holds the code hashes, and NewCode the code deployed by the block
+	Code    *Change[common.Hash] `json:"code,omitempty"`
+	NewCode hexutil.Bytes        `json:"newCode,omitempty"`
+	// Storage is keyed by the normalized storage key
+	Storage map[common.Hash]*Change[common.Hash] `json:"storage,omitempty"`
+	// MultiCoin is keyed by the normalized coin ID
+	MultiCoin map[common.Hash]*Change[*hexutil.Big] `json:"multiCoin,omitempty"`
+}
+
+// NewStateDiff compares the accounts and slots [touched] while processing a
+// block, or any sequence of txs, between the [parent] state and the finalised
+// [post] state.
+func NewStateDiff(parent, post *state.StateDB, touched map[common.Address][]common.Hash) *StateDiff {
+	diff := &StateDiff{Accounts: make([]*AccountDiff, 0, len(touched))}
+	for addr, keys := range touched {
+		account := &AccountDiff{
+			Address: addr,
+		}
+		existed, exists := parent.Exist(addr), post.Exist(addr)
+		account.Created = !existed && exists
+		account.Destroyed = existed && !exists
+
+		if from, to := parent.GetBalance(addr), post.GetBalance(addr); !from.Eq(to) {
+			account.Balance = &Change[*hexutil.Big]{(*hexutil.Big)(from.ToBig()), (*hexutil.Big)(to.ToBig())}
+		}
+		if from, to := parent.GetNonce(addr), post.GetNonce(addr); from != to {
+			account.Nonce = &Change[hexutil.Uint64]{hexutil.Uint64(from), hexutil.Uint64(to)}
+		}
+		if from, to := parent.GetCodeHash(addr), post.GetCodeHash(addr); from != to {
+			account.Code = &Change[common.Hash]{from, to}
+			account.NewCode = post.GetCode(addr)
+		}
+		for _, key := range keys {
+			// read the normalized keys as stored, bypassing the normalization
+			// of StateDB.GetState
+			from, to := parent.StateDB.GetState(addr, key), post.StateDB.GetState(addr, key)
+			if from == to {
+				continue
+			}
+			if state.IsMultiCoinKey(key) {
+				if account.MultiCoin == nil {
+					account.MultiCoin = make(map[common.Hash]*Change[*hexutil.Big])
+				}
+				account.MultiCoin[key] = &Change[*hexutil.Big]{(*hexutil.Big)(from.Big()), (*hexutil.Big)(to.Big())}
+				continue
+			}
+			if account.Storage == nil {
+				account.Storage = make(map[common.Hash]*Change[common.Hash])
+			}
+			account.Storage[key] = &Change[common.Hash]{from, to}
+		}
+		if account.changed() {
+			diff.Accounts = append(diff.Accounts, account)
+		}
+	}
+	sort.Slice(diff.Accounts, func(i, j int) bool {
+		return bytes.Compare(diff.Accounts[i].Address[:], diff.Accounts[j].Address[:]) < 0
+	})
+	return diff
+}
+
+// changed returns whether [a] holds any change, as writes may have been
+// reverted or restored the previous value.
+func (a *AccountDiff) changed() bool {
+	return a.Created || a.Destroyed || a.Balance != nil || a.Nonce != nil || a.Code != nil || len(a.Storage) > 0 || len(a.MultiCoin) > 0
+}
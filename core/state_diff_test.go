@@ -0,0 +1,163 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/params"
+)
+
+func TestStateDiffHook(t *testing.T) {
+	var (
+		engine  = dummy.NewFaker()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.Address{0xaa}
+		created = crypto.CreateAddress(sender, 1)
+		funds   = big.NewInt(params.Ether)
+		value   = big.NewInt(1000)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: funds}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	// stores 1 at slot 2 and deploys the single byte code 0x00
+	initCode := []byte{
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x2, byte(vm.SSTORE),
+		byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.RETURN),
+	}
+	_, blocks, _, err := GenerateChainWithGenesis(gspec, engine, 1, 10, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(0, to, value, params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+		tx, _ = types.SignTx(types.NewContractCreation(1, common.Big0, 100_000, b.BaseFee(), initCode), signer, key)
+		b.AddTx(tx)
+	})
+	require.NoError(t, err)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	var diffs []*StateDiff
+	chain.SetStateDiffHook(func(block *types.Block, diff *StateDiff) error {
+		require.Equal(t, blocks[0].Hash(), block.Hash())
+		diffs = append(diffs, diff)
+		return nil
+	})
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+
+	accounts := make(map[common.Address]*AccountDiff)
+	for _, a := range diffs[0].Accounts {
+		accounts[a.Address] = a
+	}
+
+	recipient := accounts[to]
+	require.NotNil(t, recipient)
+	require.True(t, recipient.Created)
+	require.Zero(t, recipient.Balance.From.ToInt().Sign())
+	require.Equal(t, value, recipient.Balance.To.ToInt())
+
+	from := accounts[sender]
+	require.NotNil(t, from)
+	require.False(t, from.Created)
+	require.Equal(t, funds, from.Balance.From.ToInt())
+	require.EqualValues(t, 0, from.Nonce.From)
+	require.EqualValues(t, 2, from.Nonce.To)
+
+	contract := accounts[created]
+	require.NotNil(t, contract)
+	require.True(t, contract.Created)
+	require.Equal(t, common.Hash{}, contract.Code.From, "the account did not exist")
+	require.Equal(t, crypto.Keccak256Hash([]byte{0}), contract.Code.To)
+	require.Equal(t, []byte{0}, []byte(contract.NewCode))
+	require.Equal(t, &Change[common.Hash]{common.Hash{}, common.BigToHash(common.Big1)}, contract.Storage[common.BigToHash(common.Big2)])
+}
+
+func TestStateDiffHookFailure(t *testing.T) {
+	var (
+		engine = dummy.NewFaker()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _, err := GenerateChainWithGenesis(gspec, engine, 2, 10, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{0xaa}, common.Big1, params.TxGas, b.BaseFee(), nil), signer, key)
+		b.AddTx(tx)
+	})
+	require.NoError(t, err)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfig, gspec, engine, vm.Config{}, common.Hash{}, false)
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	var calls int
+	chain.SetStateDiffHook(func(*types.Block, *StateDiff) error {
+		calls++
+		return errors.New("hook failure")
+	})
+
+	// blocks that are not written, as when building a block, are not diffed
+	require.NoError(t, chain.InsertBlockManual(blocks[0], false, nil))
+	require.Zero(t, calls)
+
+	// a failing hook does not reject a valid block
+	_, err = chain.InsertChain(blocks)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.NotNil(t, chain.GetBlockByHash(blocks[1].Hash()))
+}
+
+func TestStateDiffMultiCoin(t *testing.T) {
+	var (
+		db     = state.NewDatabase(rawdb.NewMemoryDatabase())
+		addr   = common.Address{1}
+		coinID = common.Hash{2}
+	)
+	parent, err := state.New(types.EmptyRootHash, db, nil)
+	require.NoError(t, err)
+	parent.AddBalance(addr, uint256.NewInt(10))
+	root, err := parent.Commit(0, true)
+	require.NoError(t, err)
+	parent, err = state.New(root, db, nil)
+	require.NoError(t, err)
+
+	post, err := state.New(root, db, nil)
+	require.NoError(t, err)
+	post.TrackTouched()
+	post.AddBalanceMultiCoin(addr, coinID, big.NewInt(5))
+	post.SetState(addr, common.Hash{4}, common.Hash{5})
+	post.SetState(addr, common.Hash{6}, common.Hash{7})
+	post.SetState(addr, common.Hash{6}, common.Hash{}) // restored
+	post.Finalise(true)
+
+	diff := NewStateDiff(parent, post, post.Touched())
+	require.Len(t, diff.Accounts, 1)
+	account := diff.Accounts[0]
+	require.Nil(t, account.Balance)
+	require.Len(t, account.MultiCoin, 1)
+	normalized := coinID
+	state.NormalizeCoinID(&normalized)
+	require.Equal(t, big.NewInt(5), account.MultiCoin[normalized].To.ToInt())
+	require.Equal(t, map[common.Hash]*Change[common.Hash]{{4}: {common.Hash{}, common.Hash{5}}}, account.Storage)
+}
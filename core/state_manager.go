@@ -32,6 +32,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/ava-labs/coreth/triedb/pathdb"
 	"github.com/ava-labs/libevm/common"
 	"github.com/ava-labs/libevm/core/types"
 	"github.com/ava-labs/libevm/ethdb"
@@ -91,6 +92,19 @@ func NewTrieWriter(db TrieDB, config *CacheConfig) TrieWriter {
 	}
 }
 
+// NewPathTrieWriter returns the TrieWriter used with the path-based scheme,
+// where the disk layer of [db] only holds the latest state and older states
+// are served from its state histories.
+func NewPathTrieWriter(db *pathdb.Database, config *CacheConfig) TrieWriter {
+	p := &pathTrieWriter{
+		db:             db,
+		pruning:        config.Pruning,
+		commitInterval: config.CommitInterval,
+	}
+	p.tipBuffer = NewBoundedBuffer(TipBufferSize, p.flatten)
+	return p
+}
+
 type noPruningTrieWriter struct {
 	TrieDB
 }
@@ -203,3 +217,46 @@ func (cm *cappedMemoryTrieWriter) Shutdown() error {
 	// re-processing the state on the next startup.
 	return cm.TrieDB.Commit(last, true)
 }
+
+// pathTrieWriter flattens accepted layers into the disk layer once
+// [TipBufferSize] newer blocks have been accepted, so recent states are
+// still served from the diff layers. Rejected layers are discarded by the
+// next flatten and the remaining layers are journaled when the chain stops.
+//
+// As with the hash scheme, the disk layer is only guaranteed to be persisted
+// every [commitInterval] blocks, or for every block if pruning is disabled,
+// which bounds the blocks re-processed after an unclean shutdown.
+type pathTrieWriter struct {
+	db             *pathdb.Database
+	pruning        bool
+	commitInterval uint64
+	tipBuffer      *BoundedBuffer[*types.Block]
+}
+
+func (p *pathTrieWriter) InsertTrie(block *types.Block) error { return nil }
+
+func (p *pathTrieWriter) AcceptTrie(block *types.Block) error {
+	if !p.pruning {
+		if err := p.db.Commit(block.Root(), false); err != nil {
+			return fmt.Errorf("failed to commit trie for block %s: %w", block.Hash().Hex(), err)
+		}
+		return nil
+	}
+	return p.tipBuffer.Insert(block)
+}
+
+// flatten merges the layer of [block] into the disk layer, persisting it if
+// the [commitInterval] has been reached.
+func (p *pathTrieWriter) flatten(block *types.Block) error {
+	if block.NumberU64()%p.commitInterval == 0 {
+		if err := p.db.Commit(block.Root(), true); err != nil {
+			return fmt.Errorf("failed to commit trie for block %s: %w", block.Hash().Hex(), err)
+		}
+		return nil
+	}
+	return p.db.Flatten(block.Root())
+}
+
+func (p *pathTrieWriter) RejectTrie(block *types.Block) error { return nil }
+
+func (p *pathTrieWriter) Shutdown() error { return nil }
@@ -83,7 +83,7 @@ func (p *StateProcessor) ProcessWithTracers(block *types.Block, parent *types.He
 		allLogs     []*types.Log
 		gp          = new(GasPool).AddGas(block.GasLimit())
 	)
-	if pause.RedisBehind(blockNumber.Int64()) {
+	if pause.Behind(blockNumber.Int64()) {
 		shutdown := pause.PauseIfBehind("[StateProcessor Process]")
 		if shutdown {
 			return nil, nil, 0, errors.New("### DEBUG ### stop execution")
@@ -0,0 +1,177 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tokenindex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/event"
+	"github.com/ava-labs/libevm/log"
+
+	"github.com/ava-labs/coreth/core"
+)
+
+// backfillThrottling is the time to wait between processing two consecutive
+// backfill sections, to keep the backfill from hogging the disk.
+const backfillThrottling = 100 * time.Millisecond
+
+// Chain is the accepted chain indexed by the Indexer
+type Chain interface {
+	core.AcceptedChain
+	GetReceiptsByHash(hash common.Hash) types.Receipts
+	SubscribeAcceptedLogsEvent(ch chan<- []*types.Log) event.Subscription
+}
+
+// Status is the progress of the Indexer
+type Status struct {
+	SectionSize uint64 `json:"sectionSize"`
+	// Sections is the number of sections indexed by the backfill, which
+	// covers the blocks [0, Sections*SectionSize).
+	Sections uint64 `json:"sections"`
+	// LiveFrom is the first block indexed as it was accepted
+	LiveFrom uint64 `json:"liveFrom"`
+	// Complete is true once every accepted block is indexed
+	Complete bool `json:"complete"`
+}
+
+// Indexer indexes the token transfers of accepted blocks as their logs are
+// accepted. The blocks accepted before the Indexer started are indexed in
+// the background by a ChainIndexer, one section at a time.
+type Indexer struct {
+	db       ethdb.Database
+	chain    Chain
+	backfill *core.ChainIndexer
+	size     uint64
+	liveFrom uint64
+
+	sub  event.Subscription
+	logs chan []*types.Log
+	wg   sync.WaitGroup
+}
+
+// New starts indexing the token transfers of [chain] into [db]
+func New(db ethdb.Database, chain Chain, sectionSize uint64) *Indexer {
+	idx := &Indexer{
+		db:       db,
+		chain:    chain,
+		size:     sectionSize,
+		liveFrom: chain.LastAcceptedBlock().NumberU64() + 1,
+		logs:     make(chan []*types.Log, 64),
+	}
+	idx.sub = chain.SubscribeAcceptedLogsEvent(idx.logs)
+	idx.wg.Add(1)
+	go idx.indexAccepted()
+
+	backend := &backfillBackend{db: db, chain: chain}
+	table := rawdb.NewTable(db, string(sectionsPrefix))
+	idx.backfill = core.NewChainIndexer(db, table, backend, sectionSize, 0, backfillThrottling, "tokentransfers")
+	idx.backfill.StartAccepted(chain)
+	return idx
+}
+
+// indexAccepted indexes the transfers of the accepted logs
+func (idx *Indexer) indexAccepted() {
+	defer idx.wg.Done()
+	for {
+		select {
+		case logs := <-idx.logs:
+			idx.write(logs)
+		case <-idx.sub.Err():
+			// Unsubscribed, index the logs already received
+			for {
+				select {
+				case logs := <-idx.logs:
+					idx.write(logs)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (idx *Indexer) write(logs []*types.Log) {
+	transfers := DecodeLogs(logs)
+	if len(transfers) == 0 {
+		return
+	}
+	batch := idx.db.NewBatch()
+	if err := WriteTransfers(batch, transfers); err != nil {
+		log.Error("failed to encode token transfers", "block", logs[0].BlockNumber, "err", err)
+		return
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("failed to write token transfers", "block", logs[0].BlockNumber, "err", err)
+	}
+}
+
+// Status returns the progress of the indexer
+func (idx *Indexer) Status() Status {
+	sections, _, _ := idx.backfill.Sections()
+	return Status{
+		SectionSize: idx.size,
+		Sections:    sections,
+		LiveFrom:    idx.liveFrom,
+		Complete:    sections*idx.size >= idx.liveFrom,
+	}
+}
+
+// Close stops indexing. The transfers of the logs already received are
+// written before it returns.
+func (idx *Indexer) Close() error {
+	idx.sub.Unsubscribe()
+	idx.wg.Wait()
+	return idx.backfill.Close()
+}
+
+// backfillBackend implements core.ChainIndexerBackend, indexing the
+// transfers of a section of the accepted chain.
+type backfillBackend struct {
+	db    ethdb.Database
+	chain Chain
+	batch ethdb.Batch
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section
+func (b *backfillBackend) Reset(ctx context.Context, section uint64, prevHead common.Hash) error {
+	b.batch = b.db.NewBatch()
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, indexing the transfers of
+// [header]'s block.
+func (b *backfillBackend) Process(ctx context.Context, header *types.Header) error {
+	var logs []*types.Log
+	for _, receipt := range b.chain.GetReceiptsByHash(header.Hash()) {
+		logs = append(logs, receipt.Logs...)
+	}
+	if err := WriteTransfers(b.batch, DecodeLogs(logs)); err != nil {
+		return err
+	}
+	if b.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := b.batch.Write(); err != nil {
+		return err
+	}
+	b.batch.Reset()
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the remaining
+// transfers of the section.
+func (b *backfillBackend) Commit() error {
+	return b.batch.Write()
+}
+
+// Prune implements core.ChainIndexerBackend, the index is never pruned
+func (b *backfillBackend) Prune(threshold uint64) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tokenindex
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/event"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+)
+
+// testChain is an accepted chain whose block i carries an ERC-20 transfer of
+// i tokens from alice to bob
+type testChain struct {
+	db       ethdb.Database
+	blocks   []*types.Block
+	accepted event.Feed
+	logs     event.Feed
+}
+
+func newTestChain(db ethdb.Database, n int) *testChain {
+	c := &testChain{db: db}
+	for i := 0; i < n; i++ {
+		c.add()
+	}
+	return c
+}
+
+func (c *testChain) add() *types.Block {
+	header := &types.Header{Number: big.NewInt(int64(len(c.blocks)))}
+	if len(c.blocks) > 0 {
+		header.ParentHash = c.blocks[len(c.blocks)-1].Hash()
+	}
+	block := types.NewBlockWithHeader(header)
+	rawdb.WriteHeader(c.db, header)
+	rawdb.WriteCanonicalHash(c.db, block.Hash(), block.NumberU64())
+	c.blocks = append(c.blocks, block)
+	return block
+}
+
+func (c *testChain) blockLogs(block *types.Block) []*types.Log {
+	return []*types.Log{erc20Log(block.NumberU64(), 0, alice, bob, int64(block.NumberU64()))}
+}
+
+func (c *testChain) LastAcceptedBlock() *types.Block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *testChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	for _, block := range c.blocks {
+		if block.Hash() == hash {
+			return types.Receipts{{Logs: c.blockLogs(block)}}
+		}
+	}
+	return nil
+}
+
+func (c *testChain) SubscribeChainAcceptedEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return c.accepted.Subscribe(ch)
+}
+
+func (c *testChain) SubscribeAcceptedLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return c.logs.Subscribe(ch)
+}
+
+func TestIndexerBackfillsThenFollows(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	chain := newTestChain(db, 8)
+	idx := New(db, chain, 4)
+
+	// blocks 0-7 form two sections, backfilled in the background
+	require.Equal(t, uint64(8), idx.Status().LiveFrom)
+	require.Eventually(t, func() bool {
+		return idx.Status().Complete
+	}, 5*time.Second, 10*time.Millisecond)
+	page, err := ReadTransfersByToken(db, token, 0, 100, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 8)
+
+	// block 8 is indexed when its logs are accepted
+	block := chain.add()
+	chain.logs.Send(chain.blockLogs(block))
+	chain.accepted.Send(core.ChainEvent{Block: block})
+	require.NoError(t, idx.Close())
+
+	page, err = ReadTransfersByAddress(db, bob, 8, 8, nil, 100)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 1)
+	require.Equal(t, big.NewInt(8), page.Transfers[0].Value)
+}
+
+func TestReadTransfersPages(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	var transfers []*Transfer
+	for i := uint64(1); i <= 5; i++ {
+		transfers = append(transfers, DecodeLog(erc20Log(i, 0, alice, bob, int64(i)))...)
+	}
+	// a transfer to self and a mint are indexed once for the address
+	transfers = append(transfers, DecodeLog(erc20Log(6, 0, alice, alice, 6))...)
+	transfers = append(transfers, DecodeLog(erc20Log(6, 1, common.Address{}, alice, 7))...)
+	require.NoError(t, WriteTransfers(db, transfers))
+
+	var (
+		values []int64
+		cursor []byte
+	)
+	for {
+		page, err := ReadTransfersByAddress(db, alice, 2, 6, cursor, 2)
+		require.NoError(t, err)
+		for _, transfer := range page.Transfers {
+			values = append(values, transfer.Value.Int64())
+		}
+		if page.Next == nil {
+			break
+		}
+		cursor = page.Next
+	}
+	require.Equal(t, []int64{2, 3, 4, 5, 6, 7}, values)
+
+	page, err := ReadTransfersByAddress(db, common.Address{}, 0, 6, nil, 10)
+	require.NoError(t, err)
+	require.Empty(t, page.Transfers)
+	page, err = ReadTransfersByToken(db, token, 4, 4, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 1)
+	require.Equal(t, uint64(4), page.Transfers[0].BlockNumber)
+
+	_, err = ReadTransfersByToken(db, token, 0, 6, []byte{1}, 10)
+	require.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tokenindex
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/rlp"
+)
+
+var (
+	// byTokenPrefix + token + position -> transfer
+	byTokenPrefix = []byte("tokenidx_t")
+	// byAddressPrefix + address + position -> transfer, for the sender and
+	// the recipient of the transfer
+	byAddressPrefix = []byte("tokenidx_a")
+	// sectionsPrefix is the prefix of the chain indexer metadata
+	sectionsPrefix = []byte("tokenidx_s")
+)
+
+// positionLength is the length of the position of a transfer in the chain:
+// block number, log index in the block and index in the batch event.
+const positionLength = 8 + 4 + 4
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// storedTransfer is the RLP encoding of a transfer, whose position is
+// encoded in the key.
+type storedTransfer struct {
+	Token    common.Address
+	Standard uint8
+	Operator common.Address
+	From     common.Address
+	To       common.Address
+	TokenID  *big.Int `rlp:"nil"`
+	Value    *big.Int
+	TxHash   common.Hash
+	TxIndex  uint64
+}
+
+func position(t *Transfer) []byte {
+	pos := make([]byte, positionLength)
+	binary.BigEndian.PutUint64(pos, t.BlockNumber)
+	binary.BigEndian.PutUint32(pos[8:], uint32(t.LogIndex))
+	binary.BigEndian.PutUint32(pos[12:], uint32(t.BatchIndex))
+	return pos
+}
+
+func transferKey(prefix []byte, subject common.Address, pos []byte) []byte {
+	key := make([]byte, 0, len(prefix)+common.AddressLength+len(pos))
+	key = append(key, prefix...)
+	key = append(key, subject[:]...)
+	return append(key, pos...)
+}
+
+// WriteTransfers stores [transfers] under their token and under the sender
+// and the recipient. The zero address, the sender of mints and the
+// recipient of burns, is not indexed. Writing a transfer again overwrites
+// the same entries.
+func WriteTransfers(db ethdb.KeyValueWriter, transfers []*Transfer) error {
+	for _, t := range transfers {
+		value, err := rlp.EncodeToBytes(&storedTransfer{
+			Token:    t.Token,
+			Standard: uint8(t.Standard),
+			Operator: t.Operator,
+			From:     t.From,
+			To:       t.To,
+			TokenID:  t.TokenID,
+			Value:    t.Value,
+			TxHash:   t.TxHash,
+			TxIndex:  uint64(t.TxIndex),
+		})
+		if err != nil {
+			return err
+		}
+		pos := position(t)
+		if err := db.Put(transferKey(byTokenPrefix, t.Token, pos), value); err != nil {
+			return err
+		}
+		for i, addr := range []common.Address{t.From, t.To} {
+			if addr == (common.Address{}) || (i == 1 && addr == t.From) {
+				continue
+			}
+			if err := db.Put(transferKey(byAddressPrefix, addr, pos), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Page is a page of transfers in chain order
+type Page struct {
+	Transfers []*Transfer `json:"transfers"`
+	// Next is the cursor of the next page, nil on the last page
+	Next hexutil.Bytes `json:"next,omitempty"`
+}
+
+// ReadTransfersByToken returns up to [limit] transfers of [token] in the
+// blocks [from, to], starting at [cursor] if it is not nil.
+func ReadTransfersByToken(db ethdb.Iteratee, token common.Address, from, to uint64, cursor []byte, limit int) (*Page, error) {
+	return readTransfers(db, byTokenPrefix, token, from, to, cursor, limit)
+}
+
+// ReadTransfersByAddress returns up to [limit] transfers sent or received by
+// [addr] in the blocks [from, to], starting at [cursor] if it is not nil.
+func ReadTransfersByAddress(db ethdb.Iteratee, addr common.Address, from, to uint64, cursor []byte, limit int) (*Page, error) {
+	return readTransfers(db, byAddressPrefix, addr, from, to, cursor, limit)
+}
+
+func readTransfers(db ethdb.Iteratee, prefix []byte, subject common.Address, from, to uint64, cursor []byte, limit int) (*Page, error) {
+	start := cursor
+	if start == nil {
+		start = binary.BigEndian.AppendUint64(nil, from)
+	} else if len(start) != positionLength || binary.BigEndian.Uint64(start) < from {
+		return nil, ErrInvalidCursor
+	}
+	subjectPrefix := transferKey(prefix, subject, nil)
+	it := db.NewIterator(subjectPrefix, start)
+	defer it.Release()
+
+	page := &Page{Transfers: []*Transfer{}}
+	for it.Next() {
+		pos := it.Key()[len(subjectPrefix):]
+		if len(pos) != positionLength {
+			continue
+		}
+		number := binary.BigEndian.Uint64(pos)
+		if number > to {
+			break
+		}
+		if len(page.Transfers) == limit {
+			page.Next = common.CopyBytes(pos)
+			break
+		}
+		var stored storedTransfer
+		if err := rlp.DecodeBytes(it.Value(), &stored); err != nil {
+			return nil, fmt.Errorf("invalid transfer at block %d: %w", number, err)
+		}
+		page.Transfers = append(page.Transfers, &Transfer{
+			Token:       stored.Token,
+			Standard:    Standard(stored.Standard),
+			Operator:    stored.Operator,
+			From:        stored.From,
+			To:          stored.To,
+			TokenID:     stored.TokenID,
+			Value:       stored.Value,
+			BlockNumber: number,
+			TxHash:      stored.TxHash,
+			TxIndex:     uint(stored.TxIndex),
+			LogIndex:    uint(binary.BigEndian.Uint32(pos[8:])),
+			BatchIndex:  uint(binary.BigEndian.Uint32(pos[12:])),
+		})
+	}
+	return page, it.Error()
+}
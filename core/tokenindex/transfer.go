@@ -0,0 +1,202 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package tokenindex indexes the ERC-20, ERC-721 and ERC-1155 transfers of
+// the accepted chain by token and by participant address.
+package tokenindex
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+
+	"github.com/ava-labs/coreth/accounts/abi"
+)
+
+// Standard is the token standard of the event a transfer was decoded from
+type Standard uint8
+
+const (
+	ERC20 Standard = iota + 1
+	ERC721
+	ERC1155
+)
+
+func (s Standard) String() string {
+	switch s {
+	case ERC20:
+		return "erc20"
+	case ERC721:
+		return "erc721"
+	case ERC1155:
+		return "erc1155"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+var (
+	// Transfer(address,address,uint256) of ERC-20, and of ERC-721 where the
+	// third argument, the token ID, is indexed
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	// TransferSingle(address,address,address,uint256,uint256) of ERC-1155
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	// TransferBatch(address,address,address,uint256[],uint256[]) of ERC-1155
+	transferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+
+	transferBatchArgs abi.Arguments
+)
+
+func init() {
+	uint256Array, err := abi.NewType("uint256[]", "", nil)
+	if err != nil {
+		panic(err)
+	}
+	transferBatchArgs = abi.Arguments{{Type: uint256Array}, {Type: uint256Array}}
+}
+
+// Transfer is a token transfer decoded from a log of the accepted chain
+type Transfer struct {
+	Token    common.Address
+	Standard Standard
+	Operator common.Address // sender of the ERC-1155 transfer, zero otherwise
+	From     common.Address
+	To       common.Address
+	TokenID  *big.Int // nil for ERC-20
+	Value    *big.Int // 1 for ERC-721
+
+	BlockNumber uint64
+	TxHash      common.Hash
+	TxIndex     uint
+	LogIndex    uint
+	// BatchIndex is the position of the transfer in an ERC-1155
+	// TransferBatch event, zero otherwise
+	BatchIndex uint
+}
+
+type transferJSON struct {
+	Token       common.Address  `json:"token"`
+	Standard    string          `json:"standard"`
+	Operator    *common.Address `json:"operator,omitempty"`
+	From        common.Address  `json:"from"`
+	To          common.Address  `json:"to"`
+	TokenID     *hexutil.Big    `json:"tokenId,omitempty"`
+	Value       *hexutil.Big    `json:"value"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	TxHash      common.Hash     `json:"transactionHash"`
+	TxIndex     hexutil.Uint    `json:"transactionIndex"`
+	LogIndex    hexutil.Uint    `json:"logIndex"`
+	BatchIndex  hexutil.Uint    `json:"batchIndex"`
+}
+
+// MarshalJSON encodes the transfer as returned by the RPC API
+func (t *Transfer) MarshalJSON() ([]byte, error) {
+	enc := transferJSON{
+		Token:       t.Token,
+		Standard:    t.Standard.String(),
+		From:        t.From,
+		To:          t.To,
+		TokenID:     (*hexutil.Big)(t.TokenID),
+		Value:       (*hexutil.Big)(t.Value),
+		BlockNumber: hexutil.Uint64(t.BlockNumber),
+		TxHash:      t.TxHash,
+		TxIndex:     hexutil.Uint(t.TxIndex),
+		LogIndex:    hexutil.Uint(t.LogIndex),
+		BatchIndex:  hexutil.Uint(t.BatchIndex),
+	}
+	if t.Standard == ERC1155 {
+		enc.Operator = &t.Operator
+	}
+	return json.Marshal(&enc)
+}
+
+// DecodeLog returns the token transfers emitted by [log], if it is a
+// Transfer, TransferSingle or TransferBatch event. Logs which match the event
+// signature but not its layout, such as an ERC-721 Transfer with an unindexed
+// token ID, are ignored.
+func DecodeLog(log *types.Log) []*Transfer {
+	if log.Removed || len(log.Topics) == 0 {
+		return nil
+	}
+	base := Transfer{
+		Token:       log.Address,
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+		TxIndex:     log.TxIndex,
+		LogIndex:    log.Index,
+	}
+	switch log.Topics[0] {
+	case transferTopic:
+		switch {
+		case len(log.Topics) == 3 && len(log.Data) == 32:
+			base.Standard = ERC20
+			base.Value = new(big.Int).SetBytes(log.Data)
+		case len(log.Topics) == 4 && len(log.Data) == 0:
+			base.Standard = ERC721
+			base.TokenID = log.Topics[3].Big()
+			base.Value = big.NewInt(1)
+		default:
+			return nil
+		}
+		base.From = topicAddress(log.Topics[1])
+		base.To = topicAddress(log.Topics[2])
+		return []*Transfer{&base}
+
+	case transferSingleTopic:
+		if len(log.Topics) != 4 || len(log.Data) != 64 {
+			return nil
+		}
+		base.Standard = ERC1155
+		base.Operator = topicAddress(log.Topics[1])
+		base.From = topicAddress(log.Topics[2])
+		base.To = topicAddress(log.Topics[3])
+		base.TokenID = new(big.Int).SetBytes(log.Data[:32])
+		base.Value = new(big.Int).SetBytes(log.Data[32:])
+		return []*Transfer{&base}
+
+	case transferBatchTopic:
+		if len(log.Topics) != 4 {
+			return nil
+		}
+		values, err := transferBatchArgs.Unpack(log.Data)
+		if err != nil {
+			return nil
+		}
+		ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+		if len(ids) != len(amounts) {
+			return nil
+		}
+		base.Standard = ERC1155
+		base.Operator = topicAddress(log.Topics[1])
+		base.From = topicAddress(log.Topics[2])
+		base.To = topicAddress(log.Topics[3])
+		transfers := make([]*Transfer, len(ids))
+		for i := range ids {
+			transfer := base
+			transfer.TokenID = ids[i]
+			transfer.Value = amounts[i]
+			transfer.BatchIndex = uint(i)
+			transfers[i] = &transfer
+		}
+		return transfers
+	}
+	return nil
+}
+
+// DecodeLogs returns the token transfers emitted by [logs]
+func DecodeLogs(logs []*types.Log) []*Transfer {
+	var transfers []*Transfer
+	for _, log := range logs {
+		transfers = append(transfers, DecodeLog(log)...)
+	}
+	return transfers
+}
+
+func topicAddress(topic common.Hash) common.Address {
+	return common.BytesToAddress(topic[common.HashLength-common.AddressLength:])
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tokenindex
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token = common.HexToAddress("0x70")
+	alice = common.HexToAddress("0xa1")
+	bob   = common.HexToAddress("0xb0")
+)
+
+func addressTopic(addr common.Address) common.Hash {
+	return common.BytesToHash(addr[:])
+}
+
+func word(v int64) []byte {
+	return common.BigToHash(big.NewInt(v)).Bytes()
+}
+
+func erc20Log(number uint64, index uint, from, to common.Address, value int64) *types.Log {
+	return &types.Log{
+		Address:     token,
+		Topics:      []common.Hash{transferTopic, addressTopic(from), addressTopic(to)},
+		Data:        word(value),
+		BlockNumber: number,
+		Index:       index,
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	erc20 := DecodeLog(erc20Log(3, 2, alice, bob, 50))
+	require.Equal(t, []*Transfer{{
+		Token:       token,
+		Standard:    ERC20,
+		From:        alice,
+		To:          bob,
+		Value:       big.NewInt(50),
+		BlockNumber: 3,
+		LogIndex:    2,
+	}}, erc20)
+
+	erc721 := DecodeLog(&types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob), common.BigToHash(big.NewInt(7))},
+	})
+	require.Len(t, erc721, 1)
+	require.Equal(t, ERC721, erc721[0].Standard)
+	require.Equal(t, big.NewInt(7), erc721[0].TokenID)
+	require.Equal(t, big.NewInt(1), erc721[0].Value)
+
+	single := DecodeLog(&types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferSingleTopic, addressTopic(bob), addressTopic(alice), addressTopic(bob)},
+		Data:    append(word(9), word(4)...),
+	})
+	require.Len(t, single, 1)
+	require.Equal(t, ERC1155, single[0].Standard)
+	require.Equal(t, bob, single[0].Operator)
+	require.Equal(t, alice, single[0].From)
+	require.Equal(t, big.NewInt(9), single[0].TokenID)
+	require.Equal(t, big.NewInt(4), single[0].Value)
+
+	data, err := transferBatchArgs.Pack(
+		[]*big.Int{big.NewInt(1), big.NewInt(2)},
+		[]*big.Int{big.NewInt(10), big.NewInt(20)},
+	)
+	require.NoError(t, err)
+	batch := DecodeLog(&types.Log{
+		Address: token,
+		Topics:  []common.Hash{transferBatchTopic, addressTopic(bob), addressTopic(alice), addressTopic(bob)},
+		Data:    data,
+	})
+	require.Len(t, batch, 2)
+	for i, transfer := range batch {
+		require.Equal(t, uint(i), transfer.BatchIndex)
+		require.Equal(t, big.NewInt(int64(i+1)), transfer.TokenID)
+		require.Equal(t, big.NewInt(int64(10*(i+1))), transfer.Value)
+	}
+
+	// an ERC-721 Transfer with an unindexed token ID, an unrelated event and
+	// a removed log are ignored
+	require.Empty(t, DecodeLog(&types.Log{
+		Topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+	}))
+	require.Empty(t, DecodeLog(&types.Log{Topics: []common.Hash{{1}}}))
+	removed := erc20Log(3, 2, alice, bob, 50)
+	removed.Removed = true
+	require.Empty(t, DecodeLog(removed))
+}
+
+func TestTransferJSON(t *testing.T) {
+	data, err := json.Marshal(DecodeLog(erc20Log(3, 2, alice, bob, 50))[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"token": "0x0000000000000000000000000000000000000070",
+		"standard": "erc20",
+		"from": "0x00000000000000000000000000000000000000a1",
+		"to": "0x00000000000000000000000000000000000000b0",
+		"value": "0x32",
+		"blockNumber": "0x3",
+		"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"transactionIndex": "0x0",
+		"logIndex": "0x2",
+		"batchIndex": "0x0"
+	}`, string(data))
+}
@@ -46,6 +46,7 @@ import (
 	"github.com/ava-labs/coreth/rpc"
 	"github.com/ava-labs/libevm/accounts"
 	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
 	"github.com/ava-labs/libevm/core/types"
 	"github.com/ava-labs/libevm/core/vm"
 	"github.com/ava-labs/libevm/ethdb"
@@ -246,6 +247,16 @@ func (b *EthAPIBackend) BadBlocks() ([]*types.Block, []*core.BadBlockReason) {
 	return b.eth.blockchain.BadBlocks()
 }
 
+// stateAt returns the state for [root]. With the path scheme, states no
+// longer held in the live database are rebuilt from the state histories.
+func (b *EthAPIBackend) stateAt(ctx context.Context, root common.Hash) (*state.StateDB, error) {
+	statedb, err := b.eth.blockchain.StateAt(root)
+	if err == nil || b.eth.blockchain.TrieDB().Scheme() != rawdb.PathScheme {
+		return statedb, err
+	}
+	return b.eth.blockchain.HistoricState(ctx, root)
+}
+
 func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
 	// Request the block by its number and retrieve its state
 	header, err := b.HeaderByNumber(ctx, number)
@@ -255,7 +266,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	if header == nil {
 		return nil, nil, errors.New("header not found")
 	}
-	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	stateDb, err := b.stateAt(ctx, header.Root)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -277,7 +288,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		if header == nil {
 			return nil, nil, errors.New("header for hash not found")
 		}
-		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+		stateDb, err := b.stateAt(ctx, header.Root)
 		if err != nil {
 			return nil, nil, err
 		}
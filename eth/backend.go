@@ -227,7 +227,12 @@ func New(
 			TransactionHistory:              config.TransactionHistory,
 			SkipTxIndexing:                  config.SkipTxIndexing,
 			StateHistory:                    config.StateHistory,
+			HistoricStateDepth:              config.HistoricStateDepth,
 			StateScheme:                     scheme,
+			OnlinePruning:                   config.OnlinePruning,
+			OnlinePruningInterval:           config.OnlinePruningInterval,
+			OnlinePruningBloomSize:          config.OnlinePruningBloomFilterSize,
+			OnlinePruningBatchDelay:         config.OnlinePruningBatchDelay,
 		}
 	)
 
@@ -158,6 +158,13 @@ type Config struct {
 	OfflinePruningBloomFilterSize uint64
 	OfflinePruningDataDirectory   string
 
+	// OnlinePruning enables rounds of pruning of the stale trie nodes while the node
+	// keeps processing blocks, at most once every OnlinePruningInterval.
+	OnlinePruning                bool
+	OnlinePruningInterval        time.Duration
+	OnlinePruningBloomFilterSize uint64
+	OnlinePruningBatchDelay      time.Duration
+
 	// SkipUpgradeCheck disables checking that upgrades must take place before the last
 	// accepted block. Skipping this check is useful when a node operator does not update
 	// their node before the network upgrade and their node accepts blocks that have
@@ -171,6 +178,10 @@ type Config struct {
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
 
+	// HistoricStateDepth is the maximum number of state histories reverted
+	// to serve a state no longer held by the path database, 0 means no limit.
+	HistoricStateDepth uint64 `toml:",omitempty"`
+
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
 	// consistent with persistent state.
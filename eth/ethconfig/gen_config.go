@@ -55,6 +55,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SkipUpgradeCheck                bool
 		TransactionHistory              uint64 `toml:",omitempty"`
 		StateHistory                    uint64 `toml:",omitempty"`
+		HistoricStateDepth              uint64 `toml:",omitempty"`
 		StateScheme                     string `toml:",omitempty"`
 		SkipTxIndexing                  bool
 		PriceOptionConfig               ethapi.PriceOptionConfig
@@ -98,6 +99,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SkipUpgradeCheck = c.SkipUpgradeCheck
 	enc.TransactionHistory = c.TransactionHistory
 	enc.StateHistory = c.StateHistory
+	enc.HistoricStateDepth = c.HistoricStateDepth
 	enc.StateScheme = c.StateScheme
 	enc.SkipTxIndexing = c.SkipTxIndexing
 	enc.PriceOptionConfig = c.PriceOptionConfig
@@ -145,6 +147,7 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SkipUpgradeCheck                *bool
 		TransactionHistory              *uint64 `toml:",omitempty"`
 		StateHistory                    *uint64 `toml:",omitempty"`
+		HistoricStateDepth              *uint64 `toml:",omitempty"`
 		StateScheme                     *string `toml:",omitempty"`
 		SkipTxIndexing                  *bool
 		PriceOptionConfig               *ethapi.PriceOptionConfig
@@ -267,6 +270,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StateHistory != nil {
 		c.StateHistory = *dec.StateHistory
 	}
+	if dec.HistoricStateDepth != nil {
+		c.HistoricStateDepth = *dec.HistoricStateDepth
+	}
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
@@ -229,6 +229,15 @@ type blockTraceResult struct {
 	Block  hexutil.Uint64   `json:"block"`  // Block number corresponding to this trace
 	Hash   common.Hash      `json:"hash"`   // Block hash corresponding to this trace
 	Traces []*txTraceResult `json:"traces"` // Trace results produced by the task
+
+	header *types.Header // Header of the traced block
+}
+
+// chainTraceOptions tunes traceChain for callers other than TraceChain.
+type chainTraceOptions struct {
+	threads    int  // Maximum number of blocks traced concurrently, NumCPU if zero
+	emitEmpty  bool // Whether to stream the results of blocks without transactions
+	preferDisk bool // Whether to look up every state on disk before re-executing
 }
 
 // txTraceTask represents a single transaction trace task when an entire block
@@ -259,7 +268,7 @@ func (api *API) TraceChain(ctx context.Context, start, end rpc.BlockNumber, conf
 	}
 	sub := notifier.CreateSubscription()
 
-	resCh := api.traceChain(from, to, config, notifier.Closed())
+	resCh := api.traceChain(from, to, config, chainTraceOptions{}, notifier.Closed())
 	go func() {
 		for result := range resCh {
 			notifier.Notify(sub.ID, result)
@@ -268,18 +277,70 @@ func (api *API) TraceChain(ctx context.Context, start, end rpc.BlockNumber, conf
 	return sub, nil
 }
 
+// TraceChainRange traces the blocks in (start, end] the same way as
+// TraceChain, tracing at most [threads] blocks concurrently, and calls [fn]
+// in block order with the header and the JSON encoded transaction traces of
+// every block, including blocks without transactions. The state of every block is looked
+// up on disk first, since re-executing blocks with atomic transactions fails
+// once their UTXOs are consumed. Tracing stops at the first error returned by
+// [fn] or once [ctx] is done.
+func (api *API) TraceChainRange(ctx context.Context, start, end uint64, config *TraceConfig, threads int, fn func(header *types.Header, traces []byte) error) error {
+	from, err := api.blockByNumber(ctx, rpc.BlockNumber(start))
+	if err != nil {
+		return err
+	}
+	to, err := api.blockByNumber(ctx, rpc.BlockNumber(end))
+	if err != nil {
+		return err
+	}
+	if from.Number().Cmp(to.Number()) >= 0 {
+		return fmt.Errorf("end block (#%d) needs to come after start block (#%d)", end, start)
+	}
+	closed := make(chan interface{})
+	resCh := api.traceChain(from, to, config, chainTraceOptions{threads: threads, emitEmpty: true, preferDisk: true}, closed)
+	defer func() {
+		// Abort tracing and let the tracing goroutines drain
+		close(closed)
+		for range resCh {
+		}
+	}()
+	next := start + 1
+	for next <= end {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result, ok := <-resCh:
+			if !ok {
+				return fmt.Errorf("chain tracing stopped before block #%d", next)
+			}
+			traces, err := json.Marshal(result.Traces)
+			if err != nil {
+				return err
+			}
+			if err := fn(result.header, traces); err != nil {
+				return err
+			}
+			next++
+		}
+	}
+	return nil
+}
+
 // traceChain configures a new tracer according to the provided configuration, and
 // executes all the transactions contained within. The tracing chain range includes
 // the end block but excludes the start one. The return value will be one item per
 // transaction, dependent on the requested tracer.
 // The tracing procedure should be aborted in case the closed signal is received.
-func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed <-chan interface{}) chan *blockTraceResult {
+func (api *API) traceChain(start, end *types.Block, config *TraceConfig, opts chainTraceOptions, closed <-chan interface{}) chan *blockTraceResult {
 	reexec := defaultTraceReexec
 	if config != nil && config.Reexec != nil {
 		reexec = *config.Reexec
 	}
 	blocks := int(end.NumberU64() - start.NumberU64())
 	threads := runtime.NumCPU()
+	if opts.threads > 0 && opts.threads < threads {
+		threads = opts.threads
+	}
 	if threads > blocks {
 		threads = blocks
 	}
@@ -400,8 +461,8 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 			// over to `preferDisk` mode only if the memory usage exceeds the
 			// limit, the trie database will be reconstructed from scratch only
 			// if the relevant state is available in disk.
-			var preferDisk bool
-			if statedb != nil {
+			preferDisk := opts.preferDisk
+			if statedb != nil && !preferDisk {
 				s1, s2, s3 := statedb.Database().TrieDB().Size()
 				preferDisk = s1+s2+s3 > defaultTracechainMemLimit
 			}
@@ -442,12 +503,13 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 				Block:  hexutil.Uint64(res.block.NumberU64()),
 				Hash:   res.block.Hash(),
 				Traces: res.results,
+				header: res.block.Header(),
 			}
 			done[uint64(result.Block)] = result
 
 			// Stream completed traces to the result channel
 			for result, ok := done[next]; ok; result, ok = done[next] {
-				if len(result.Traces) > 0 || next == end.NumberU64() {
+				if len(result.Traces) > 0 || next == end.NumberU64() || opts.emitEmpty {
 					// It will be blocked in case the channel consumer doesn't take the
 					// tracing result in time(e.g. the websocket connect is not stable)
 					// which will eventually block the entire chain tracer. It's the
@@ -918,6 +980,28 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // the trace will be conducted on the state after executing the specified transaction
 // within the specified block.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	_, statedb, vmctx, release, err := api.callState(ctx, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Execute the trace
+	msg, err := args.ToMessage(api.backend.RPCGasCap(), vmctx.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+
+	var traceConfig *TraceConfig
+	if config != nil {
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
+}
+
+// callState returns the state and the block context calls are traced with at
+// [blockNrOrHash], with the overrides of [config] applied.
+func (api *API) callState(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (*types.Block, *state.StateDB, vm.BlockContext, StateReleaseFunc, error) {
 	// Try to retrieve the specified block
 	var (
 		err     error
@@ -934,14 +1018,14 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			// more flexibility and stability than trying to trace on 'pending', since
 			// the contents of 'pending' is unstable and probably not a true representation
 			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
+			return nil, nil, vm.BlockContext{}, nil, errors.New("tracing on top of pending is not supported")
 		}
 		block, err = api.blockByNumber(ctx, number)
 	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return nil, nil, vm.BlockContext{}, nil, errors.New("invalid arguments; neither block nor hash specified")
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, vm.BlockContext{}, nil, err
 	}
 	// try to recompute the state
 	reexec := defaultTraceReexec
@@ -955,9 +1039,8 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 		statedb, release, err = api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, vm.BlockContext{}, nil, err
 	}
-	defer release()
 
 	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 
@@ -970,24 +1053,16 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 		blockContext := core.NewBlockContext(block.Number(), block.Time())
 		err = core.ApplyUpgrades(api.backend.ChainConfig(), &originalTime, blockContext, statedb)
 		if err != nil {
-			return nil, err
+			release()
+			return nil, nil, vm.BlockContext{}, nil, err
 		}
 
 		if err := config.StateOverrides.Apply(statedb); err != nil {
-			return nil, err
+			release()
+			return nil, nil, vm.BlockContext{}, nil, err
 		}
 	}
-	// Execute the trace
-	msg, err := args.ToMessage(api.backend.RPCGasCap(), vmctx.BaseFee)
-	if err != nil {
-		return nil, err
-	}
-
-	var traceConfig *TraceConfig
-	if config != nil {
-		traceConfig = &config.TraceConfig
-	}
-	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
+	return block, statedb, vmctx, release, nil
 }
 
 // traceTx configures a new tracer according to the provided configuration, and
@@ -1052,6 +1127,11 @@ func APIs(backend Backend) []rpc.API {
 			Service:   NewFileTracerAPI(backend),
 			Name:      "debug-file-tracer",
 		},
+		{
+			Namespace: "trace",
+			Service:   NewTraceAPI(backend),
+			Name:      "trace",
+		},
 	}
 }
 
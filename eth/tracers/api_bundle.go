@@ -0,0 +1,181 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/internal/ethapi"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+// maximumBundleSize is the maximum number of calls or txs traced by a single
+// request
+const maximumBundleSize = 1024
+
+// TraceCallManyConfig is the config for the bundle tracing APIs. The overrides
+// of TraceCallConfig are applied before the first call, and the state diff
+// from the state the bundle starts on to the state after the last call is
+// returned if StateDiff is set.
+type TraceCallManyConfig struct {
+	TraceCallConfig
+	StateDiff bool
+}
+
+// BundleCall is a call of a bundle, traced after applying its state
+// overrides on top of the previous calls
+type BundleCall struct {
+	ethapi.TransactionArgs
+	StateOverrides *ethapi.StateOverride `json:"stateOverrides"`
+}
+
+// BundleCallResult is the trace of a call or tx of a bundle, or the error
+// preventing its execution, in which case it did not change the state
+type BundleCallResult struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// BundleResult holds the traces of the calls or txs of a bundle, in order
+type BundleResult struct {
+	Results   []*BundleCallResult `json:"results"`
+	StateDiff *core.StateDiff     `json:"stateDiff,omitempty"`
+}
+
+// bundleStep prepares the [i]th call or tx of a bundle on [statedb]
+type bundleStep func(i int, statedb *state.StateDB, vmctx vm.BlockContext) (*core.Message, *Context, error)
+
+// TraceCallMany traces the [calls] sequentially on top of the state at
+// [blockNrOrHash], each call seeing the state changes of the previous ones.
+// It is meant to evaluate multi-step flows without sending any tx.
+func (api *API) TraceCallMany(ctx context.Context, calls []BundleCall, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallManyConfig) (*BundleResult, error) {
+	overrides := make([]*ethapi.StateOverride, len(calls))
+	for i := range calls {
+		overrides[i] = calls[i].StateOverrides
+	}
+	return api.traceBundle(ctx, len(calls), blockNrOrHash, config, overrides, func(i int, statedb *state.StateDB, vmctx vm.BlockContext) (*core.Message, *Context, error) {
+		msg, err := calls[i].ToMessage(api.backend.RPCGasCap(), vmctx.BaseFee)
+		if err != nil {
+			return nil, nil, err
+		}
+		return msg, &Context{TxIndex: i}, nil
+	})
+}
+
+// TraceBundle traces the signed [txs] sequentially on top of the state at
+// [blockNrOrHash], like eth_callBundle. Unlike calls, the txs are checked
+// against the nonce and balance of their sender.
+func (api *API) TraceBundle(ctx context.Context, txs []hexutil.Bytes, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallManyConfig) (*BundleResult, error) {
+	if err := checkBundleSize(len(txs)); err != nil {
+		return nil, err
+	}
+	decoded := make([]*types.Transaction, len(txs))
+	for i, encoded := range txs {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(encoded); err != nil {
+			return nil, fmt.Errorf("invalid tx %d: %w", i, err)
+		}
+		decoded[i] = tx
+	}
+	return api.traceBundle(ctx, len(txs), blockNrOrHash, config, nil, func(i int, statedb *state.StateDB, vmctx vm.BlockContext) (*core.Message, *Context, error) {
+		signer := types.MakeSigner(api.backend.ChainConfig(), vmctx.BlockNumber, vmctx.Time)
+		msg, err := core.TransactionToMessage(decoded[i], signer, vmctx.BaseFee)
+		if err != nil {
+			return nil, nil, err
+		}
+		return msg, &Context{TxIndex: i, TxHash: decoded[i].Hash()}, nil
+	})
+}
+
+// traceBundle traces the [n] calls or txs of a bundle prepared by [step],
+// applying the state overrides of the calls in [overrides] first, if any
+func (api *API) traceBundle(ctx context.Context, n int, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallManyConfig, overrides []*ethapi.StateOverride, step bundleStep) (*BundleResult, error) {
+	if err := checkBundleSize(n); err != nil {
+		return nil, err
+	}
+	var callConfig *TraceCallConfig
+	if config != nil {
+		callConfig = &config.TraceCallConfig
+	}
+	block, statedb, vmctx, release, err := api.callState(ctx, blockNrOrHash, callConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var pre *state.StateDB
+	if config != nil && config.StateDiff {
+		pre = statedb.Copy()
+		statedb.TrackTouched()
+	}
+	var traceConfig *TraceConfig
+	if callConfig != nil {
+		traceConfig = &callConfig.TraceConfig
+	}
+	res := &BundleResult{Results: make([]*BundleCallResult, n)}
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// A failed call leaves no changes, including its state overrides.
+		// Applying the overrides finalises them, so they are only undone by
+		// restoring a copy of the state.
+		var (
+			snapshot = statedb.Snapshot()
+			backup   *state.StateDB
+		)
+		if i < len(overrides) && overrides[i] != nil {
+			backup = statedb.Copy()
+		}
+		result, err := api.traceBundleCall(ctx, i, block, statedb, vmctx, traceConfig, overrides, step)
+		if err != nil {
+			if backup != nil {
+				statedb = backup
+			} else {
+				statedb.RevertToSnapshot(snapshot)
+			}
+			res.Results[i] = &BundleCallResult{Error: err.Error()}
+			continue
+		}
+		res.Results[i] = &BundleCallResult{Result: result}
+		// Finalise the changes like between the txs of a block
+		statedb.Finalise(true)
+	}
+	if pre != nil {
+		res.StateDiff = core.NewStateDiff(pre, statedb, statedb.Touched())
+	}
+	return res, nil
+}
+
+// traceBundleCall applies the state overrides of the [i]th call or tx of a
+// bundle, if any, and traces it.
+func (api *API) traceBundleCall(ctx context.Context, i int, block *types.Block, statedb *state.StateDB, vmctx vm.BlockContext, config *TraceConfig, overrides []*ethapi.StateOverride, step bundleStep) (interface{}, error) {
+	if i < len(overrides) {
+		if err := overrides[i].Apply(statedb); err != nil {
+			return nil, err
+		}
+	}
+	msg, txctx, err := step(i, statedb, vmctx)
+	if err != nil {
+		return nil, err
+	}
+	txctx.BlockHash, txctx.BlockNumber = block.Hash(), vmctx.BlockNumber
+	return api.traceTx(ctx, msg, txctx, vmctx, statedb, config)
+}
+
+// checkBundleSize returns an error if a bundle of [n] calls or txs is too
+// large to be traced
+func checkBundleSize(n int) error {
+	if n > maximumBundleSize {
+		return fmt.Errorf("bundle too large: %d > %d", n, maximumBundleSize)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/internal/ethapi"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	var (
+		tracer   = "callTracer"
+		contract = common.HexToAddress("0xaa")
+		// stores 1 at slot 0
+		code  = hexutil.Bytes(common.FromHex("600160005500"))
+		value = (*hexutil.Big)(big.NewInt(1000))
+		calls = []BundleCall{
+			{TransactionArgs: ethapi.TransactionArgs{From: &accounts[0].addr, To: &accounts[1].addr, Value: value}},
+			{
+				TransactionArgs: ethapi.TransactionArgs{From: &accounts[0].addr, To: &contract},
+				StateOverrides:  &ethapi.StateOverride{contract: {Code: &code}},
+			},
+			// account 2 has no funds
+			{TransactionArgs: ethapi.TransactionArgs{From: &accounts[2].addr, To: &accounts[1].addr, Value: value}},
+		}
+		config = &TraceCallManyConfig{
+			TraceCallConfig: TraceCallConfig{TraceConfig: TraceConfig{Tracer: &tracer}},
+			StateDiff:       true,
+		}
+	)
+	res, err := api.TraceCallMany(context.Background(), calls, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	require.NoError(err)
+	require.Len(res.Results, 3)
+
+	var frame struct {
+		From  common.Address `json:"from"`
+		To    common.Address `json:"to"`
+		Value *hexutil.Big   `json:"value"`
+	}
+	require.NoError(json.Unmarshal(res.Results[0].Result.(json.RawMessage), &frame))
+	require.Equal(accounts[0].addr, frame.From)
+	require.Equal(accounts[1].addr, frame.To)
+	require.Equal(value, frame.Value)
+	require.Empty(res.Results[0].Error)
+	require.NotNil(res.Results[1].Result)
+	require.Nil(res.Results[2].Result)
+	require.Contains(res.Results[2].Error, "insufficient funds")
+
+	diffs := make(map[common.Address]*core.AccountDiff)
+	for _, account := range res.StateDiff.Accounts {
+		diffs[account.Address] = account
+	}
+	require.Equal(&core.Change[hexutil.Uint64]{From: 0, To: 2}, diffs[accounts[0].addr].Nonce)
+	require.True(diffs[accounts[1].addr].Created)
+	require.Equal(value, diffs[accounts[1].addr].Balance.To)
+	require.Equal(common.BigToHash(big.NewInt(1)), diffs[contract].Storage[common.Hash{}].To)
+	require.NotContains(diffs, accounts[2].addr)
+
+	// The state overrides of a failed call are dropped
+	calls = []BundleCall{
+		{
+			TransactionArgs: ethapi.TransactionArgs{From: &accounts[2].addr, To: &accounts[1].addr, Value: value},
+			StateOverrides:  &ethapi.StateOverride{contract: {Code: &code}},
+		},
+		{TransactionArgs: ethapi.TransactionArgs{From: &accounts[0].addr, To: &contract}},
+	}
+	res, err = api.TraceCallMany(context.Background(), calls, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	require.NoError(err)
+	require.Contains(res.Results[0].Error, "insufficient funds")
+	require.Empty(res.Results[1].Error)
+	for _, account := range res.StateDiff.Accounts {
+		require.NotEqual(contract, account.Address)
+	}
+
+	// Without the state diff the diff is not computed
+	config.StateDiff = false
+	res, err = api.TraceCallMany(context.Background(), calls[:1], rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	require.NoError(err)
+	require.Len(res.Results, 1)
+	require.Nil(res.StateDiff)
+}
+
+func TestTraceBundle(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.chain.Stop()
+	api := NewAPI(backend)
+
+	signer := types.LatestSigner(genesis.Config)
+	var txs []hexutil.Bytes
+	// The second tx reuses the nonce of the first one
+	for _, nonce := range []uint64{0, 0, 1} {
+		tx := types.MustSignNewTx(accounts[0].key, signer, &types.LegacyTx{
+			Nonce:    nonce,
+			To:       &accounts[1].addr,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: big.NewInt(0),
+		})
+		encoded, err := tx.MarshalBinary()
+		require.NoError(err)
+		txs = append(txs, encoded)
+	}
+	tracer := "callTracer"
+	config := &TraceCallManyConfig{
+		TraceCallConfig: TraceCallConfig{TraceConfig: TraceConfig{Tracer: &tracer}},
+		StateDiff:       true,
+	}
+	res, err := api.TraceBundle(context.Background(), txs, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), config)
+	require.NoError(err)
+	require.Len(res.Results, 3)
+	require.Empty(res.Results[0].Error)
+	require.Contains(res.Results[1].Error, "nonce too low")
+	require.Empty(res.Results[2].Error)
+	require.Len(res.StateDiff.Accounts, 2)
+	for _, account := range res.StateDiff.Accounts {
+		if account.Address == accounts[1].addr {
+			require.Equal(big.NewInt(2000), account.Balance.To.ToInt())
+		}
+	}
+
+	_, err = api.TraceBundle(context.Background(), []hexutil.Bytes{{0x01}}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	require.ErrorContains(err, "invalid tx 0")
+	_, err = api.TraceBundle(context.Background(), make([]hexutil.Bytes, maximumBundleSize+1), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	require.ErrorContains(err, "bundle too large")
+}
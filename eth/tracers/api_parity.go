@@ -0,0 +1,512 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+
+	"github.com/ava-labs/coreth/rpc"
+
+	// Register the flatCallTracer, prestateTracer and muxTracer the trace
+	// API is built on
+	_ "github.com/ava-labs/libevm/eth/tracers/native"
+)
+
+const (
+	// maximumTraceFilterRange is the maximum number of blocks trace_filter
+	// traces in a single request
+	maximumTraceFilterRange = 10_000
+
+	// the trace types of trace_replayBlockTransactions
+	traceTypeTrace     = "trace"
+	traceTypeStateDiff = "stateDiff"
+	traceTypeVMTrace   = "vmTrace"
+
+	// names of the tracers producing each trace type
+	flatCallTracerName = "flatCallTracer"
+	prestateTracerName = "prestateTracer"
+	vmTracerName       = "vmTracer"
+)
+
+// errTraceFilterDone stops tracing once trace_filter has found enough traces
+var errTraceFilterDone = errors.New("trace filter done")
+
+// TraceAPI is the collection of OpenEthereum style tracing APIs exposed over
+// the trace namespace. Avalanche blocks have no rewards, so the traces only
+// hold the call frames of the transactions.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the OpenEthereum style tracing
+// methods of the Ethereum service.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// TraceFilterArgs are the arguments of trace_filter. A trace matches if it is
+// from one of [FromAddress] and to one of [ToAddress], where an empty list
+// matches any address. The first [After] matching traces are skipped and at
+// most [Count] traces are returned.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// TraceResults holds the traces of a transaction requested from
+// trace_replayBlockTransactions. The traces which were not requested are
+// nil.
+type TraceResults struct {
+	Output          hexutil.Bytes                   `json:"output"`
+	StateDiff       map[common.Address]*AccountDiff `json:"stateDiff"`
+	Trace           []json.RawMessage               `json:"trace"`
+	VMTrace         json.RawMessage                 `json:"vmTrace"`
+	TransactionHash common.Hash                     `json:"transactionHash"`
+}
+
+// AccountDiff is the change of an account made by a transaction. Every value
+// is either "=" if unchanged, or an object keyed by "+" if the account was
+// created, "-" if it was destroyed or "*" with the "from" and "to" values if
+// it was changed.
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Code    interface{}                 `json:"code"`
+	Nonce   interface{}                 `json:"nonce"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// prestateAccount is an account of the prestateTracer output
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Code    hexutil.Bytes               `json:"code"`
+	Nonce   uint64                      `json:"nonce"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// exists returns whether the account is not empty
+func (a *prestateAccount) exists() bool {
+	return a.Nonce > 0 || len(a.Code) > 0 || len(a.Storage) > 0 || (a.Balance != nil && a.Balance.ToInt().Sign() != 0)
+}
+
+// flatCallResult is the part of a flatCallTracer frame used by the trace API
+type flatCallResult struct {
+	Type   string `json:"type"`
+	Error  string `json:"error"`
+	Action struct {
+		From          *common.Address `json:"from"`
+		To            *common.Address `json:"to"`
+		Address       *common.Address `json:"address"`
+		RefundAddress *common.Address `json:"refundAddress"`
+	} `json:"action"`
+	Result *struct {
+		Address *common.Address `json:"address"`
+		Code    hexutil.Bytes   `json:"code"`
+		Output  hexutil.Bytes   `json:"output"`
+	} `json:"result"`
+}
+
+// from returns the caller of a call or creation, or the selfdestructed
+// contract
+func (f *flatCallResult) from() *common.Address {
+	if f.Action.From != nil {
+		return f.Action.From
+	}
+	return f.Action.Address
+}
+
+// to returns the callee of a call, the created contract or the beneficiary
+// of a selfdestruct
+func (f *flatCallResult) to() *common.Address {
+	switch {
+	case f.Action.To != nil:
+		return f.Action.To
+	case f.Result != nil && f.Result.Address != nil:
+		return f.Result.Address
+	default:
+		return f.Action.RefundAddress
+	}
+}
+
+// Block returns the traces of the call frames of all the transactions of
+// block [number].
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]json.RawMessage, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if block.NumberU64() == 0 {
+		return []json.RawMessage{}, nil
+	}
+	results, err := api.api.traceBlock(ctx, block, flatCallTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	traces := []json.RawMessage{}
+	for _, res := range results {
+		frames, err := flatTraces(res)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, frames...)
+	}
+	return traces, nil
+}
+
+// Transaction returns the traces of the call frames of the transaction
+// [hash].
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]json.RawMessage, error) {
+	res, err := api.api.TraceTransaction(ctx, hash, flatCallTraceConfig())
+	if err != nil {
+		return nil, err
+	}
+	return flatTraces(&txTraceResult{TxHash: hash, Result: res})
+}
+
+// Filter returns the traces of the call frames matching [args] in the blocks
+// between [args.FromBlock] and [args.ToBlock] included, which default to the
+// genesis and the latest block. The blocks are traced concurrently, like
+// debug_traceChain.
+func (api *TraceAPI) Filter(ctx context.Context, args TraceFilterArgs) ([]json.RawMessage, error) {
+	fromNumber, toNumber := rpc.EarliestBlockNumber, rpc.LatestBlockNumber
+	if args.FromBlock != nil {
+		fromNumber = *args.FromBlock
+	}
+	if args.ToBlock != nil {
+		toNumber = *args.ToBlock
+	}
+	from, err := api.api.blockByNumber(ctx, fromNumber)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.api.blockByNumber(ctx, toNumber)
+	if err != nil {
+		return nil, err
+	}
+	// The genesis has no transactions
+	start := from.NumberU64()
+	if start > 0 {
+		start--
+	}
+	end := to.NumberU64()
+	if end < from.NumberU64() {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", end, from.NumberU64())
+	}
+	if end-start > maximumTraceFilterRange {
+		return nil, fmt.Errorf("block range (#%d, #%d] exceeds the limit of %d blocks", start, end, maximumTraceFilterRange)
+	}
+	traces := []json.RawMessage{}
+	if end == 0 || (args.Count != nil && *args.Count == 0) {
+		return traces, nil
+	}
+	var (
+		fromSet = addressSet(args.FromAddress)
+		toSet   = addressSet(args.ToAddress)
+		skip    uint64
+	)
+	if args.After != nil {
+		skip = *args.After
+	}
+	err = api.api.TraceChainRange(ctx, start, end, flatCallTraceConfig(), 0, func(_ *types.Header, encoded []byte) error {
+		var results []struct {
+			TxHash common.Hash     `json:"txHash"`
+			Result json.RawMessage `json:"result"`
+			Error  string          `json:"error"`
+		}
+		if err := json.Unmarshal(encoded, &results); err != nil {
+			return err
+		}
+		for _, res := range results {
+			frames, err := flatTraces(&txTraceResult{TxHash: res.TxHash, Result: res.Result, Error: res.Error})
+			if err != nil {
+				return err
+			}
+			for _, frame := range frames {
+				var f flatCallResult
+				if err := json.Unmarshal(frame, &f); err != nil {
+					return err
+				}
+				if !matchAddress(fromSet, f.from()) || !matchAddress(toSet, f.to()) {
+					continue
+				}
+				if skip > 0 {
+					skip--
+					continue
+				}
+				traces = append(traces, frame)
+				if args.Count != nil && uint64(len(traces)) >= *args.Count {
+					return errTraceFilterDone
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errTraceFilterDone) {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// ReplayBlockTransactions replays the transactions of a block and returns
+// the requested [traceTypes] for each of them: "trace" for the call frames,
+// "stateDiff" for the state changes and "vmTrace" for the executed
+// instructions.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, traceTypes []string) ([]*TraceResults, error) {
+	var (
+		tracerConfig = map[string]json.RawMessage{
+			flatCallTracerName: json.RawMessage(`{"convertParityErrors":true}`),
+		}
+		withTrace bool
+	)
+	for _, typ := range traceTypes {
+		switch typ {
+		case traceTypeTrace:
+			withTrace = true
+		case traceTypeStateDiff:
+			tracerConfig[prestateTracerName] = json.RawMessage(`{"diffMode":true}`)
+		case traceTypeVMTrace:
+			tracerConfig[vmTracerName] = json.RawMessage(`{}`)
+		default:
+			return nil, fmt.Errorf("invalid trace type %q", typ)
+		}
+	}
+	encoded, err := json.Marshal(tracerConfig)
+	if err != nil {
+		return nil, err
+	}
+	var block *types.Block
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = api.api.blockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block, err = api.api.blockByNumber(ctx, number)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block.NumberU64() == 0 {
+		return []*TraceResults{}, nil
+	}
+	tracer := "muxTracer"
+	results, err := api.api.traceBlock(ctx, block, &TraceConfig{Tracer: &tracer, TracerConfig: encoded})
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]*TraceResults, len(results))
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("failed to trace tx %s: %s", res.TxHash, res.Error)
+		}
+		raw, ok := res.Result.(json.RawMessage)
+		if !ok {
+			return nil, fmt.Errorf("unexpected muxTracer result %T", res.Result)
+		}
+		var outputs map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &outputs); err != nil {
+			return nil, err
+		}
+		replay := &TraceResults{TransactionHash: res.TxHash, Output: hexutil.Bytes{}}
+		frames, err := flatTraces(&txTraceResult{TxHash: res.TxHash, Result: outputs[flatCallTracerName]})
+		if err != nil {
+			return nil, err
+		}
+		created := make(map[common.Address]struct{})
+		for j, encodedFrame := range frames {
+			var f flatCallResult
+			if err := json.Unmarshal(encodedFrame, &f); err != nil {
+				return nil, err
+			}
+			if j == 0 && f.Result != nil {
+				if f.Result.Code != nil {
+					replay.Output = f.Result.Code
+				} else if f.Result.Output != nil {
+					replay.Output = f.Result.Output
+				}
+			}
+			if f.Type == "create" && f.Error == "" && f.Result != nil && f.Result.Address != nil {
+				created[*f.Result.Address] = struct{}{}
+			}
+		}
+		if withTrace {
+			replay.Trace = frames
+		}
+		if diff, ok := outputs[prestateTracerName]; ok {
+			if replay.StateDiff, err = stateDiff(diff, created); err != nil {
+				return nil, err
+			}
+		}
+		replay.VMTrace = outputs[vmTracerName]
+		replays[i] = replay
+	}
+	return replays, nil
+}
+
+// flatCallTraceConfig returns the config of the flatCallTracer, reporting
+// errors the way OpenEthereum does
+func flatCallTraceConfig() *TraceConfig {
+	tracer := flatCallTracerName
+	return &TraceConfig{Tracer: &tracer, TracerConfig: json.RawMessage(`{"convertParityErrors":true}`)}
+}
+
+// flatTraces splits the flatCallTracer output of [res] into its frames
+func flatTraces(res *txTraceResult) ([]json.RawMessage, error) {
+	if res.Error != "" {
+		return nil, fmt.Errorf("failed to trace tx %s: %s", res.TxHash, res.Error)
+	}
+	encoded, ok := res.Result.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected flatCallTracer result %T", res.Result)
+	}
+	var frames []json.RawMessage
+	if err := json.Unmarshal(encoded, &frames); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
+
+// stateDiff converts the prestateTracer output in diff mode to an
+// OpenEthereum state diff, where [created] are the contracts created by the
+// transaction. The prestateTracer reports the accounts which did not exist
+// as empty, and the contracts once their creation started, so they are told
+// apart from the accounts which already existed using the call frames.
+func stateDiff(encoded json.RawMessage, created map[common.Address]struct{}) (map[common.Address]*AccountDiff, error) {
+	var prestate struct {
+		Pre  map[common.Address]*prestateAccount `json:"pre"`
+		Post map[common.Address]*prestateAccount `json:"post"`
+	}
+	if err := json.Unmarshal(encoded, &prestate); err != nil {
+		return nil, err
+	}
+	diffs := make(map[common.Address]*AccountDiff)
+	for addr, pre := range prestate.Pre {
+		post, ok := prestate.Post[addr]
+		if _, isCreated := created[addr]; isCreated || !pre.exists() {
+			// An account destroyed by the transaction which created it
+			// leaves no diff
+			if ok {
+				diffs[addr] = accountDiff(createdAccount(pre, post), "+")
+			}
+			continue
+		}
+		if !ok {
+			// The prestateTracer only keeps in pre the accounts which were
+			// modified, so the account was destroyed
+			diffs[addr] = accountDiff(pre, "-")
+			continue
+		}
+		diff := &AccountDiff{Balance: "=", Code: "=", Nonce: "=", Storage: make(map[common.Hash]interface{})}
+		if post.Balance != nil {
+			diff.Balance = changed(balanceOf(pre), post.Balance)
+		}
+		if post.Code != nil {
+			diff.Code = changed(hexutil.Bytes(common.CopyBytes(pre.Code)), post.Code)
+		}
+		if post.Nonce != 0 && post.Nonce != pre.Nonce {
+			diff.Nonce = changed(hexutil.Uint64(pre.Nonce), hexutil.Uint64(post.Nonce))
+		}
+		for key, from := range pre.Storage {
+			if to := post.Storage[key]; to != from {
+				diff.Storage[key] = changed(from, to)
+			}
+		}
+		for key, to := range post.Storage {
+			if _, ok := pre.Storage[key]; !ok {
+				diff.Storage[key] = changed(common.Hash{}, to)
+			}
+		}
+		diffs[addr] = diff
+	}
+	for addr, post := range prestate.Post {
+		if _, ok := prestate.Pre[addr]; !ok {
+			diffs[addr] = accountDiff(post, "+")
+		}
+	}
+	return diffs, nil
+}
+
+// accountDiff returns the diff of an account created or destroyed, where
+// [kind] is "+" or "-"
+func accountDiff(account *prestateAccount, kind string) *AccountDiff {
+	diff := &AccountDiff{
+		Balance: map[string]interface{}{kind: balanceOf(account)},
+		Code:    map[string]interface{}{kind: hexutil.Bytes(common.CopyBytes(account.Code))},
+		Nonce:   map[string]interface{}{kind: hexutil.Uint64(account.Nonce)},
+		Storage: make(map[common.Hash]interface{}),
+	}
+	for key, val := range account.Storage {
+		diff.Storage[key] = map[string]interface{}{kind: val}
+	}
+	return diff
+}
+
+// createdAccount returns the state of a contract created by a transaction,
+// given its state once its creation started and the changes made since
+func createdAccount(pre, post *prestateAccount) *prestateAccount {
+	account := &prestateAccount{
+		Balance: pre.Balance,
+		Code:    pre.Code,
+		Nonce:   pre.Nonce,
+		Storage: post.Storage,
+	}
+	if post.Balance != nil {
+		account.Balance = post.Balance
+	}
+	if post.Code != nil {
+		account.Code = post.Code
+	}
+	if post.Nonce != 0 {
+		account.Nonce = post.Nonce
+	}
+	return account
+}
+
+// changed returns the diff of a value changed from [from] to [to]
+func changed(from, to interface{}) interface{} {
+	return map[string]interface{}{"*": map[string]interface{}{"from": from, "to": to}}
+}
+
+// balanceOf returns the balance of [account], zero if omitted
+func balanceOf(account *prestateAccount) *hexutil.Big {
+	if account.Balance == nil {
+		return (*hexutil.Big)(new(big.Int))
+	}
+	return account.Balance
+}
+
+// addressSet returns the set of [addresses], nil if empty
+func addressSet(addresses []common.Address) map[common.Address]struct{} {
+	if len(addresses) == 0 {
+		return nil
+	}
+	set := make(map[common.Address]struct{}, len(addresses))
+	for _, addr := range addresses {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+// matchAddress returns whether [addr] is in [set], where a nil set matches
+// any address
+func matchAddress(set map[common.Address]struct{}, addr *common.Address) bool {
+	if set == nil {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	_, ok := set[*addr]
+	return ok
+}
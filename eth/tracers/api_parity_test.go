@@ -0,0 +1,143 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+func TestTraceAPI(t *testing.T) {
+	require := require.New(t)
+
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	signer := types.HomesteadSigner{}
+	var hashes []common.Hash
+	// Block 1 deploys a contract storing 1 at slot 0, the next blocks send
+	// 1000 wei to accounts 1 and 2 in turn
+	backend := newTestBackend(t, 4, genesis, func(i int, b *core.BlockGen) {
+		var tx *types.Transaction
+		if i == 0 {
+			tx = types.NewContractCreation(0, big.NewInt(0), 100_000, b.BaseFee(), common.FromHex("600160005500"))
+		} else {
+			tx = types.NewTransaction(uint64(i), accounts[2-i%2].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil)
+		}
+		tx, _ = types.SignTx(tx, signer, accounts[0].key)
+		b.AddTx(tx)
+		hashes = append(hashes, tx.Hash())
+	})
+	defer backend.chain.Stop()
+	api := NewTraceAPI(backend)
+	ctx := context.Background()
+
+	type frame struct {
+		Action struct {
+			From  common.Address `json:"from"`
+			To    common.Address `json:"to"`
+			Value *hexutil.Big   `json:"value"`
+		} `json:"action"`
+		BlockNumber     uint64      `json:"blockNumber"`
+		TransactionHash common.Hash `json:"transactionHash"`
+		Type            string      `json:"type"`
+	}
+	decode := func(traces []json.RawMessage) []frame {
+		frames := make([]frame, len(traces))
+		for i, trace := range traces {
+			require.NoError(json.Unmarshal(trace, &frames[i]))
+		}
+		return frames
+	}
+
+	traces, err := api.Block(ctx, 2)
+	require.NoError(err)
+	frames := decode(traces)
+	require.Len(frames, 1)
+	require.Equal("call", frames[0].Type)
+	require.Equal(accounts[0].addr, frames[0].Action.From)
+	require.Equal(accounts[1].addr, frames[0].Action.To)
+	require.Equal(big.NewInt(1000), frames[0].Action.Value.ToInt())
+	require.Equal(uint64(2), frames[0].BlockNumber)
+	require.Equal(hashes[1], frames[0].TransactionHash)
+
+	traces, err = api.Transaction(ctx, hashes[0])
+	require.NoError(err)
+	frames = decode(traces)
+	require.Len(frames, 1)
+	require.Equal("create", frames[0].Type)
+
+	// Accounts 1 and 2 received 1000 wei in blocks 2, 4 and 3
+	to := func(addr common.Address) TraceFilterArgs {
+		return TraceFilterArgs{ToAddress: []common.Address{addr}}
+	}
+	traces, err = api.Filter(ctx, to(accounts[1].addr))
+	require.NoError(err)
+	frames = decode(traces)
+	require.Len(frames, 2)
+	require.Equal(uint64(2), frames[0].BlockNumber)
+	require.Equal(uint64(4), frames[1].BlockNumber)
+
+	var (
+		after, count = uint64(1), uint64(1)
+		fromBlock    = rpc.BlockNumber(2)
+	)
+	args := TraceFilterArgs{FromBlock: &fromBlock, FromAddress: []common.Address{accounts[0].addr}, After: &after, Count: &count}
+	traces, err = api.Filter(ctx, args)
+	require.NoError(err)
+	frames = decode(traces)
+	require.Len(frames, 1)
+	require.Equal(uint64(3), frames[0].BlockNumber)
+
+	traces, err = api.Filter(ctx, to(accounts[0].addr))
+	require.NoError(err)
+	require.Empty(traces)
+
+	// The deployment creates the contract with its storage
+	replays, err := api.ReplayBlockTransactions(ctx, rpc.BlockNumberOrHashWithNumber(1), []string{"trace", "stateDiff"})
+	require.NoError(err)
+	require.Len(replays, 1)
+	require.Equal(hashes[0], replays[0].TransactionHash)
+	require.Len(replays[0].Trace, 1)
+	require.Nil(replays[0].VMTrace)
+	contract := crypto.CreateAddress(accounts[0].addr, 0)
+	diff, err := json.Marshal(replays[0].StateDiff[contract])
+	require.NoError(err)
+	require.JSONEq(`{
+		"balance": {"+": "0x0"},
+		"code": {"+": "0x"},
+		"nonce": {"+": "0x1"},
+		"storage": {"0x0000000000000000000000000000000000000000000000000000000000000000": {"+": "0x0000000000000000000000000000000000000000000000000000000000000001"}}
+	}`, string(diff))
+	diff, err = json.Marshal(replays[0].StateDiff[accounts[0].addr].Nonce)
+	require.NoError(err)
+	require.JSONEq(`{"*": {"from": "0x0", "to": "0x1"}}`, string(diff))
+
+	replays, err = api.ReplayBlockTransactions(ctx, rpc.BlockNumberOrHashWithNumber(2), []string{"stateDiff"})
+	require.NoError(err)
+	require.Len(replays, 1)
+	require.Nil(replays[0].Trace)
+	diff, err = json.Marshal(replays[0].StateDiff[accounts[1].addr])
+	require.NoError(err)
+	require.JSONEq(`{"balance": {"+": "0x3e8"}, "code": {"+": "0x"}, "nonce": {"+": "0x0"}, "storage": {}}`, string(diff))
+
+	_, err = api.ReplayBlockTransactions(ctx, rpc.BlockNumberOrHashWithNumber(2), []string{"unknown"})
+	require.ErrorContains(err, "invalid trace type")
+}
@@ -1006,7 +1006,7 @@ func TestTraceChain(t *testing.T) {
 
 		from, _ := api.blockByNumber(context.Background(), rpc.BlockNumber(c.start))
 		to, _ := api.blockByNumber(context.Background(), rpc.BlockNumber(c.end))
-		resCh := api.traceChain(from, to, c.config, nil)
+		resCh := api.traceChain(from, to, c.config, chainTraceOptions{}, nil)
 
 		next := c.start + 1
 		for result := range resCh {
@@ -1034,3 +1034,68 @@ func TestTraceChain(t *testing.T) {
 		}
 	}
 }
+
+func TestTraceChainRange(t *testing.T) {
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(5 * params.Ether)},
+		},
+	}
+	signer := types.HomesteadSigner{}
+	var nonce uint64
+	backend := newTestBackend(t, 20, genesis, func(i int, b *core.BlockGen) {
+		// Only every other block carries a transaction
+		if i%2 == 0 {
+			return
+		}
+		tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+		nonce++
+	})
+	api := NewAPI(backend)
+
+	// Blocks without transactions must be reported too
+	next := uint64(6)
+	err := api.TraceChainRange(context.Background(), 5, 15, nil, 2, func(header *types.Header, traces []byte) error {
+		number, hash := header.Number.Uint64(), header.Hash()
+		if number != next {
+			t.Fatalf("unexpected tracing block, have %d want %d", number, next)
+		}
+		block, err := api.blockByNumber(context.Background(), rpc.BlockNumber(number))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block.Hash() != hash {
+			t.Fatalf("unexpected block hash, have %x want %x", hash, block.Hash())
+		}
+		var results []*txTraceResult
+		if err := json.Unmarshal(traces, &results); err != nil {
+			t.Fatal(err)
+		}
+		if have, want := len(results), len(block.Transactions()); have != want {
+			t.Fatalf("unexpected result length, have %d want %d", have, want)
+		}
+		next++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != 16 {
+		t.Fatalf("missing tracing blocks, next %d", next)
+	}
+
+	// An error returned by the callback stops tracing
+	errStop := errors.New("stop")
+	err = api.TraceChainRange(context.Background(), 0, 20, nil, 0, func(header *types.Header, _ []byte) error {
+		if header.Number.Uint64() == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("unexpected error, have %v want %v", err, errStop)
+	}
+}
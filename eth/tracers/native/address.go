@@ -0,0 +1,100 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/vm"
+
+	"github.com/ava-labs/coreth/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("addressTracer", newAddressTracer, false)
+}
+
+// addressTracer reports the sorted set of addresses taking part in the call
+// frames of a transaction, as caller, callee, created contract or
+// selfdestruct beneficiary, including the frames which reverted.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x..", {tracer: "addressTracer"})
+//	["0x..", "0x.."]
+type addressTracer struct {
+	addresses map[common.Address]struct{}
+	interrupt atomic.Bool
+	reason    error
+}
+
+// newAddressTracer returns a native go tracer which reports the addresses
+// touched by the calls of a tx, and implements vm.EVMLogger.
+func newAddressTracer(_ *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &addressTracer{addresses: make(map[common.Address]struct{})}, nil
+}
+
+// CaptureTxStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *addressTracer) CaptureTxStart(gasLimit uint64) {}
+
+// CaptureTxEnd implements the EVMLogger interface to finalize the tracing operation.
+func (t *addressTracer) CaptureTxEnd(restGas uint64) {}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *addressTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.addresses[from] = struct{}{}
+	t.addresses[to] = struct{}{}
+}
+
+// CaptureEnd is called after the top-level call finishes.
+func (t *addressTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *addressTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.addresses[from] = struct{}{}
+	t.addresses[to] = struct{}{}
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *addressTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *addressTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *addressTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// GetResult returns the json-encoded sorted list of addresses, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *addressTracer) GetResult() (json.RawMessage, error) {
+	addresses := make([]common.Address, 0, len(t.addresses))
+	for addr := range t.addresses {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+	res, err := json.Marshal(addresses)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *addressTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/vm/runtime"
+	"github.com/ava-labs/coreth/eth/tracers"
+)
+
+func TestAddressTracer(t *testing.T) {
+	var (
+		origin   = common.HexToAddress("0x0a")
+		contract = common.HexToAddress("0xaa")
+		callee   = common.HexToAddress("0xbb")
+		reverter = common.HexToAddress("0xdd")
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+	// call callee, then reverter, which reverts but is reported too
+	call := func(to common.Address) string {
+		return "6000600060006000600073" + common.Bytes2Hex(to[:]) + "5af150"
+	}
+	statedb.SetCode(contract, common.FromHex(call(callee)+call(reverter)))
+	statedb.SetCode(reverter, common.FromHex("60006000fd"))
+
+	tracer, err := tracers.DefaultDirectory.New("addressTracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+	_, _, err = runtime.Call(contract, nil, &runtime.Config{
+		Origin:    origin,
+		GasLimit:  100_000,
+		GasPrice:  big.NewInt(0),
+		State:     statedb,
+		EVMConfig: vm.Config{Tracer: tracer},
+	})
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	var got []common.Address
+	require.NoError(t, json.Unmarshal(res, &got))
+	require.Equal(t, []common.Address{origin, contract, callee, reverter}, got)
+}
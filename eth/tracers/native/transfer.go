@@ -0,0 +1,226 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package native holds the native tracers of coreth, which are registered in
+// the tracer directory alongside the tracers bundled by libevm.
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/vm"
+
+	"github.com/ava-labs/coreth/constants"
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/nativeasset"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("transferTracer", newTransferTracer, false)
+}
+
+// types of the transfers reported by the transferTracer
+const (
+	TransferCall         = "call"         // value sent by a call
+	TransferCreate       = "create"       // value endowed to a created contract
+	TransferSelfDestruct = "selfdestruct" // balance sent to the beneficiary of a selfdestruct
+	TransferNativeAsset  = "nativeAsset"  // multicoin asset sent through the NativeAssetCall precompile
+	TransferBurn         = "burn"         // transaction fee burned, ie. paid to the blackhole coinbase
+	TransferCoinbase     = "coinbase"     // transaction fee paid to any other coinbase
+)
+
+// Transfer is a movement of AVAX, or of a multicoin asset if AssetID is set
+type Transfer struct {
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	AssetID *common.Hash   `json:"assetID,omitempty"`
+	Amount  *hexutil.Big   `json:"amount"`
+	TxIndex int            `json:"txIndex"`
+	Depth   int            `json:"depth"`
+	Type    string         `json:"type"`
+}
+
+// transferTracer reports every value transfer of a transaction as a flat
+// list, in execution order: the value of calls and creations at any depth,
+// the balances sent by selfdestructs, the multicoin assets sent through the
+// NativeAssetCall precompile and the transaction fee. The transfers of a
+// reverted call frame are dropped.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x..", {tracer: "transferTracer"})
+//	[
+//	  {"from": "0x..", "to": "0x..", "amount": "0xde0b6b3a7640000", "txIndex": 0, "depth": 0, "type": "call"},
+//	  {"from": "0x..", "to": "0x0100000000000000000000000000000000000000", "amount": "0x5208", "txIndex": 0, "depth": 0, "type": "burn"}
+//	]
+type transferTracer struct {
+	txIndex  int
+	env      *vm.EVM
+	gasLimit uint64
+	// frames holds the transfers of every open call frame, the top-level
+	// frame first
+	frames    [][]Transfer
+	transfers []Transfer // transfers of the finished top-level frame
+	interrupt atomic.Bool
+	reason    error
+}
+
+// newTransferTracer returns a native go tracer which reports the value
+// transfers of a tx, and implements vm.EVMLogger.
+func newTransferTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	t := &transferTracer{}
+	if ctx != nil {
+		t.txIndex = ctx.TxIndex
+	}
+	return t, nil
+}
+
+// CaptureTxStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *transferTracer) CaptureTxStart(gasLimit uint64) {
+	t.gasLimit = gasLimit
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *transferTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.frames = [][]Transfer{nil}
+	typ := TransferCall
+	if create {
+		typ = TransferCreate
+	}
+	t.enter(typ, from, to, input, value)
+}
+
+// CaptureEnd is called after the top-level call finishes.
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	if err == nil {
+		t.transfers = append(t.transfers, t.frames[0]...)
+	}
+	t.frames = nil
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *transferTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if t.interrupt.Load() {
+		return
+	}
+	t.frames = append(t.frames, nil)
+	switch typ {
+	case vm.CALL:
+		t.enter(TransferCall, from, to, input, value)
+	case vm.CREATE, vm.CREATE2:
+		t.enter(TransferCreate, from, to, input, value)
+	case vm.SELFDESTRUCT:
+		t.enter(TransferSelfDestruct, from, to, nil, value)
+	}
+	// CALLCODE sends the value to the caller itself, DELEGATECALL and
+	// STATICCALL transfer nothing
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *transferTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if t.interrupt.Load() || len(t.frames) < 2 {
+		return
+	}
+	n := len(t.frames)
+	frame := t.frames[n-1]
+	t.frames = t.frames[:n-1]
+	if err == nil {
+		t.frames[n-2] = append(t.frames[n-2], frame...)
+	}
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *transferTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *transferTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureTxEnd records the fee paid for the gas used by the tx
+func (t *transferTracer) CaptureTxEnd(restGas uint64) {
+	if t.env == nil || t.env.TxContext.GasPrice == nil {
+		return
+	}
+	fee := new(big.Int).SetUint64(t.gasLimit - restGas)
+	fee.Mul(fee, t.env.TxContext.GasPrice)
+	if fee.Sign() == 0 {
+		return
+	}
+	coinbase := t.env.Context.Coinbase
+	typ := TransferCoinbase
+	if coinbase == constants.BlackholeAddr {
+		typ = TransferBurn
+	}
+	t.transfers = append(t.transfers, Transfer{
+		From:    t.env.TxContext.Origin,
+		To:      coinbase,
+		Amount:  (*hexutil.Big)(fee),
+		TxIndex: t.txIndex,
+		Type:    typ,
+	})
+}
+
+// enter records the transfers of the call frame just opened
+func (t *transferTracer) enter(typ string, from, to common.Address, input []byte, value *big.Int) {
+	depth := len(t.frames) - 1
+	frame := &t.frames[depth]
+	if value != nil && value.Sign() > 0 {
+		*frame = append(*frame, Transfer{
+			From:    from,
+			To:      to,
+			Amount:  (*hexutil.Big)(new(big.Int).Set(value)),
+			TxIndex: t.txIndex,
+			Depth:   depth,
+			Type:    typ,
+		})
+	}
+	if typ != TransferCall || to != nativeasset.NativeAssetCallAddr {
+		return
+	}
+	// The precompile sends the asset from the caller before calling the
+	// recipient. Its input is validated by the precompile, which reverts if
+	// it is malformed and so drops this frame.
+	recipient, assetID, amount, _, err := nativeasset.UnpackNativeAssetCallInput(input)
+	if err != nil || amount.Sign() == 0 {
+		return
+	}
+	*frame = append(*frame, Transfer{
+		From:    from,
+		To:      recipient,
+		AssetID: &assetID,
+		Amount:  (*hexutil.Big)(amount),
+		TxIndex: t.txIndex,
+		Depth:   depth,
+		Type:    TransferNativeAsset,
+	})
+}
+
+// GetResult returns the json-encoded list of transfers, and any error
+// arising from the encoding or forceful termination (via `Stop`).
+func (t *transferTracer) GetResult() (json.RawMessage, error) {
+	transfers := t.transfers
+	if transfers == nil {
+		transfers = []Transfer{}
+	}
+	res, err := json.Marshal(transfers)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *transferTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
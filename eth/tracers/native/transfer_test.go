@@ -0,0 +1,103 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/constants"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/vm/runtime"
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/nativeasset"
+)
+
+func TestTransferTracer(t *testing.T) {
+	var (
+		origin   = common.HexToAddress("0x0a")
+		contract = common.HexToAddress("0xaa")
+		paid     = common.HexToAddress("0xbb")
+		heir     = common.HexToAddress("0xcc")
+		reverter = common.HexToAddress("0xdd")
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+	statedb.SetBalance(origin, uint256.NewInt(1_000_000_000))
+	statedb.SetBalance(contract, uint256.NewInt(10))
+	// send 1 to paid, 2 to reverter, which reverts, and selfdestruct to heir
+	call := func(to common.Address, value byte) string {
+		return "6000600060006000" + "60" + common.Bytes2Hex([]byte{value}) + "73" + common.Bytes2Hex(to[:]) + "5af150"
+	}
+	code := call(paid, 1) + call(reverter, 2) + "73" + common.Bytes2Hex(heir[:]) + "ff"
+	statedb.SetCode(contract, common.FromHex(code))
+	statedb.SetCode(reverter, common.FromHex("60006000fd"))
+
+	tracer, err := tracers.DefaultDirectory.New("transferTracer", &tracers.Context{TxIndex: 3}, nil)
+	require.NoError(t, err)
+	const gasLimit = 100_000
+	tracer.CaptureTxStart(gasLimit)
+	_, rest, err := runtime.Call(contract, nil, &runtime.Config{
+		Origin:    origin,
+		Coinbase:  constants.BlackholeAddr,
+		GasLimit:  gasLimit,
+		GasPrice:  big.NewInt(2),
+		Value:     big.NewInt(5),
+		State:     statedb,
+		EVMConfig: vm.Config{Tracer: tracer},
+	})
+	require.NoError(t, err)
+	tracer.CaptureTxEnd(rest)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	var got []Transfer
+	require.NoError(t, json.Unmarshal(res, &got))
+	want := []Transfer{
+		{From: origin, To: contract, Amount: (*hexutil.Big)(big.NewInt(5)), TxIndex: 3, Depth: 0, Type: TransferCall},
+		{From: contract, To: paid, Amount: (*hexutil.Big)(big.NewInt(1)), TxIndex: 3, Depth: 1, Type: TransferCall},
+		{From: contract, To: heir, Amount: (*hexutil.Big)(big.NewInt(14)), TxIndex: 3, Depth: 1, Type: TransferSelfDestruct},
+		{From: origin, To: constants.BlackholeAddr, Amount: (*hexutil.Big)(big.NewInt(2 * int64(gasLimit-rest))), TxIndex: 3, Type: TransferBurn},
+	}
+	require.Equal(t, want, got)
+}
+
+func TestTransferTracerNativeAssetCall(t *testing.T) {
+	var (
+		caller    = common.HexToAddress("0x0a")
+		recipient = common.HexToAddress("0xbb")
+		assetID   = common.Hash{1}
+		input     = nativeasset.PackNativeAssetCallInput(recipient, assetID, big.NewInt(7), nil)
+	)
+	tracer, err := tracers.DefaultDirectory.New("transferTracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+	tracer.CaptureStart(nil, caller, caller, false, nil, 0, nil)
+	tracer.CaptureEnter(vm.CALL, caller, nativeasset.NativeAssetCallAddr, input, 0, nil)
+	tracer.CaptureEnter(vm.CALL, caller, recipient, nil, 0, big.NewInt(0))
+	tracer.CaptureExit(nil, 0, nil)
+	tracer.CaptureExit(nil, 0, nil)
+	// a reverted asset transfer is dropped
+	tracer.CaptureEnter(vm.CALL, caller, nativeasset.NativeAssetCallAddr, input, 0, nil)
+	tracer.CaptureExit(nil, 0, vm.ErrExecutionReverted)
+	tracer.CaptureEnd(nil, 0, nil)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(res), TransferNativeAsset))
+	var got []Transfer
+	require.NoError(t, json.Unmarshal(res, &got))
+	require.Equal(t, []Transfer{
+		{From: caller, To: recipient, AssetID: &assetID, Amount: (*hexutil.Big)(big.NewInt(7)), Depth: 1, Type: TransferNativeAsset},
+	}, got)
+}
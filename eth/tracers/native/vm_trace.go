@@ -0,0 +1,261 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/vm"
+
+	"github.com/ava-labs/coreth/eth/tracers"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("vmTracer", newVMTracer, false)
+}
+
+// vmTrace is the OpenEthereum style trace of the code executed by a call
+// frame
+type vmTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*vmTraceOp  `json:"ops"`
+}
+
+// vmTraceOp is an executed instruction, with the trace of the frame it
+// entered if any. Ex is nil if the instruction failed.
+type vmTraceOp struct {
+	Cost uint64     `json:"cost"`
+	Ex   *vmTraceEx `json:"ex"`
+	PC   uint64     `json:"pc"`
+	Sub  *vmTrace   `json:"sub"`
+}
+
+// vmTraceEx is the effect of an executed instruction: the stack items it
+// pushed, the memory and storage it wrote and the gas left afterwards
+type vmTraceEx struct {
+	Mem   *vmTraceMem   `json:"mem"`
+	Push  []string      `json:"push"`
+	Store *vmTraceStore `json:"store"`
+	Used  uint64        `json:"used"`
+}
+
+type vmTraceMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+type vmTraceStore struct {
+	Key string `json:"key"`
+	Val string `json:"val"`
+}
+
+// vmTraceFrame is an open call frame. The effect of the pending instruction
+// is only known once the next instruction of the frame starts.
+type vmTraceFrame struct {
+	trace   *vmTrace
+	pending *vmTraceOp
+	op      vm.OpCode
+	gas     uint64
+	memOff  uint64
+	memSize uint64
+}
+
+// vmTracer reports the instructions executed by a transaction in the
+// OpenEthereum vmTrace format, as returned by trace_replayBlockTransactions.
+//
+// Example:
+//
+//	> debug.traceTransaction("0x..", {tracer: "vmTracer"})
+//	{"code": "0x6080..", "ops": [{"cost": 3, "ex": {"mem": null, "push": ["0x80"], "store": null, "used": 78997}, "pc": 0, "sub": null}, ..]}
+type vmTracer struct {
+	env       *vm.EVM
+	frames    []*vmTraceFrame
+	root      *vmTrace
+	interrupt atomic.Bool
+	reason    error
+}
+
+// newVMTracer returns a native go tracer which reports the executed
+// instructions of a tx, and implements vm.EVMLogger.
+func newVMTracer(_ *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
+	return &vmTracer{}, nil
+}
+
+// CaptureTxStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *vmTracer) CaptureTxStart(gasLimit uint64) {}
+
+// CaptureTxEnd implements the EVMLogger interface to finalize the tracing operation.
+func (t *vmTracer) CaptureTxEnd(restGas uint64) {}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *vmTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+	t.root = t.enter(create, to, input)
+	t.frames = []*vmTraceFrame{{trace: t.root}}
+}
+
+// CaptureEnd is called after the top-level call finishes.
+func (t *vmTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.exit()
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *vmTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	frame := &vmTraceFrame{}
+	// A selfdestruct executes no code
+	if typ != vm.SELFDESTRUCT && !t.interrupt.Load() {
+		frame.trace = t.enter(typ == vm.CREATE || typ == vm.CREATE2, to, input)
+		if parent := t.frames[len(t.frames)-1]; parent.pending != nil {
+			parent.pending.Sub = frame.trace
+		}
+	}
+	t.frames = append(t.frames, frame)
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *vmTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.exit()
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *vmTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if t.interrupt.Load() || len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.trace == nil {
+		return
+	}
+	if frame.pending != nil {
+		frame.finish(scope, gas)
+	}
+	var (
+		stack = scope.Stack.Data()
+		ex    = &vmTraceEx{Push: []string{}}
+		arg   = func(i int) uint64 {
+			if i >= len(stack) {
+				return 0
+			}
+			return stack[len(stack)-1-i].Uint64()
+		}
+	)
+	frame.memOff, frame.memSize = 0, 0
+	switch op {
+	case vm.MSTORE:
+		frame.memOff, frame.memSize = arg(0), 32
+	case vm.MSTORE8:
+		frame.memOff, frame.memSize = arg(0), 1
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY, vm.MCOPY:
+		frame.memOff, frame.memSize = arg(0), arg(2)
+	case vm.EXTCODECOPY:
+		frame.memOff, frame.memSize = arg(1), arg(3)
+	case vm.CALL, vm.CALLCODE:
+		frame.memOff, frame.memSize = arg(5), arg(6)
+	case vm.DELEGATECALL, vm.STATICCALL:
+		frame.memOff, frame.memSize = arg(4), arg(5)
+	case vm.SSTORE:
+		if len(stack) >= 2 {
+			ex.Store = &vmTraceStore{Key: stack[len(stack)-1].Hex(), Val: stack[len(stack)-2].Hex()}
+		}
+	}
+	frame.pending = &vmTraceOp{Cost: cost, Ex: ex, PC: pc}
+	frame.op, frame.gas = op, gas
+	frame.trace.Ops = append(frame.trace.Ops, frame.pending)
+}
+
+// CaptureFault implements the EVMLogger interface to trace an execution fault.
+func (t *vmTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	if frame := t.frames[len(t.frames)-1]; frame.pending != nil {
+		frame.pending.Ex = nil
+		frame.pending = nil
+	}
+}
+
+// GetResult returns the json-encoded trace of the top-level call frame, and
+// any error arising from the encoding or forceful termination (via `Stop`).
+func (t *vmTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.root)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *vmTracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// enter returns the trace of a new call frame running the code of [to], or
+// [input] if the frame creates a contract
+func (t *vmTracer) enter(create bool, to common.Address, input []byte) *vmTrace {
+	code := input
+	if !create {
+		code = t.env.StateDB.GetCode(to)
+	}
+	return &vmTrace{Code: common.CopyBytes(code), Ops: []*vmTraceOp{}}
+}
+
+// exit closes the innermost call frame. Its last instruction is not followed
+// by another one, so only the gas it used is known.
+func (t *vmTracer) exit() {
+	if len(t.frames) == 0 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	if frame.pending != nil && frame.pending.Ex != nil && frame.gas >= frame.pending.Cost {
+		frame.pending.Ex.Used = frame.gas - frame.pending.Cost
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+}
+
+// finish records the effect of the pending instruction of [f] from the
+// [scope] and [gas] left when the next instruction starts
+func (f *vmTraceFrame) finish(scope *vm.ScopeContext, gas uint64) {
+	ex := f.pending.Ex
+	f.pending = nil
+	if ex == nil {
+		return
+	}
+	ex.Used = gas
+	stack := scope.Stack.Data()
+	for i := vmTracePushes(f.op); i > 0; i-- {
+		if i <= len(stack) {
+			ex.Push = append(ex.Push, stack[len(stack)-i].Hex())
+		}
+	}
+	if f.memSize > 0 {
+		data := scope.Memory.GetCopy(int64(f.memOff), int64(f.memSize))
+		ex.Mem = &vmTraceMem{Data: data, Off: f.memOff}
+	}
+}
+
+// vmTracePushes returns the number of stack items reported as pushed by
+// [op]: the items a DUP or SWAP rearranges, or the result of [op] if any
+func vmTracePushes(op vm.OpCode) int {
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.TSTORE, vm.JUMP, vm.JUMPI,
+		vm.JUMPDEST, vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+		vm.MCOPY, vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.INVALID:
+		return 0
+	}
+	return 1
+}
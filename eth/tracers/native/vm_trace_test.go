@@ -0,0 +1,71 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/vm/runtime"
+	"github.com/ava-labs/coreth/eth/tracers"
+)
+
+func TestVMTracer(t *testing.T) {
+	var (
+		origin   = common.HexToAddress("0x0a")
+		contract = common.HexToAddress("0xaa")
+		callee   = common.HexToAddress("0xbb")
+	)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+	// store 0x2a at slot 0, write it to memory and call callee, which stops
+	statedb.SetCode(contract, common.FromHex("602a600055602a6000526000600060006000600073"+common.Bytes2Hex(callee[:])+"5af100"))
+	statedb.SetCode(callee, common.FromHex("00"))
+
+	tracer, err := tracers.DefaultDirectory.New("vmTracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+	_, _, err = runtime.Call(contract, nil, &runtime.Config{
+		Origin:    origin,
+		GasLimit:  100_000,
+		GasPrice:  big.NewInt(0),
+		State:     statedb,
+		EVMConfig: vm.Config{Tracer: tracer},
+	})
+	require.NoError(t, err)
+
+	res, err := tracer.GetResult()
+	require.NoError(t, err)
+	var trace vmTrace
+	require.NoError(t, json.Unmarshal(res, &trace))
+	require.Len(t, trace.Ops, 15)
+
+	push := trace.Ops[0]
+	require.Equal(t, uint64(0), push.PC)
+	require.Equal(t, uint64(3), push.Cost)
+	require.Equal(t, []string{"0x2a"}, push.Ex.Push)
+
+	sstore := trace.Ops[2]
+	require.Equal(t, &vmTraceStore{Key: "0x0", Val: "0x2a"}, sstore.Ex.Store)
+	require.Empty(t, sstore.Ex.Push)
+	require.Equal(t, push.Ex.Used-3-sstore.Cost, sstore.Ex.Used)
+
+	mstore := trace.Ops[5]
+	require.Equal(t, uint64(0), mstore.Ex.Mem.Off)
+	require.Equal(t, common.LeftPadBytes([]byte{0x2a}, 32), []byte(mstore.Ex.Mem.Data))
+
+	call := trace.Ops[13]
+	require.Equal(t, []string{"0x1"}, call.Ex.Push)
+	require.NotNil(t, call.Sub)
+	require.Equal(t, []byte{0x00}, []byte(call.Sub.Code))
+	require.Len(t, call.Sub.Ops, 1)
+	require.Nil(t, trace.Ops[14].Sub)
+}
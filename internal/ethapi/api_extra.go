@@ -13,6 +13,7 @@ import (
 	"github.com/ava-labs/coreth/rpc"
 	"github.com/ava-labs/libevm/common"
 	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
 	"github.com/ava-labs/libevm/rlp"
 )
 
@@ -136,3 +137,15 @@ func (s *BlockChainAPI) stateQueryBlockNumberAllowed(blockNumOrHash rpc.BlockNum
 	return fmt.Errorf("block number %d is before the oldest allowed block number %d (window of %d blocks)",
 		number, oldestAllowed, queryWindow)
 }
+
+// RPCMarshalReceipt marshals [receipt] of the tx at [txIndex] of a block as
+// returned by eth_getTransactionReceipt.
+func RPCMarshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, signer types.Signer, tx *types.Transaction, txIndex int) map[string]interface{} {
+	return marshalReceipt(receipt, blockHash, blockNumber, signer, tx, txIndex)
+}
+
+// NewRPCTransactionFromBlockIndex returns the tx at [index] of [b] as
+// returned by eth_getTransactionByBlockHashAndIndex, or nil if there is none.
+func NewRPCTransactionFromBlockIndex(b *types.Block, index uint64, config *params.ChainConfig) *RPCTransaction {
+	return newRPCTransactionFromBlockIndex(b, index, config)
+}
@@ -0,0 +1,182 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+//
+// This file is a derived work, based on the go-ethereum library whose original
+// notices appear below.
+//
+// It is distributed under a license compatible with the licensing terms of the
+// original code from which it is derived.
+//
+// Much love to the original authors for their work.
+// **********
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"math/big"
+
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+)
+
+var (
+	// keccak256("Transfer(address,address,uint256)")
+	transferTopic = common.HexToHash("ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	// ERC-7528
+	transferAddress = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+)
+
+// transferLog is an ether transfer reported as a log, with the number of logs
+// the tx had emitted when the transfer happened.
+type transferLog struct {
+	pos int
+	log *types.Log
+}
+
+// tracer is a simple tracer that records all logs and ether transfers. Transfers
+// are recorded as if they were logs. Transfer events include:
+// - tx value
+// - call value
+// - self destructs
+//
+// The log format for a transfer is:
+// - address: 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE
+// - data: Value
+// - topics:
+//   - Transfer(address,address,uint256)
+//   - Sender address
+//   - Recipient address
+//
+// The EVMLogger interface does not report logs, so they are read back from the
+// state and transfers are placed among them by their position.
+type tracer struct {
+	state          *state.StateDB
+	traceTransfers bool
+	blockNumber    uint64
+	txHash         common.Hash
+	txIdx          uint
+
+	// frames holds the transfers of the open call frames
+	frames    [][]transferLog
+	transfers []transferLog
+}
+
+func newTracer(state *state.StateDB, traceTransfers bool, blockNumber uint64) *tracer {
+	return &tracer{
+		state:          state,
+		traceTransfers: traceTransfers,
+		blockNumber:    blockNumber,
+	}
+}
+
+func (t *tracer) reset(txHash common.Hash, txIdx uint) {
+	t.txHash = txHash
+	t.txIdx = txIdx
+	t.frames = nil
+	t.transfers = nil
+}
+
+func (t *tracer) CaptureTxStart(gasLimit uint64) {}
+
+func (t *tracer) CaptureTxEnd(restGas uint64) {}
+
+func (t *tracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.frames = [][]transferLog{nil}
+	t.captureTransfer(from, to, value)
+}
+
+func (t *tracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) == 0 {
+		return
+	}
+	if err == nil {
+		t.transfers = t.frames[0]
+	}
+	t.frames = nil
+}
+
+func (t *tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	t.frames = append(t.frames, nil)
+	if typ != vm.DELEGATECALL {
+		t.captureTransfer(from, to, value)
+	}
+}
+
+func (t *tracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	if len(t.frames) < 2 {
+		return
+	}
+	frame := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	// Transfers of a reverted frame did not happen
+	if err == nil {
+		t.frames[len(t.frames)-1] = append(t.frames[len(t.frames)-1], frame...)
+	}
+}
+
+func (t *tracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *tracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *tracer) captureTransfer(from, to common.Address, value *big.Int) {
+	if !t.traceTransfers || value == nil || value.Sign() <= 0 || len(t.frames) == 0 {
+		return
+	}
+	topics := []common.Hash{
+		transferTopic,
+		common.BytesToHash(from.Bytes()),
+		common.BytesToHash(to.Bytes()),
+	}
+	log := &types.Log{
+		Address: transferAddress,
+		Topics:  topics,
+		Data:    common.BigToHash(value).Bytes(),
+	}
+	pos := len(t.state.GetLogs(t.txHash, t.blockNumber, common.Hash{}))
+	t.frames[len(t.frames)-1] = append(t.frames[len(t.frames)-1], transferLog{pos: pos, log: log})
+}
+
+// Logs returns the logs emitted by the tx, with its transfers if they are
+// traced, in the order they happened.
+func (t *tracer) Logs() []*types.Log {
+	var (
+		stateLogs = t.state.GetLogs(t.txHash, t.blockNumber, common.Hash{})
+		logs      = make([]*types.Log, 0, len(stateLogs)+len(t.transfers))
+		next      = 0
+	)
+	for i, log := range stateLogs {
+		for next < len(t.transfers) && t.transfers[next].pos <= i {
+			logs = append(logs, t.transfers[next].log)
+			next++
+		}
+		logs = append(logs, log)
+	}
+	for ; next < len(t.transfers); next++ {
+		logs = append(logs, t.transfers[next].log)
+	}
+	for _, log := range logs {
+		log.BlockNumber = t.blockNumber
+		log.TxHash = t.txHash
+		log.TxIndex = t.txIdx
+	}
+	return logs
+}
@@ -0,0 +1,490 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+//
+// This file is a derived work, based on the go-ethereum library whose original
+// notices appear below.
+//
+// It is distributed under a license compatible with the licensing terms of the
+// original code from which it is derived.
+//
+// Much love to the original authors for their work.
+// **********
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/plugin/evm/customtypes"
+	customheader "github.com/ava-labs/coreth/plugin/evm/header"
+	"github.com/ava-labs/coreth/rpc"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/common/math"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/core/vm"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/ava-labs/libevm/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single request.
+	maxSimulateBlocks = 256
+
+	// timestampIncrement is the default increment between block timestamps.
+	timestampIncrement = 2
+)
+
+// simBlock is a batch of calls to be simulated sequentially.
+type simBlock struct {
+	BlockOverrides *BlockOverrides
+	StateOverrides *StateOverride
+	Calls          []TransactionArgs
+}
+
+// simCallResult is the result of a simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
+func (r *simCallResult) MarshalJSON() ([]byte, error) {
+	type callResultAlias simCallResult
+	// Marshal logs to be an empty array instead of nil when empty
+	if r.Logs == nil {
+		r.Logs = []*types.Log{}
+	}
+	return json.Marshal((*callResultAlias)(r))
+}
+
+// simOpts are the inputs to eth_simulateV1.
+type simOpts struct {
+	BlockStateCalls        []simBlock
+	TraceTransfers         bool
+	Validation             bool
+	ReturnFullTransactions bool
+}
+
+// simulator is a stateful object that simulates a series of blocks.
+// it is not safe for concurrent use.
+type simulator struct {
+	b              Backend
+	state          *state.StateDB
+	base           *types.Header
+	chainConfig    *params.ChainConfig
+	gp             *core.GasPool
+	traceTransfers bool
+	validate       bool
+	fullTx         bool
+}
+
+// execute runs the simulation of a series of blocks.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var (
+		cancel  context.CancelFunc
+		timeout = sim.b.RPCEVMTimeout()
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the call has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	var err error
+	blocks, err = sim.sanitizeChain(blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results = make([]map[string]interface{}, len(blocks))
+		chain   = &simChainContext{ChainContext: NewChainContext(ctx, sim.b), headers: make(map[common.Hash]*types.Header)}
+		parent  = sim.base
+	)
+	for bi, block := range blocks {
+		result, callResults, senders, err := sim.processBlock(ctx, &block, parent, chain, timeout)
+		if err != nil {
+			return nil, err
+		}
+		enc := RPCMarshalBlock(result, true, sim.fullTx, sim.chainConfig)
+		enc["calls"] = callResults
+		// Unsigned txs carry no sender, so the senders of the calls are
+		// filled in
+		if txs, ok := enc["transactions"].([]interface{}); ok && sim.fullTx {
+			for i, tx := range txs {
+				if rpcTx, ok := tx.(*RPCTransaction); ok {
+					rpcTx.From = senders[i]
+				}
+			}
+		}
+		results[bi] = enc
+
+		parent = result.Header()
+		chain.headers[parent.Hash()] = parent
+	}
+	return results, nil
+}
+
+func (sim *simulator) processBlock(ctx context.Context, block *simBlock, parent *types.Header, chain *simChainContext, timeout time.Duration) (*types.Block, []simCallResult, []common.Address, error) {
+	header, capacity, err := sim.makeHeader(block.BlockOverrides, parent)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Activate the precompiles and state upgrades of the block, as the state
+	// processor does, before the overrides apply on top of them.
+	if err := core.ApplyUpgrades(sim.chainConfig, &parent.Time, core.NewBlockContext(header.Number, header.Time), sim.state); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, nil, nil, err
+	}
+	var (
+		gasUsed     uint64
+		txes        = make([]*types.Transaction, len(block.Calls))
+		callResults = make([]simCallResult, len(block.Calls))
+		receipts    = make([]*types.Receipt, len(block.Calls))
+		senders     = make([]common.Address, len(block.Calls))
+		allLogs     []*types.Log
+		tracer      = newTracer(sim.state, sim.traceTransfers, header.Number.Uint64())
+		vmConfig    = &vm.Config{NoBaseFee: !sim.validate, Tracer: tracer}
+		blockCtx    = core.NewEVMBlockContext(header, chain, nil)
+	)
+	if block.BlockOverrides != nil && block.BlockOverrides.BlobBaseFee != nil {
+		blockCtx.BlobBaseFee = block.BlockOverrides.BlobBaseFee.ToInt()
+	}
+	for i, call := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := sim.sanitizeCall(&call, sim.state, header, capacity, &gasUsed); err != nil {
+			return nil, nil, nil, err
+		}
+		tx := call.toTransaction()
+		txes[i] = tx
+		senders[i] = call.from()
+		tracer.reset(tx.Hash(), uint(i))
+		sim.state.SetTxContext(tx.Hash(), i)
+		msg, err := call.ToMessage(sim.gp.Gas(), header.BaseFee)
+		if err != nil {
+			return nil, nil, nil, txValidationError(err)
+		}
+		if sim.validate {
+			msg.Nonce = tx.Nonce()
+			msg.SkipAccountChecks = false
+		}
+		evm := sim.b.GetEVM(ctx, msg, sim.state, header, vmConfig, &blockCtx)
+		result, err := applyMessageWithEVM(ctx, evm, msg, timeout, sim.gp)
+		if err != nil {
+			return nil, nil, nil, txValidationError(err)
+		}
+		// Update the state with pending changes.
+		sim.state.Finalise(true)
+		gasUsed += result.UsedGas
+
+		logs := tracer.Logs()
+		callRes := simCallResult{ReturnValue: result.Return(), Logs: logs, GasUsed: hexutil.Uint64(result.UsedGas)}
+		if result.Failed() {
+			callRes.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				// If the result contains a revert reason, try to unpack it.
+				revertErr := newRevertError(result.Revert())
+				callRes.Error = &callError{Message: revertErr.Error(), Code: errCodeReverted, Data: revertErr.ErrorData().(string)}
+			} else {
+				callRes.Error = &callError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		} else {
+			callRes.Status = hexutil.Uint64(types.ReceiptStatusSuccessful)
+		}
+		callResults[i] = callRes
+		allLogs = append(allLogs, logs...)
+		receipts[i] = makeReceipt(sim.state, tx, msg, result, header, gasUsed, uint(i))
+	}
+	header.GasUsed = gasUsed
+	headerExtra := &customtypes.HeaderExtra{
+		BlockGasCost: customheader.BlockGasCost(params.GetExtra(sim.chainConfig), parent, header.Time),
+	}
+	if headerExtra.BlockGasCost != nil {
+		headerExtra.ExtDataGasUsed = new(big.Int)
+	}
+	customtypes.SetHeaderExtra(header, headerExtra)
+	extra, err := customheader.ExtraPrefix(params.GetExtra(sim.chainConfig), parent, header, nil)
+	if err != nil {
+		if sim.validate {
+			return nil, nil, nil, err
+		}
+		// Without validation the gas used may exceed the capacity of the
+		// block, in which case the fee state of the parent is carried over.
+		extra = common.CopyBytes(parent.Extra)
+	}
+	header.Extra = extra
+	header.Root = sim.state.IntermediateRoot(true)
+	b := customtypes.NewBlockWithExtData(header, txes, nil, receipts, trie.NewStackTrie(nil), nil, true)
+	repairLogs(allLogs, b.Hash())
+	return b, callResults, senders, nil
+}
+
+// makeReceipt returns the receipt of the simulated [tx], as built by the state
+// processor.
+func makeReceipt(statedb *state.StateDB, tx *types.Transaction, msg *core.Message, result *core.ExecutionResult, header *types.Header, cumulativeGasUsed uint64, txIndex uint) *types.Receipt {
+	receipt := &types.Receipt{Type: tx.Type(), CumulativeGasUsed: cumulativeGasUsed}
+	if result.Failed() {
+		receipt.Status = types.ReceiptStatusFailed
+	} else {
+		receipt.Status = types.ReceiptStatusSuccessful
+	}
+	receipt.TxHash = tx.Hash()
+	receipt.GasUsed = result.UsedGas
+	if msg.To == nil {
+		receipt.ContractAddress = crypto.CreateAddress(msg.From, tx.Nonce())
+	}
+	receipt.Logs = statedb.GetLogs(tx.Hash(), header.Number.Uint64(), common.Hash{})
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	receipt.BlockNumber = header.Number
+	receipt.TransactionIndex = txIndex
+	return receipt
+}
+
+// repairLogs updates the block hash and the block-wide index of the logs.
+func repairLogs(logs []*types.Log, hash common.Hash) {
+	for i, log := range logs {
+		log.BlockHash = hash
+		log.Index = uint(i)
+	}
+}
+
+func (sim *simulator) sanitizeCall(call *TransactionArgs, state *state.StateDB, header *types.Header, capacity uint64, gasUsed *uint64) error {
+	if call.Nonce == nil {
+		nonce := state.GetNonce(call.from())
+		call.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	// Let the call run wild unless explicitly specified.
+	if call.Gas == nil {
+		remaining := capacity - *gasUsed
+		call.Gas = (*hexutil.Uint64)(&remaining)
+	}
+	if *gasUsed+uint64(*call.Gas) > capacity {
+		return &blockGasLimitReachedError{fmt.Sprintf("block gas limit reached: %d >= %d", *gasUsed, capacity)}
+	}
+	if err := call.CallDefaults(sim.gp.Gas(), header.BaseFee, sim.chainConfig.ChainID); err != nil {
+		return &invalidParamsError{err.Error()}
+	}
+	return nil
+}
+
+// sanitizeChain checks the chain integrity. Specifically it checks that
+// block numbers and timestamp are strictly increasing, setting default values
+// when necessary. Gaps in block numbers are filled with empty blocks.
+// Note: It modifies the block's override object.
+func (sim *simulator) sanitizeChain(blocks []simBlock) ([]simBlock, error) {
+	var (
+		res           = make([]simBlock, 0, len(blocks))
+		base          = sim.base
+		prevNumber    = base.Number
+		prevTimestamp = base.Time
+	)
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = new(BlockOverrides)
+		}
+		if block.BlockOverrides.Number == nil {
+			n := new(big.Int).Add(prevNumber, big.NewInt(1))
+			block.BlockOverrides.Number = (*hexutil.Big)(n)
+		}
+		diff := new(big.Int).Sub(block.BlockOverrides.Number.ToInt(), prevNumber)
+		if diff.Cmp(common.Big0) <= 0 {
+			return nil, &invalidBlockNumberError{fmt.Sprintf("block numbers must be in order: %d <= %d", block.BlockOverrides.Number.ToInt().Uint64(), prevNumber)}
+		}
+		if total := new(big.Int).Sub(block.BlockOverrides.Number.ToInt(), base.Number); total.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, &clientLimitExceededError{message: "too many blocks"}
+		}
+		if diff.Cmp(big.NewInt(1)) > 0 {
+			// Fill the gap with empty blocks.
+			gap := new(big.Int).Sub(diff, big.NewInt(1))
+			// Assign block number to the empty blocks.
+			for i := uint64(0); i < gap.Uint64(); i++ {
+				n := new(big.Int).Add(prevNumber, big.NewInt(int64(i+1)))
+				t := prevTimestamp + timestampIncrement
+				b := simBlock{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(n), Time: (*hexutil.Uint64)(&t)}}
+				prevTimestamp = t
+				res = append(res, b)
+			}
+		}
+		// Only append block after filling a potential gap.
+		prevNumber = block.BlockOverrides.Number.ToInt()
+		var t uint64
+		if block.BlockOverrides.Time == nil {
+			t = prevTimestamp + timestampIncrement
+			block.BlockOverrides.Time = (*hexutil.Uint64)(&t)
+		} else {
+			// Avalanche blocks may share the timestamp of their parent.
+			t = uint64(*block.BlockOverrides.Time)
+			if t < prevTimestamp {
+				return nil, &invalidBlockTimestampError{fmt.Sprintf("block timestamps must be in order: %d < %d", t, prevTimestamp)}
+			}
+		}
+		prevTimestamp = t
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeader returns the header of the block simulated on top of [parent]
+// with the [overrides], and the gas that can be consumed in the block. The
+// gas limit, base fee and block gas cost follow the dynamic fee rules of
+// the simulated block. The base fee is zero without validation, unless
+// overridden.
+func (sim *simulator) makeHeader(overrides *BlockOverrides, parent *types.Header) (*types.Header, uint64, error) {
+	var (
+		config    = params.GetExtra(sim.chainConfig)
+		timestamp = uint64(*overrides.Time)
+	)
+	gasLimit, err := customheader.GasLimit(config, parent, timestamp)
+	if err != nil {
+		return nil, 0, err
+	}
+	capacity, err := customheader.GasCapacity(config, parent, timestamp)
+	if err != nil {
+		return nil, 0, err
+	}
+	if overrides.GasLimit != nil {
+		gasLimit = uint64(*overrides.GasLimit)
+		capacity = gasLimit
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		Difficulty: parent.Difficulty,
+		Number:     overrides.Number.ToInt(),
+		GasLimit:   gasLimit,
+		Time:       timestamp,
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = overrides.Difficulty.ToInt()
+	}
+	if sim.chainConfig.IsLondon(header.Number) {
+		switch {
+		case overrides.BaseFee != nil:
+			header.BaseFee = overrides.BaseFee.ToInt()
+		case sim.validate:
+			header.BaseFee, err = customheader.BaseFee(config, parent, timestamp)
+			if err != nil {
+				return nil, 0, err
+			}
+		default:
+			header.BaseFee = big.NewInt(0)
+		}
+	}
+	return header, capacity, nil
+}
+
+// applyMessageWithEVM runs [msg] in [evm], cancelling the execution when [ctx]
+// is done.
+func applyMessageWithEVM(ctx context.Context, evm *vm.EVM, msg *core.Message, timeout time.Duration, gp *core.GasPool) (*core.ExecutionResult, error) {
+	// Wait for the context to be done and cancel the evm. Even if the
+	// EVM has finished, cancelling may be done (repeatedly)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	// Execute the message.
+	result, err := core.ApplyMessage(evm, msg, gp)
+
+	// If the timer caused an abort, return an appropriate error message
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+	}
+	if err != nil {
+		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.GasLimit)
+	}
+	return result, nil
+}
+
+// simChainContext serves the headers of the simulated blocks, so that
+// BLOCKHASH works across them.
+type simChainContext struct {
+	*ChainContext
+	headers map[common.Hash]*types.Header
+}
+
+func (c *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := c.headers[hash]; ok {
+		return header
+	}
+	return c.ChainContext.GetHeader(hash, number)
+}
+
+// SimulateV1 executes series of transactions on top of a base state.
+// The transactions are packed into blocks. For each block, block header
+// fields can be overridden. The state can also be overridden prior to
+// execution of each block.
+//
+// Note, this function doesn't make any changes in the state/blockchain and is
+// useful to execute and retrieve values.
+func (s *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	} else if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &clientLimitExceededError{message: "too many blocks"}
+	}
+	if blockNrOrHash == nil {
+		n := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &n
+	}
+	state, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	gasCap := s.b.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = math.MaxUint64
+	}
+	sim := &simulator{
+		b:           s.b,
+		state:       state,
+		base:        base,
+		chainConfig: s.b.ChainConfig(),
+		// Each tx and all the series of txes shouldn't consume more gas than cap
+		gp:             new(core.GasPool).AddGas(gasCap),
+		traceTransfers: opts.TraceTransfers,
+		validate:       opts.Validation,
+		fullTx:         opts.ReturnFullTransactions,
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
+}
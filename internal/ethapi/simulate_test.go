@@ -0,0 +1,183 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/coreth/consensus/dummy"
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/params"
+	"github.com/ava-labs/coreth/params/extras"
+	"github.com/ava-labs/coreth/precompile/contracts/warp"
+	"github.com/ava-labs/coreth/rpc"
+	"github.com/ava-labs/coreth/utils"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		// returns the hash of the parent block
+		blockHashCode = hexutil.Bytes(common.FromHex("600143034060005260206000f3"))
+		revertCode    = hexutil.Bytes(common.FromHex("60006000fd"))
+		blockHashAddr = common.HexToAddress("0xaa")
+		revertAddr    = common.HexToAddress("0xbb")
+		value         = (*hexutil.Big)(big.NewInt(1000))
+		ctx           = context.Background()
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 2, genesis, dummy.NewCoinbaseFaker(), func(i int, b *core.BlockGen) {}))
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	results, err := api.SimulateV1(ctx, simOpts{
+		BlockStateCalls: []simBlock{
+			{
+				StateOverrides: &StateOverride{
+					blockHashAddr: {Code: &blockHashCode},
+					revertAddr:    {Code: &revertCode},
+				},
+				Calls: []TransactionArgs{
+					{From: &accounts[0].addr, To: &accounts[1].addr, Value: value},
+					{From: &accounts[0].addr, To: &revertAddr},
+				},
+			},
+			{
+				BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(5))},
+				Calls:          []TransactionArgs{{From: &accounts[0].addr, To: &blockHashAddr}},
+			},
+		},
+		TraceTransfers: true,
+	}, &latest)
+	require.NoError(err)
+	// Block 4 fills the gap between blocks 3 and 5
+	require.Len(results, 3)
+	for i, res := range results {
+		require.Equal(big.NewInt(int64(3+i)), res["number"].(*hexutil.Big).ToInt())
+	}
+
+	calls := results[0]["calls"].([]simCallResult)
+	require.Len(calls, 2)
+	require.Equal(hexutil.Uint64(types.ReceiptStatusSuccessful), calls[0].Status)
+	require.Len(calls[0].Logs, 1)
+	transfer := calls[0].Logs[0]
+	require.Equal(transferAddress, transfer.Address)
+	require.Equal([]common.Hash{transferTopic, common.BytesToHash(accounts[0].addr.Bytes()), common.BytesToHash(accounts[1].addr.Bytes())}, transfer.Topics)
+	require.Equal(common.BigToHash(value.ToInt()).Bytes(), transfer.Data)
+	require.Equal(results[0]["hash"], transfer.BlockHash)
+
+	require.Equal(hexutil.Uint64(types.ReceiptStatusFailed), calls[1].Status)
+	require.Equal(errCodeReverted, calls[1].Error.Code)
+
+	// The empty block 4 is the parent of block 5
+	require.Empty(results[1]["calls"])
+	calls = results[2]["calls"].([]simCallResult)
+	require.Equal(results[1]["hash"], common.BytesToHash(calls[0].ReturnValue))
+	require.Equal(results[1]["hash"], results[2]["parentHash"])
+
+	// Validation charges fees at the base fee and checks nonces, which are
+	// chained across the calls
+	var (
+		feeCap = (*hexutil.Big)(big.NewInt(params.GWei * 1000))
+		gas    = hexutil.Uint64(params.TxGas)
+	)
+	results, err = api.SimulateV1(ctx, simOpts{
+		BlockStateCalls: []simBlock{
+			{Calls: []TransactionArgs{
+				{From: &accounts[0].addr, To: &accounts[1].addr, Value: value, Gas: &gas, MaxFeePerGas: feeCap},
+				{From: &accounts[0].addr, To: &accounts[1].addr, Value: value, Gas: &gas, MaxFeePerGas: feeCap},
+			}},
+			{Calls: []TransactionArgs{{From: &accounts[0].addr, To: &accounts[1].addr, Value: value, Gas: &gas, MaxFeePerGas: feeCap}}},
+		},
+		Validation: true,
+	}, &latest)
+	require.NoError(err)
+	require.Len(results, 2)
+	require.Positive(results[0]["baseFeePerGas"].(*hexutil.Big).ToInt().Sign())
+	require.Equal(hexutil.Uint64(2*params.TxGas), results[0]["gasUsed"])
+
+	nonce := hexutil.Uint64(5)
+	_, err = api.SimulateV1(ctx, simOpts{
+		BlockStateCalls: []simBlock{{Calls: []TransactionArgs{{From: &accounts[0].addr, To: &accounts[1].addr, Nonce: &nonce, Gas: &gas, MaxFeePerGas: feeCap}}}},
+		Validation:      true,
+	}, &latest)
+	var txErr *invalidTxError
+	require.True(errors.As(err, &txErr))
+	require.Equal(errCodeNonceTooHigh, txErr.Code)
+
+	_, err = api.SimulateV1(ctx, simOpts{
+		BlockStateCalls: []simBlock{{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(2))}}},
+	}, &latest)
+	var numberErr *invalidBlockNumberError
+	require.True(errors.As(err, &numberErr))
+
+	_, err = api.SimulateV1(ctx, simOpts{}, &latest)
+	var paramsErr *invalidParamsError
+	require.True(errors.As(err, &paramsErr))
+}
+
+func TestSimulateV1Upgrades(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// The warp precompile activates after the blocks of the chain
+	const activation = 1000
+	config := params.Copy(params.TestChainConfig)
+	params.GetExtra(&config).UpgradeConfig = extras.UpgradeConfig{
+		PrecompileUpgrades: []extras.PrecompileUpgrade{
+			{Config: warp.NewConfig(utils.NewUint64(activation), 0, false)},
+		},
+	}
+	var (
+		accounts = newAccounts(1)
+		genesis  = &core.Genesis{
+			Config: &config,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		// returns the code size of the warp precompile
+		codeSizeCode = hexutil.Bytes(common.FromHex("73" + warp.ContractAddress.Hex()[2:] + "3b60005260206000f3"))
+		codeSizeAddr = common.HexToAddress("0xaa")
+	)
+	api := NewBlockChainAPI(newTestBackend(t, 2, genesis, dummy.NewCoinbaseFaker(), func(i int, b *core.BlockGen) {}))
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	call := []TransactionArgs{{From: &accounts[0].addr, To: &codeSizeAddr}}
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{
+				BlockOverrides: &BlockOverrides{Time: (*hexutil.Uint64)(utils.NewUint64(activation - 1))},
+				StateOverrides: &StateOverride{codeSizeAddr: {Code: &codeSizeCode}},
+				Calls:          call,
+			},
+			{
+				BlockOverrides: &BlockOverrides{Time: (*hexutil.Uint64)(utils.NewUint64(activation))},
+				Calls:          call,
+			},
+		},
+	}, &latest)
+	require.NoError(err)
+	require.Len(results, 2)
+
+	// The precompile is configured by the block crossing the activation
+	before := results[0]["calls"].([]simCallResult)
+	require.Zero(new(big.Int).SetBytes(before[0].ReturnValue).Uint64())
+	after := results[1]["calls"].([]simCallResult)
+	require.Equal(uint64(1), new(big.Int).SetBytes(after[0].ReturnValue).Uint64())
+}
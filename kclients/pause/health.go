@@ -0,0 +1,94 @@
+package pause
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// minStaleHeight is the minimum age of the consumer height above which the
+	// service reports itself unhealthy, raised to ten poll intervals if
+	// those are longer.
+	minStaleHeight = time.Minute
+	// maxPause is how long block processing may wait for the consumer
+	// before the service reports itself unhealthy.
+	maxPause = 10 * time.Minute
+)
+
+// Health reports the state of the service. It returns an error if the
+// consumer height could not be refreshed for a while, eg. because redis is
+// down, or block processing has been waiting for a stalled consumer for
+// longer than maxPause.
+func Health() (map[string]interface{}, error) {
+	if !Started() {
+		return map[string]interface{}{"started": false}, nil
+	}
+	pc.lock.RLock()
+	var (
+		interval       = pc.interval
+		consumerHeight = pc.consumerHeight
+		nextHeight     = pc.nextHeight
+		lastUpdate     = pc.lastUpdate
+		lastErr        = pc.lastErr
+		pausedSince    = pc.pausedSince
+		held           = pc.held
+	)
+	pc.lock.RUnlock()
+
+	details := map[string]interface{}{
+		"started":        true,
+		"consumerHeight": consumerHeight,
+		"nextHeight":     nextHeight,
+		"lastUpdate":     lastUpdate.UTC().Format(time.RFC3339),
+	}
+	if lastErr != nil {
+		details["lastError"] = lastErr.Error()
+	}
+	var paused time.Duration
+	if !pausedSince.IsZero() {
+		paused = time.Since(pausedSince)
+		details["paused"] = paused.String()
+	}
+	if held {
+		details["held"] = true
+	}
+
+	if stale := time.Since(lastUpdate); stale > max(minStaleHeight, 10*interval) {
+		return details, fmt.Errorf("consumer height not refreshed for %s: %v", stale.Truncate(time.Second), lastErr)
+	}
+	// a pause held by an operator is intended
+	if paused > maxPause && !held {
+		return details, fmt.Errorf("block processing paused for %s waiting for the consumer at height %d", paused.Truncate(time.Second), consumerHeight)
+	}
+	return details, nil
+}
+
+// Status is a snapshot of the service
+type Status struct {
+	Started        bool
+	ConsumerHeight int64
+	NextHeight     int64 // height of the next block processed
+	Paused         bool  // block processing waits for the consumer or Release
+	Held           bool  // block processing is paused by Hold
+	LastError      string
+}
+
+// CurrentStatus returns the status of the service
+func CurrentStatus() Status {
+	if !Started() {
+		return Status{}
+	}
+	pc.lock.RLock()
+	defer pc.lock.RUnlock()
+	status := Status{
+		Started:        true,
+		ConsumerHeight: pc.consumerHeight,
+		NextHeight:     pc.nextHeight,
+		Paused:         !pc.pausedSince.IsZero(),
+		Held:           pc.held,
+	}
+	if pc.lastErr != nil {
+		status.LastError = pc.lastErr.Error()
+	}
+	return status
+}
@@ -0,0 +1,38 @@
+package pause
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	details, err := Health()
+	require.NoError(t, err)
+	require.Equal(t, false, details["started"])
+
+	source := NewPushSource()
+	StartWithSource(source, 10, time.Hour)
+	defer Stop()
+
+	require.NoError(t, Push(100))
+	require.Eventually(t, func() bool {
+		details, err := Health()
+		return err == nil && details["consumerHeight"] == int64(100)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the height is polled hourly, so it is stale after ten hours
+	pc.lock.Lock()
+	pc.lastUpdate = time.Now().Add(-11 * time.Hour)
+	pc.lock.Unlock()
+	_, err = Health()
+	require.ErrorContains(t, err, "not refreshed")
+
+	pc.lock.Lock()
+	pc.lastUpdate = time.Now()
+	pc.pausedSince = time.Now().Add(-2 * maxPause)
+	pc.lock.Unlock()
+	_, err = Health()
+	require.ErrorContains(t, err, "paused")
+}
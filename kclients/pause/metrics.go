@@ -0,0 +1,13 @@
+package pause
+
+import "github.com/ava-labs/libevm/metrics"
+
+var (
+	consumerHeightGauge = metrics.NewRegisteredGauge("pause/consumer/height", nil)
+	// consumerLagGauge is the number of blocks the node runs ahead of the consumer
+	consumerLagGauge    = metrics.NewRegisteredGauge("pause/consumer/lag", nil)
+	sourceErrorsCounter = metrics.NewRegisteredCounter("pause/source/errors", nil)
+	pausedGauge         = metrics.NewRegisteredGauge("pause/paused", nil)
+	pauseCounter        = metrics.NewRegisteredCounter("pause/count", nil)
+	pauseTimeCounter    = metrics.NewRegisteredCounter("pause/time", nil) // ms
+)
@@ -2,10 +2,11 @@ package pause
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/ava-labs/coreth/kclients/syncstatus"
-	"github.com/ava-labs/coreth/kclients/util/env"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -13,117 +14,345 @@ import (
 
 var pc pauseControl
 
+const defaultPollInterval = time.Second
+
+var ErrNotStarted = errors.New("pause control service is not started")
+
 type pauseControl struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	enabled     bool
-	started     bool
+	source   HeightSource
+	interval time.Duration
+
+	started     atomic.Bool
 	allowOffset int64
 	//l2Height    int64
-	nextHeight  int64
-	redisHeight int64
-	lock        sync.RWMutex
+	nextHeight     int64
+	consumerHeight int64
+	// updated is closed and replaced whenever consumerHeight is refreshed
+	updated chan struct{}
+	lock    sync.RWMutex
+
+	lastUpdate  time.Time // last time consumerHeight was refreshed
+	lastErr     error     // error of the last failed refresh
+	pausedSince time.Time // zero unless block processing is paused
+	held        bool      // block processing is paused by Hold until Release
 }
 
-func Start() {
-	pc.enabled = env.LoadEnvBool(env.EnvETLPauseEnabled)
-	if !pc.enabled {
-		log.Info("### DEBUG ### pause control service is not enabled")
-		return
+// Config configures the back-pressure of block processing on the consumer
+type Config struct {
+	Enabled bool
+	// AllowBehind is the number of blocks the node may run ahead of the
+	// consumer before block processing is paused.
+	AllowBehind int64
+	// PollInterval is the interval the height source is polled at
+	PollInterval time.Duration
+
+	// Source is one of the Source* constants, SourceSyncStatus if empty
+	Source string
+	// URL is the comma separated address list of a redis or sentinel
+	// source, the url of an http or rpc source or the path of a file source.
+	URL    string
+	Key    string // hash key of a redis or sentinel source
+	Field  string // hash field of a redis or sentinel source, or json field of an http source
+	Method string // method of an rpc source
+
+	// authentication of a redis or sentinel source
+	MasterName string
+	Password   string
+	DB         int
+}
+
+var ErrInvalidAllowBehind = errors.New("invalid etl allow behind")
+
+// Validate returns an error if [c] is enabled but invalid
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.AllowBehind <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidAllowBehind, c.AllowBehind)
+	}
+	if c.PollInterval < 0 {
+		return fmt.Errorf("invalid etl poll interval: %s", c.PollInterval)
+	}
+	return checkSource(c)
+}
+
+// checkSource returns an error if the source selected by c.Source is
+// unknown or misses a setting
+func checkSource(c Config) error {
+	switch c.Source {
+	case "", SourceSyncStatus, SourcePush:
+	case SourceRedis, SourceSentinel:
+		if c.URL == "" || c.Key == "" || c.Field == "" {
+			return fmt.Errorf("%s height source requires addresses, key and field", c.Source)
+		}
+		if c.Source == SourceSentinel && c.MasterName == "" {
+			return errors.New("sentinel height source requires a master name")
+		}
+	case SourceHTTP, SourceFile:
+		if c.URL == "" {
+			return fmt.Errorf("%s height source requires a url", c.Source)
+		}
+	case SourceRPC:
+		if c.URL == "" || c.Method == "" {
+			return errors.New("rpc height source requires a url and a method")
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownSource, c.Source)
+	}
+	return nil
+}
+
+// Start starts the service with [c] if it is enabled
+func Start(c Config) error {
+	if !c.Enabled {
+		log.Info("Pause control service is not enabled")
+		return nil
 	}
-	pc.allowOffset = env.LoadEnvInt64(env.EnvETLAllowBehind)
-	if pc.allowOffset == env.WrongInt {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	source, err := newSource(c)
+	if err != nil {
+		return err
+	}
+	StartWithSource(source, c.AllowBehind, c.PollInterval)
+	return nil
+}
+
+// StartWithSource starts the service throttling block processing once it is
+// [allowOffset] blocks ahead of the height reported by [source], which is
+// polled every [interval]. A zero interval selects the default of one second.
+func StartWithSource(source HeightSource, allowOffset int64, interval time.Duration) {
+	if Started() {
 		return
 	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
+	pc.lock.Lock()
 	pc.ctx = ctx
 	pc.cancel = cancelFunc
+	pc.source = source
+	pc.interval = interval
+	pc.allowOffset = allowOffset
+	pc.updated = make(chan struct{})
+	pc.lastUpdate = time.Now()
+	pc.lastErr = nil
+	pc.pausedSince = time.Time{}
+	pc.held = false
+	pc.lock.Unlock()
+
+	go pc.updateLoop(ctx, source, interval)
+	log.Info("Pause control service started", "source", fmt.Sprintf("%T", source), "allowBehind", allowOffset, "interval", interval)
+	pc.started.Store(true)
+}
 
-	go pc.updateLoop()
-	log.Info("### DEBUG ### pause control service started")
-	pc.started = true
+// newSource returns the source selected by c.Source
+func newSource(c Config) (HeightSource, error) {
+	switch c.Source {
+	case "", SourceSyncStatus:
+		return syncStatusSource{}, nil
+	case SourceRedis, SourceSentinel:
+		cfg := RedisSourceConfig{
+			Password: c.Password,
+			DB:       c.DB,
+			Key:      c.Key,
+			Field:    c.Field,
+		}
+		if c.URL != "" {
+			cfg.Addrs = strings.Split(c.URL, ",")
+		}
+		if c.Source == SourceSentinel {
+			cfg.MasterName = c.MasterName
+		}
+		return NewRedisSource(cfg)
+	case SourceHTTP:
+		return NewHTTPSource(c.URL, c.Field)
+	case SourceRPC:
+		return NewRPCSource(c.URL, c.Method)
+	case SourceFile:
+		return NewFileSource(c.URL)
+	case SourcePush:
+		return NewPushSource(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSource, c.Source)
+	}
 }
 
 func Stop() {
 	if !Started() {
 		return
 	}
-	log.Info("### DEBUG ### stopping pause control service")
+	log.Info("Stopping pause control service")
+	pc.lock.RLock()
 	pc.cancel()
+	pc.lock.RUnlock()
+	pc.started.Store(false)
+}
+
+// Push records a height reported by the consumer. It fails unless the
+// service runs with the push height source.
+func Push(height int64) error {
+	if !Started() {
+		return ErrNotStarted
+	}
+	pc.lock.RLock()
+	s, ok := pc.source.(*PushSource)
+	pc.lock.RUnlock()
+	if !ok {
+		return ErrNotPushSource
+	}
+	s.Set(height)
+	return nil
 }
 
-func (c *pauseControl) updateLoop() {
-	c.updateBlockHeight()
+// Hold pauses block processing until Release is called, whatever the height
+// of the consumer.
+func Hold() error {
+	if !Started() {
+		return ErrNotStarted
+	}
+	log.Info("Block processing held")
+	pc.setHeld(true)
+	return nil
+}
+
+// Release resumes block processing paused by Hold. Processing stays paused
+// while the consumer is behind.
+func Release() error {
+	if !Started() {
+		return ErrNotStarted
+	}
+	log.Info("Block processing released")
+	pc.setHeld(false)
+	return nil
+}
+
+// setHeld wakes up the paused block processing to re-check its condition
+func (c *pauseControl) setHeld(held bool) {
+	c.lock.Lock()
+	c.held = held
+	close(c.updated)
+	c.updated = make(chan struct{})
+	c.lock.Unlock()
+}
+
+func (c *pauseControl) updateLoop(ctx context.Context, source HeightSource, interval time.Duration) {
+	var notify <-chan struct{}
+	if n, ok := source.(notifier); ok {
+		notify = n.Notify()
+	}
+	c.updateBlockHeight(ctx, source)
 	for {
 		select {
-		case <-time.After(time.Second):
-			c.updateBlockHeight()
-		case <-c.ctx.Done():
-			log.Info("### DEBUG ### pauseControl updateLoop stopped")
+		case <-time.After(interval):
+		case <-notify:
+		case <-ctx.Done():
+			log.Debug("Pause control update loop stopped")
 			return
 		}
+		c.updateBlockHeight(ctx, source)
 	}
 }
 
-func (c *pauseControl) updateBlockHeight() {
-	redisHeight := syncstatus.RedisHeight()
+func (c *pauseControl) updateBlockHeight(ctx context.Context, source HeightSource) {
+	ctx, cancel := context.WithTimeout(ctx, sourceTimeout)
+	defer cancel()
+	height, err := source.Height(ctx)
+	if err != nil {
+		log.Warn("Failed to read consumer height", "err", err)
+		sourceErrorsCounter.Inc(1)
+		c.lock.Lock()
+		c.lastErr = err
+		c.lock.Unlock()
+		return
+	}
+	consumerHeightGauge.Update(height)
 
 	c.lock.Lock()
-	if redisHeight != -1 {
-		c.redisHeight = redisHeight
-	}
+	c.consumerHeight = height
+	c.lastUpdate = time.Now()
+	c.lastErr = nil
+	close(c.updated)
+	c.updated = make(chan struct{})
 	c.lock.Unlock()
 }
 
 func Started() bool {
-	return pc.started
+	return pc.started.Load()
 }
 
-func RedisBehind(nextHeight int64) bool {
-	if pc.started == false {
+// Behind reports whether the block at [nextHeight] is too far ahead of the
+// consumer and must wait for it.
+func Behind(nextHeight int64) bool {
+	if !Started() {
 		return false
 	}
-	return pc.redisBehind(nextHeight)
+	return pc.behind(nextHeight)
 }
 
 func PauseIfBehind(tag string) (shutdown bool) {
 	return pc.pauseIfBehind(tag)
 }
-func (c *pauseControl) redisBehind(nextHeight int64) bool {
-	if !Started() {
-		fmt.Println("### DEBUG ### [pauseControl.redisBehind] service is not started yet")
-		Start()
-		time.Sleep(5 * time.Second)
-	}
-	c.lock.RLock()
+
+func (c *pauseControl) behind(nextHeight int64) bool {
+	c.lock.Lock()
 	if nextHeight == 0 {
 		nextHeight = c.nextHeight
 	} else {
 		c.nextHeight = nextHeight
 	}
-	var pause = nextHeight-c.redisHeight >= c.allowOffset
-	c.lock.RUnlock()
-	log.Info(fmt.Sprintf("### DEBUG ### nextHeight(%d)-redisHeight(%d) = %d >= allowOffset(%d): %v",
-		nextHeight, c.redisHeight, nextHeight-c.redisHeight, c.allowOffset, pause))
+	consumerHeight, allowOffset, held := c.consumerHeight, c.allowOffset, c.held
+	c.lock.Unlock()
+	consumerLagGauge.Update(nextHeight - consumerHeight)
+	if held {
+		log.Trace("Block processing is held", "nextHeight", nextHeight)
+		return true
+	}
+	var pause = nextHeight-consumerHeight >= allowOffset
+	log.Trace("Checked consumer lag", "nextHeight", nextHeight, "consumerHeight", consumerHeight,
+		"lag", nextHeight-consumerHeight, "allowOffset", allowOffset, "pause", pause)
 	return pause
 }
 
+// pauseIfBehind blocks until the consumer catches up, re-checking whenever
+// the consumer height is refreshed.
 func (c *pauseControl) pauseIfBehind(tag string) (shutdown bool) {
-	stopCh := make(chan struct{})
+	start := time.Now()
+	c.setPaused(start)
+	pauseCounter.Inc(1)
+	pausedGauge.Update(1)
+	defer func() {
+		c.setPaused(time.Time{})
+		pausedGauge.Update(0)
+		pauseTimeCounter.Inc(time.Since(start).Milliseconds())
+	}()
 	for {
-		select {
-		case <-time.After(1 * time.Second):
-			//log.Debug(fmt.Sprintf("### DEBUG ### check redis block height [%s]", tag))
-			if !c.redisBehind(0) {
-				close(stopCh)
-			}
-		case <-stopCh:
-			log.Info("### DEBUG ### stop pause", "tag", tag)
+		// take the channel before checking so that no refresh is missed
+		c.lock.RLock()
+		ctx, interval, updated := c.ctx, c.interval, c.updated
+		c.lock.RUnlock()
+		if !c.behind(0) {
+			log.Debug("Resuming block processing", "tag", tag, "paused", time.Since(start))
 			return false
-		case <-c.ctx.Done():
-			log.Info("### DEBUG ### Pause Control exit", "tag", tag)
+		}
+		select {
+		case <-updated:
+		case <-time.After(interval):
+		case <-ctx.Done():
+			log.Debug("Pause interrupted by shutdown", "tag", tag)
 			return true
 		}
 	}
 }
+
+func (c *pauseControl) setPaused(since time.Time) {
+	c.lock.Lock()
+	c.pausedSince = since
+	c.lock.Unlock()
+}
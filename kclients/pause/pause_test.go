@@ -0,0 +1,47 @@
+package pause
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldAndRelease(t *testing.T) {
+	require.ErrorIs(t, Hold(), ErrNotStarted)
+
+	source := NewPushSource()
+	StartWithSource(source, 10, time.Hour)
+	defer Stop()
+
+	require.NoError(t, Push(100))
+	require.Eventually(t, func() bool {
+		return CurrentStatus().ConsumerHeight == 100
+	}, 5*time.Second, 10*time.Millisecond)
+	require.False(t, Behind(105))
+
+	require.NoError(t, Hold())
+	require.True(t, Behind(105))
+	shutdown := make(chan bool)
+	go func() {
+		shutdown <- PauseIfBehind("test")
+	}()
+	require.Eventually(t, func() bool {
+		status := CurrentStatus()
+		return status.Paused && status.Held
+	}, 5*time.Second, 10*time.Millisecond)
+	details, err := Health()
+	require.NoError(t, err)
+	require.Equal(t, true, details["held"])
+
+	require.NoError(t, Release())
+	select {
+	case s := <-shutdown:
+		require.False(t, s)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "block processing not released")
+	}
+	status := CurrentStatus()
+	require.False(t, status.Paused)
+	require.False(t, status.Held)
+}
@@ -0,0 +1,321 @@
+package pause
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/ava-labs/coreth/kclients/syncstatus"
+)
+
+// HeightSource reports the height of the last block processed by the
+// consumer of the node, which the block processing is throttled against.
+type HeightSource interface {
+	Height(ctx context.Context) (int64, error)
+}
+
+// notifier is implemented by sources which are told about new heights
+// instead of being polled. A value is sent on the channel after every
+// update.
+type notifier interface {
+	Notify() <-chan struct{}
+}
+
+// supported values of Config.Source
+const (
+	SourceSyncStatus = "syncstatus" // default, the sentinel deployment read by syncstatus
+	SourceRedis      = "redis"
+	SourceSentinel   = "sentinel"
+	SourceHTTP       = "http"
+	SourceRPC        = "rpc"
+	SourceFile       = "file"
+	SourcePush       = "push"
+)
+
+const (
+	defaultHeightField = "blockNumber"
+	sourceTimeout      = 10 * time.Second
+)
+
+var (
+	ErrUnknownSource = errors.New("unknown height source")
+	ErrNotPushSource = errors.New("height source does not accept pushed heights")
+	errInvalidHeight = errors.New("invalid height")
+)
+
+// syncStatusSource reads the height from the redis sentinel hash configured
+// for syncstatus.
+type syncStatusSource struct{}
+
+func (syncStatusSource) Height(ctx context.Context) (int64, error) {
+	h := syncstatus.RedisHeight(ctx)
+	if h == -1 {
+		return 0, errors.New("failed to read sync status height")
+	}
+	return h, nil
+}
+
+// RedisSourceConfig selects a redis hash field holding the height, either
+// as a number or as a JSON object such as {"blockNumber":N}.
+type RedisSourceConfig struct {
+	// Addrs is the address of a plain redis server, or the addresses of the
+	// sentinels if MasterName is set.
+	Addrs      []string
+	MasterName string
+	Password   string
+	DB         int
+	Key        string // hash key
+	Field      string // field of the hash
+}
+
+type redisSource struct {
+	rdb        redis.UniversalClient
+	key, field string
+}
+
+// NewRedisSource returns a HeightSource reading a redis hash field. Sentinel
+// is used if cfg.MasterName is set.
+func NewRedisSource(cfg RedisSourceConfig) (HeightSource, error) {
+	if len(cfg.Addrs) == 0 || cfg.Key == "" || cfg.Field == "" {
+		return nil, errors.New("redis height source requires addresses, key and field")
+	}
+	var rdb redis.UniversalClient
+	if cfg.MasterName != "" {
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: cfg.Addrs,
+			Password:      cfg.Password,
+			DB:            cfg.DB,
+		})
+	} else {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:     cfg.Addrs[0],
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		})
+	}
+	return &redisSource{
+		rdb:   rdb,
+		key:   cfg.Key,
+		field: cfg.Field,
+	}, nil
+}
+
+func (s *redisSource) Height(ctx context.Context) (int64, error) {
+	str, err := s.rdb.HGet(ctx, s.key, s.field).Result()
+	if err != nil {
+		return 0, err
+	}
+	return parseHeight([]byte(str), defaultHeightField)
+}
+
+// httpSource reads the height from a JSON document served over HTTP
+type httpSource struct {
+	client *http.Client
+	url    string
+	field  string
+}
+
+// NewHTTPSource returns a HeightSource reading [field] of the JSON object
+// served at [url]. The document may also be a bare number.
+func NewHTTPSource(url string, field string) (HeightSource, error) {
+	if url == "" {
+		return nil, errors.New("http height source requires a url")
+	}
+	if field == "" {
+		field = defaultHeightField
+	}
+	return &httpSource{
+		client: &http.Client{Timeout: sourceTimeout},
+		url:    url,
+		field:  field,
+	}, nil
+}
+
+func (s *httpSource) Height(ctx context.Context) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	body, err := do(s.client, req)
+	if err != nil {
+		return 0, err
+	}
+	return parseHeight(body, s.field)
+}
+
+// rpcSource calls a JSON-RPC method without parameters, eg. eth_blockNumber
+// of the node the consumer writes into.
+type rpcSource struct {
+	client *http.Client
+	url    string
+	method string
+}
+
+// NewRPCSource returns a HeightSource calling [method] on the JSON-RPC
+// endpoint at [url]. The result is either a number, a decimal or hex string,
+// or an object with a blockNumber field.
+func NewRPCSource(url string, method string) (HeightSource, error) {
+	if url == "" || method == "" {
+		return nil, errors.New("rpc height source requires a url and a method")
+	}
+	return &rpcSource{
+		client: &http.Client{Timeout: sourceTimeout},
+		url:    url,
+		method: method,
+	}, nil
+}
+
+func (s *rpcSource) Height(ctx context.Context) (int64, error) {
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  s.method,
+		"params":  []interface{}{},
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, err := do(s.client, req)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	if resp.Error != nil {
+		return 0, fmt.Errorf("%s failed: %s (%d)", s.method, resp.Error.Message, resp.Error.Code)
+	}
+	return parseHeight(resp.Result, defaultHeightField)
+}
+
+// fileSource reads the height from a local file, rewritten by the consumer
+type fileSource struct {
+	path string
+}
+
+// NewFileSource returns a HeightSource reading the file at [path], which
+// holds a number or a JSON object with a blockNumber field.
+func NewFileSource(path string) (HeightSource, error) {
+	if path == "" {
+		return nil, errors.New("file height source requires a path")
+	}
+	return &fileSource{path: path}, nil
+}
+
+func (s *fileSource) Height(context.Context) (int64, error) {
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return 0, err
+	}
+	return parseHeight(b, defaultHeightField)
+}
+
+var _ notifier = (*PushSource)(nil)
+
+// PushSource holds the last height pushed by the consumer, eg. through the
+// admin API, and wakes up paused block processing immediately.
+type PushSource struct {
+	lock   sync.Mutex
+	height int64
+	set    bool
+	notify chan struct{}
+}
+
+func NewPushSource() *PushSource {
+	return &PushSource{notify: make(chan struct{}, 1)}
+}
+
+// Set records the consumer height
+func (s *PushSource) Set(height int64) {
+	s.lock.Lock()
+	s.height, s.set = height, true
+	s.lock.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *PushSource) Height(context.Context) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.set {
+		return 0, errors.New("no height pushed yet")
+	}
+	return s.height, nil
+}
+
+func (s *PushSource) Notify() <-chan struct{} {
+	return s.notify
+}
+
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, req.URL)
+	}
+	return body, nil
+}
+
+// parseHeight parses a height given as a JSON number, a decimal or 0x
+// prefixed hex string, or a JSON object holding one of those in [field].
+func parseHeight(b []byte, field string) (int64, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return 0, err
+		}
+		v, ok := obj[field]
+		if !ok {
+			return 0, fmt.Errorf("%w: missing field %q", errInvalidHeight, field)
+		}
+		b = bytes.TrimSpace(v)
+	}
+	s := strings.Trim(string(b), `"`)
+	var (
+		h   int64
+		err error
+	)
+	if strings.HasPrefix(s, "0x") {
+		h, err = strconv.ParseInt(s[2:], 16, 64)
+	} else {
+		h, err = strconv.ParseInt(s, 10, 64)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", errInvalidHeight, s)
+	}
+	return h, nil
+}
@@ -0,0 +1,121 @@
+package pause
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeight(t *testing.T) {
+	tests := []struct {
+		input   string
+		field   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "42", want: 42},
+		{input: " 42\n", want: 42},
+		{input: `"42"`, want: 42},
+		{input: `"0x2a"`, want: 42},
+		{input: `{"blockNumber":42}`, field: "blockNumber", want: 42},
+		{input: `{"height":"0x2a"}`, field: "height", want: 42},
+		{input: `{"height":42}`, field: "blockNumber", wantErr: true},
+		{input: `"abc"`, wantErr: true},
+		{input: ``, wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := parseHeight([]byte(test.input), test.field)
+		if test.wantErr {
+			require.ErrorIs(t, err, errInvalidHeight, test.input)
+			continue
+		}
+		require.NoError(t, err, test.input)
+		require.Equal(t, test.want, got, test.input)
+	}
+}
+
+func TestHTTPSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"chain":"c","latest":1234}`)
+	}))
+	defer srv.Close()
+
+	source, err := NewHTTPSource(srv.URL, "latest")
+	require.NoError(t, err)
+	height, err := source.Height(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), height)
+}
+
+func TestRPCSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Method != "eth_blockNumber" {
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"0x4d2"}`)
+	}))
+	defer srv.Close()
+
+	source, err := NewRPCSource(srv.URL, "eth_blockNumber")
+	require.NoError(t, err)
+	height, err := source.Height(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), height)
+
+	source, err = NewRPCSource(srv.URL, "eth_unknown")
+	require.NoError(t, err)
+	_, err = source.Height(context.Background())
+	require.ErrorContains(t, err, "method not found")
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "height")
+	source, err := NewFileSource(path)
+	require.NoError(t, err)
+
+	_, err = source.Height(context.Background())
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path, []byte("1234\n"), 0o644))
+	height, err := source.Height(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), height)
+}
+
+func TestPushSourceResumesPausedProcessing(t *testing.T) {
+	require.ErrorIs(t, Push(1), ErrNotStarted)
+
+	source := NewPushSource()
+	// poll rarely so that only pushed heights can resume processing in time
+	StartWithSource(source, 10, time.Hour)
+	defer Stop()
+
+	require.NoError(t, Push(100))
+	require.Eventually(t, func() bool { return !Behind(105) }, 5*time.Second, 10*time.Millisecond)
+	require.True(t, Behind(110))
+
+	resumed := make(chan bool)
+	go func() {
+		resumed <- PauseIfBehind("test")
+	}()
+	require.NoError(t, Push(101))
+	select {
+	case shutdown := <-resumed:
+		require.False(t, shutdown)
+	case <-time.After(5 * time.Second):
+		t.Fatal("processing was not resumed by the pushed height")
+	}
+}
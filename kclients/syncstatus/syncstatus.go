@@ -3,8 +3,9 @@ package syncstatus
 import (
 	"context"
 	"encoding/json"
-	"github.com/ava-labs/coreth/kclients/util/env"
-	"github.com/ava-labs/coreth/kclients/util/sig"
+	"errors"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/go-redis/redis/v8"
 )
@@ -13,87 +14,104 @@ type Result struct {
 	BlockNumber int64 `json:"blockNumber"`
 }
 
-var rdb *redis.Client
+// Config locates the consumer height in the hash chain_latest:timeline of a
+// redis sentinel deployment, keyed by chain name.
+type Config struct {
+	Addrs      []string // sentinel addresses, syncstatus is disabled if empty
+	MasterName string
+	Password   string
+	DB         int
+	ChainName  string
 
-type redisHeight struct {
-	testRedisHeight int64
-	testOffset      int64
+	// TestHeight is reported instead of the redis height if non-zero
+	TestHeight int64
+	// TestOffset is subtracted from the redis height
+	TestOffset int64
 }
 
-var rh redisHeight
-
 var (
-	addrs      = []string{}
-	masterName string
-	password   string
-	db         int
-
-	chainName string
+	ErrInvalidMasterName = errors.New("invalid etl redis master name")
+	ErrInvalidChainName  = errors.New("invalid etl chain name")
+	ErrInvalidDB         = errors.New("invalid etl redis db")
 )
 
-func Start() {
-	if rdb != nil {
-		return
-	}
-	addrs = env.LoadEnvStrings(env.EnvETLAddrs)
-	if len(addrs) == 0 {
-		sig.Int("empty etl redis endpoint list")
-		return
-	}
-	masterName = env.LoadEnvString(env.EnvETLMasterName)
-	if masterName == "" {
-		sig.Int("invalid etl redis master name")
-		return
-	}
-	password = env.LoadEnvStringMute(env.EnvETLPassword)
-	if password == "" {
-		sig.Int("invalid etl redis password")
-		return
-	}
-	chainName = env.LoadEnvString(env.EnvETLChainName)
-	if chainName == "" {
-		sig.Int("invalid etl chain name")
-		return
+// Enabled returns whether a height can be reported with [c]
+func (c Config) Enabled() bool {
+	return len(c.Addrs) > 0 || c.TestHeight != 0
+}
+
+// Validate returns an error if [c] is enabled but incomplete
+func (c Config) Validate() error {
+	if len(c.Addrs) == 0 {
+		return nil
 	}
-	db = env.LoadEnvInt(env.EnvETLDB)
-	if db == env.WrongInt {
-		return
+	switch {
+	case c.MasterName == "":
+		return ErrInvalidMasterName
+	case c.ChainName == "":
+		return ErrInvalidChainName
+	case c.DB < 0:
+		return ErrInvalidDB
 	}
+	return nil
+}
 
-	rh.testOffset = env.LoadEnvInt64(env.EnvTestOffset)
-	if rh.testOffset == env.WrongInt {
-		return
+var (
+	lock sync.Mutex
+	cfg  Config
+	rdb  *redis.Client
+)
+
+// Start sets the redis deployment read by RedisHeight
+func Start(c Config) {
+	lock.Lock()
+	defer lock.Unlock()
+	if rdb != nil {
+		_ = rdb.Close()
+		rdb = nil
 	}
-	rh.testRedisHeight = env.LoadEnvInt64(env.EnvTestRedisHeight)
-	if rh.testRedisHeight == env.WrongInt {
-		return
+	cfg = c
+	if c.Enabled() {
+		log.Info("Sync status configured", "addrs", c.Addrs, "masterName", c.MasterName, "chainName", c.ChainName)
 	}
 }
 
-func RedisHeight() int64 {
-	if rh.testRedisHeight != 0 {
-		return rh.testRedisHeight
+// client returns the configured client, or nil if syncstatus is disabled.
+func client() (*redis.Client, Config) {
+	lock.Lock()
+	defer lock.Unlock()
+	if rdb == nil && len(cfg.Addrs) > 0 {
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: cfg.Addrs,
+			Password:      cfg.Password,
+			DB:            cfg.DB,
+		})
 	}
+	return rdb, cfg
+}
 
+// RedisHeight returns the consumer height, or -1 if it cannot be read before
+// [ctx] is done.
+func RedisHeight(ctx context.Context) int64 {
+	rdb, cfg := client()
+	if cfg.TestHeight != 0 {
+		return cfg.TestHeight
+	}
 	if rdb == nil {
-		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
-			MasterName:    masterName,
-			SentinelAddrs: addrs,
-			Password:      password,
-			DB:            db,
-		})
+		log.Debug("Sync status is not configured")
+		return -1
 	}
-	ctx := context.Background()
-	str, err := rdb.HGet(ctx, "chain_latest:timeline", chainName).Result()
+	str, err := rdb.HGet(ctx, "chain_latest:timeline", cfg.ChainName).Result()
 	if err != nil {
-		log.Error("### DEBUG ### redis HGet err", "err", err)
+		log.Warn("Failed to read sync status", "chainName", cfg.ChainName, "err", err)
 		return -1
 	}
 	var r Result
 	err = json.Unmarshal([]byte(str), &r)
 	if err != nil {
-		log.Error("### DEBUG ### json unmarshall err", "err", err)
+		log.Warn("Failed to decode sync status", "chainName", cfg.ChainName, "status", str, "err", err)
 		return -1
 	}
-	return r.BlockNumber - rh.testOffset
+	return r.BlockNumber - cfg.TestOffset
 }
@@ -0,0 +1,33 @@
+package tracecache
+
+import "context"
+
+var _ TraceSink = (*ChanSink)(nil)
+
+// ChanSink delivers traces to an in-process channel. It lets tests, or a
+// process embedding the node, consume the live trace pipeline without any
+// external service.
+type ChanSink struct {
+	C chan Trace
+}
+
+// NewChanSink returns a ChanSink whose channel buffers [size] traces.
+func NewChanSink(size int) *ChanSink {
+	return &ChanSink{C: make(chan Trace, size)}
+}
+
+// Send blocks until the trace is received or [ctx] is done.
+func (s *ChanSink) Send(ctx context.Context, t Trace) error {
+	select {
+	case s.C <- t:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close closes the channel so consumers ranging over it terminate.
+func (s *ChanSink) Close() error {
+	close(s.C)
+	return nil
+}
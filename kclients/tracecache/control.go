@@ -0,0 +1,109 @@
+package tracecache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	ErrSinkDisabled     = errors.New("trace sink is not configured")
+	ErrPruneUnsupported = errors.New("trace sink does not support pruning")
+)
+
+// Status is a snapshot of the service
+type Status struct {
+	Started       bool
+	QueueSize     uint64
+	Pending       int   // number of blocks waiting for a decision
+	LastPublished int64 // number of the last accepted block sent, -1 if none
+	LastError     string
+}
+
+// CurrentStatus returns the status of the service
+func CurrentStatus() Status {
+	c := current()
+	if c == nil {
+		return Status{LastPublished: -1}
+	}
+	status := Status{
+		Started:   true,
+		QueueSize: c.q.len(),
+		Pending:   numPending(),
+	}
+	c.lock.Lock()
+	status.LastPublished = c.lastPublished
+	if c.lastErr != nil {
+		status.LastError = c.lastErr.Error()
+	}
+	c.lock.Unlock()
+	return status
+}
+
+// pruner is implemented by the sinks retaining traces for their consumers
+type pruner interface {
+	Prune(ctx context.Context) error
+}
+
+// Prune deletes the traces acknowledged by the consumers from the sink, which
+// is otherwise done before each trace is published.
+func Prune(ctx context.Context) error {
+	c := current()
+	if c == nil {
+		return ErrNotStarted
+	}
+	c.sinkLock.Lock()
+	defer c.sinkLock.Unlock()
+	p, ok := c.sink.(pruner)
+	if !ok {
+		return ErrPruneUnsupported
+	}
+	return p.Prune(ctx)
+}
+
+// SinkConfig returns the configuration of the sink in use, or false if the
+// service is not started or was started with StartWithSink.
+func SinkConfig() (Config, bool) {
+	c := current()
+	if c == nil {
+		return Config{}, false
+	}
+	c.sinkLock.Lock()
+	defer c.sinkLock.Unlock()
+	return c.config, c.config.Enabled()
+}
+
+// SetSink replaces the sink of the running service by the sink configured by
+// [cfg]. The trace being sent, if any, is sent to the old sink, which is
+// closed. Queued traces are sent to the new sink.
+func SetSink(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if !cfg.Enabled() {
+		return ErrSinkDisabled
+	}
+	c := current()
+	if c == nil {
+		return ErrNotStarted
+	}
+	sink, err := newSink(cfg)
+	if err != nil {
+		return err
+	}
+	c.sinkLock.Lock()
+	defer c.sinkLock.Unlock()
+	if c.ctx.Err() != nil {
+		// stopped meanwhile
+		_ = sink.Close()
+		return ErrNotStarted
+	}
+	old := c.sink
+	c.sink, c.config = sink, cfg
+	if err := old.Close(); err != nil {
+		log.Warn("Failed to close the replaced trace sink", "err", err)
+	}
+	log.Info("Trace sink replaced", "sink", cfg.Sink, "endpoint", cfg.Endpoint, "key", cfg.Key, "dir", cfg.FileDir)
+	return nil
+}
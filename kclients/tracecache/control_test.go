@@ -0,0 +1,56 @@
+package tracecache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSinkDeliversQueuedTraces(t *testing.T) {
+	require.Equal(t, Status{LastPublished: -1}, CurrentStatus())
+	require.ErrorIs(t, SetSink(Config{Sink: SinkFile, FileDir: t.TempDir()}), ErrNotStarted)
+
+	down := &flakySink{ChanSink: NewChanSink(4), failures: 1 << 30}
+	require.NoError(t, StartWithSink(context.Background(), down, nil))
+	defer Stop()
+
+	header := testHeader(1, 0)
+	Buffer(header, "", []byte(`{}`), nil)
+	Accept(header.Hash(), 1)
+	require.Eventually(t, func() bool {
+		return CurrentStatus().LastError != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	status := CurrentStatus()
+	require.True(t, status.Started)
+	require.EqualValues(t, 1, status.QueueSize)
+	require.EqualValues(t, -1, status.LastPublished)
+	require.ErrorIs(t, Prune(context.Background()), ErrPruneUnsupported)
+
+	require.ErrorIs(t, SetSink(Config{}), ErrSinkDisabled)
+	require.ErrorIs(t, SetSink(Config{Sink: SinkFile}), ErrInvalidFileDir)
+	dir := t.TempDir()
+	_, ok := SinkConfig()
+	require.False(t, ok)
+	require.NoError(t, SetSink(Config{Sink: SinkFile, FileDir: dir}))
+	cfg, ok := SinkConfig()
+	require.True(t, ok)
+	require.Equal(t, dir, cfg.FileDir)
+	require.Eventually(t, func() bool {
+		return CurrentStatus().LastPublished == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	status = CurrentStatus()
+	require.Zero(t, status.QueueSize)
+	require.Empty(t, status.LastError)
+
+	Stop()
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), header.Hash().Hex())
+}
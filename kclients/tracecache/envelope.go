@@ -0,0 +1,371 @@
+package tracecache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// EnvelopeVersion is the schema version of the published envelope. Version 1
+// was the bare JSON array of transaction traces published before envelopes.
+const EnvelopeVersion = 2
+
+// supported values of Config.Encoding
+const (
+	EncodingJSON   = "json"
+	EncodingBinary = "binary"
+)
+
+// supported values of Config.Compression
+const (
+	CompressionNone   = "none"
+	CompressionSnappy = "snappy"
+	CompressionZstd   = "zstd"
+)
+
+var (
+	ErrInvalidEnvelope    = errors.New("invalid trace envelope")
+	ErrInvalidEncoding    = errors.New("invalid trace encoding")
+	ErrInvalidCompression = errors.New("invalid trace compression")
+)
+
+// identifiers of the encodings and compressions in the envelope header
+const (
+	encodingJSON byte = iota
+	encodingBinary
+)
+
+const (
+	compressionNone byte = iota
+	compressionSnappy
+	compressionZstd
+)
+
+// size of the header preceding the payload of a framed envelope: the
+// version, the encoding and the compression
+const envelopeHeaderSize = 3
+
+// size of the fixed part of the binary encoding: the event type, the block
+// number, the block and parent hashes, the timestamp and the length of the
+// tracer name
+const binaryFixedSize = 1 + 8 + 2*common.HashLength + 8 + 1
+
+// jsonEnvelope is the JSON encoding of a Trace
+type jsonEnvelope struct {
+	Version     uint8           `json:"version"`
+	Type        string          `json:"type"`
+	BlockNumber int64           `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	ParentHash  common.Hash     `json:"parentHash"`
+	Timestamp   uint64          `json:"timestamp"`
+	Tracer      string          `json:"tracer,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	StateDiff   json.RawMessage `json:"stateDiff,omitempty"`
+	AtomicTxs   json.RawMessage `json:"atomicTxs,omitempty"`
+}
+
+// Codec encodes traces into the envelopes published to the sinks.
+//
+// An uncompressed JSON envelope is published as is, ie. a JSON object
+// starting with '{':
+//
+//	{"version":2,"type":"accepted","blockNumber":N,"blockHash":"0x..","parentHash":"0x..","timestamp":T,"tracer":"callTracer","result":[...],"stateDiff":{...},"atomicTxs":[...]}
+//
+// Any other envelope is framed by a 3 byte header holding the version, the
+// encoding (0 json, 1 binary) and the compression (0 none, 1 snappy, 2 zstd)
+// of the payload that follows. The binary encoding is the 1 byte event type,
+// the 8 byte big-endian block number, the 32 byte block hash, the 32 byte
+// parent hash, the 8 byte big-endian timestamp, the 1 byte length of the
+// tracer name, the tracer name, the 4 byte big-endian length of the state
+// diff, the state diff, the 4 byte big-endian length of the atomic txs, the
+// atomic txs and the raw trace results.
+//
+// Decode also accepts the bare JSON array of version 1, which carries the
+// trace results of an accepted block only.
+type Codec struct {
+	Encoding    string // EncodingJSON if empty
+	Compression string // CompressionNone if empty
+}
+
+// Validate returns an error if [c] selects an unknown encoding or compression
+func (c Codec) Validate() error {
+	if _, err := c.encoding(); err != nil {
+		return err
+	}
+	_, err := c.compression()
+	return err
+}
+
+// plainJSON returns whether [c] publishes uncompressed JSON envelopes
+func (c Codec) plainJSON() bool {
+	encoding, err := c.encoding()
+	if err != nil || encoding != encodingJSON {
+		return false
+	}
+	compression, err := c.compression()
+	return err == nil && compression == compressionNone
+}
+
+func (c Codec) encoding() (byte, error) {
+	switch c.Encoding {
+	case "", EncodingJSON:
+		return encodingJSON, nil
+	case EncodingBinary:
+		return encodingBinary, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidEncoding, c.Encoding)
+	}
+}
+
+func (c Codec) compression() (byte, error) {
+	switch c.Compression {
+	case "", CompressionNone:
+		return compressionNone, nil
+	case CompressionSnappy:
+		return compressionSnappy, nil
+	case CompressionZstd:
+		return compressionZstd, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCompression, c.Compression)
+	}
+}
+
+// Encode returns the envelope of [t]
+func (c Codec) Encode(t Trace) ([]byte, error) {
+	encoding, err := c.encoding()
+	if err != nil {
+		return nil, err
+	}
+	compression, err := c.compression()
+	if err != nil {
+		return nil, err
+	}
+	var payload []byte
+	switch encoding {
+	case encodingJSON:
+		if payload, err = encodeJSON(t); err != nil {
+			return nil, err
+		}
+		if compression == compressionNone {
+			return payload, nil
+		}
+	case encodingBinary:
+		if payload, err = encodeBinary(t); err != nil {
+			return nil, err
+		}
+	}
+	header := []byte{EnvelopeVersion, encoding, compression}
+	switch compression {
+	case compressionSnappy:
+		return append(header, snappy.Encode(nil, payload)...), nil
+	case compressionZstd:
+		return zstdEncoder().EncodeAll(payload, header), nil
+	default:
+		return append(header, payload...), nil
+	}
+}
+
+// Decode parses an envelope produced by any Codec, or the bare trace results
+// of version 1.
+func Decode(b []byte) (Trace, error) {
+	if len(b) > 0 && b[0] == '{' {
+		return decodeJSON(b)
+	}
+	if len(b) > 0 && b[0] == '[' {
+		return decodeBare(b)
+	}
+	if len(b) < envelopeHeaderSize {
+		return Trace{}, fmt.Errorf("%w: short header of %d bytes", ErrInvalidEnvelope, len(b))
+	}
+	if version := b[0]; version != EnvelopeVersion {
+		return Trace{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidEnvelope, version)
+	}
+	payload := b[envelopeHeaderSize:]
+	var err error
+	switch b[2] {
+	case compressionNone:
+	case compressionSnappy:
+		payload, err = snappy.Decode(nil, payload)
+	case compressionZstd:
+		payload, err = zstdDecoder().DecodeAll(payload, nil)
+	default:
+		return Trace{}, fmt.Errorf("%w: unknown compression %d", ErrInvalidEnvelope, b[2])
+	}
+	if err != nil {
+		return Trace{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	switch b[1] {
+	case encodingJSON:
+		return decodeJSON(payload)
+	case encodingBinary:
+		return decodeBinary(payload)
+	default:
+		return Trace{}, fmt.Errorf("%w: unknown encoding %d", ErrInvalidEnvelope, b[1])
+	}
+}
+
+func encodeJSON(t Trace) ([]byte, error) {
+	return json.Marshal(jsonEnvelope{
+		Version:     EnvelopeVersion,
+		Type:        t.Type.String(),
+		BlockNumber: t.BlockNumber,
+		BlockHash:   t.BlockHash,
+		ParentHash:  t.ParentHash,
+		Timestamp:   t.Timestamp,
+		Tracer:      t.Tracer,
+		Result:      t.Result,
+		StateDiff:   t.StateDiff,
+		AtomicTxs:   t.AtomicTxs,
+	})
+}
+
+func decodeJSON(b []byte) (Trace, error) {
+	var e jsonEnvelope
+	if err := json.Unmarshal(b, &e); err != nil {
+		return Trace{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if e.Version != EnvelopeVersion {
+		return Trace{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidEnvelope, e.Version)
+	}
+	t := Trace{
+		BlockNumber: e.BlockNumber,
+		BlockHash:   e.BlockHash,
+		ParentHash:  e.ParentHash,
+		Timestamp:   e.Timestamp,
+		Tracer:      e.Tracer,
+	}
+	switch e.Type {
+	case EventAccepted.String():
+		t.Type = EventAccepted
+	case EventRejected.String():
+		t.Type = EventRejected
+	default:
+		return Trace{}, fmt.Errorf("%w: unknown event type %q", ErrInvalidEnvelope, e.Type)
+	}
+	if len(e.Result) > 0 {
+		t.Result = []byte(e.Result)
+	}
+	if len(e.StateDiff) > 0 {
+		t.StateDiff = []byte(e.StateDiff)
+	}
+	if len(e.AtomicTxs) > 0 {
+		t.AtomicTxs = []byte(e.AtomicTxs)
+	}
+	return t, nil
+}
+
+// decodeBare parses the bare JSON array of trace results of version 1, which
+// does not identify the block it belongs to.
+func decodeBare(b []byte) (Trace, error) {
+	if !json.Valid(b) {
+		return Trace{}, fmt.Errorf("%w: invalid version 1 trace results", ErrInvalidEnvelope)
+	}
+	return Trace{Type: EventAccepted, Result: common.CopyBytes(b)}, nil
+}
+
+func encodeBinary(t Trace) ([]byte, error) {
+	if len(t.Tracer) > 255 {
+		return nil, fmt.Errorf("tracer name of %d bytes is too long", len(t.Tracer))
+	}
+	b := make([]byte, binaryFixedSize, binaryFixedSize+len(t.Tracer)+4+len(t.StateDiff)+4+len(t.AtomicTxs)+len(t.Result))
+	b[0] = byte(t.Type)
+	binary.BigEndian.PutUint64(b[1:9], uint64(t.BlockNumber))
+	copy(b[9:41], t.BlockHash[:])
+	copy(b[41:73], t.ParentHash[:])
+	binary.BigEndian.PutUint64(b[73:81], t.Timestamp)
+	b[81] = byte(len(t.Tracer))
+	b = append(b, t.Tracer...)
+	b = appendSized(b, t.StateDiff)
+	b = appendSized(b, t.AtomicTxs)
+	return append(b, t.Result...), nil
+}
+
+func decodeBinary(b []byte) (Trace, error) {
+	if len(b) < binaryFixedSize {
+		return Trace{}, fmt.Errorf("%w: short payload of %d bytes", ErrInvalidEnvelope, len(b))
+	}
+	tracerLen := int(b[81])
+	if len(b) < binaryFixedSize+tracerLen {
+		return Trace{}, fmt.Errorf("%w: truncated tracer name", ErrInvalidEnvelope)
+	}
+	switch EventType(b[0]) {
+	case EventAccepted, EventRejected:
+	default:
+		return Trace{}, fmt.Errorf("%w: unknown event type %d", ErrInvalidEnvelope, b[0])
+	}
+	t := Trace{
+		Type:        EventType(b[0]),
+		BlockNumber: int64(binary.BigEndian.Uint64(b[1:9])),
+		BlockHash:   common.BytesToHash(b[9:41]),
+		ParentHash:  common.BytesToHash(b[41:73]),
+		Timestamp:   binary.BigEndian.Uint64(b[73:81]),
+		Tracer:      string(b[binaryFixedSize : binaryFixedSize+tracerLen]),
+	}
+	b = b[binaryFixedSize+tracerLen:]
+	var err error
+	if t.StateDiff, b, err = readSized(b, "state diff"); err != nil {
+		return Trace{}, err
+	}
+	if t.AtomicTxs, b, err = readSized(b, "atomic txs"); err != nil {
+		return Trace{}, err
+	}
+	if result := b; len(result) > 0 {
+		t.Result = common.CopyBytes(result)
+	}
+	return t, nil
+}
+
+// appendSized appends [field] preceded by its 4 byte big-endian length
+func appendSized(b []byte, field []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(field)))
+	return append(b, field...)
+}
+
+// readSized reads a field written by appendSized, returning a copy of the
+// field or nil if empty and the remainder of [b].
+func readSized(b []byte, name string) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("%w: truncated %s", ErrInvalidEnvelope, name)
+	}
+	size := uint64(binary.BigEndian.Uint32(b[:4]))
+	if uint64(len(b)-4) < size {
+		return nil, nil, fmt.Errorf("%w: truncated %s", ErrInvalidEnvelope, name)
+	}
+	var field []byte
+	if size > 0 {
+		field = common.CopyBytes(b[4 : 4+size])
+	}
+	return field, b[4+size:], nil
+}
+
+var (
+	zstdOnce sync.Once
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+)
+
+// zstdEncoder and zstdDecoder return shared instances, which are safe for
+// concurrent use of EncodeAll and DecodeAll.
+func zstdEncoder() *zstd.Encoder {
+	initZstd()
+	return zstdEnc
+}
+
+func zstdDecoder() *zstd.Decoder {
+	initZstd()
+	return zstdDec
+}
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		// neither fails without options
+		zstdEnc, _ = zstd.NewWriter(nil)
+		zstdDec, _ = zstd.NewReader(nil)
+	})
+}
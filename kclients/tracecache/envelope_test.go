@@ -0,0 +1,100 @@
+package tracecache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	traces := []Trace{
+		{
+			Type:        EventAccepted,
+			BlockNumber: 7,
+			BlockHash:   common.Hash{7},
+			ParentHash:  common.Hash{6},
+			Timestamp:   1700000000,
+			Tracer:      "callTracer",
+			Result:      []byte(`[{"txHash":"0x07","result":{"calls":[]}}]`),
+			StateDiff:   []byte(`{"accounts":[{"address":"0x0000000000000000000000000000000000000007"}]}`),
+			AtomicTxs:   []byte(`[{"type":"import"}]`),
+		},
+		{Type: EventAccepted, BlockNumber: 9, BlockHash: common.Hash{9}, StateDiff: []byte(`{"accounts":[]}`)},
+		{Type: EventRejected, BlockNumber: 8, BlockHash: common.Hash{8}, ParentHash: common.Hash{7}},
+	}
+	for _, encoding := range []string{"", EncodingJSON, EncodingBinary} {
+		for _, compression := range []string{"", CompressionNone, CompressionSnappy, CompressionZstd} {
+			codec := Codec{Encoding: encoding, Compression: compression}
+			t.Run(fmt.Sprintf("%s/%s", encoding, compression), func(t *testing.T) {
+				for _, want := range traces {
+					b, err := codec.Encode(want)
+					require.NoError(t, err)
+					got, err := Decode(b)
+					require.NoError(t, err)
+					require.Equal(t, want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestEnvelopePlainJSON(t *testing.T) {
+	b, err := Codec{}.Encode(Trace{BlockNumber: 1, Result: []byte(`[]`)})
+	require.NoError(t, err)
+	var e map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &e))
+	require.Equal(t, float64(EnvelopeVersion), e["version"])
+	require.Equal(t, "accepted", e["type"])
+
+	b, err = Codec{Encoding: EncodingBinary}.Encode(Trace{BlockNumber: 1})
+	require.NoError(t, err)
+	require.Equal(t, []byte{EnvelopeVersion, encodingBinary, compressionNone}, b[:envelopeHeaderSize])
+}
+
+func TestEnvelopeCompresses(t *testing.T) {
+	result := bytes.Repeat([]byte(`{"type":"CALL","from":"0x0000000000000000000000000000000000000001"},`), 100)
+	tr := Trace{BlockNumber: 1, Result: append(append([]byte{'['}, result[:len(result)-1]...), ']')}
+	plain, err := Codec{}.Encode(tr)
+	require.NoError(t, err)
+	for _, compression := range []string{CompressionSnappy, CompressionZstd} {
+		b, err := Codec{Compression: compression}.Encode(tr)
+		require.NoError(t, err)
+		require.Less(t, len(b), len(plain)/4, compression)
+	}
+}
+
+func TestDecodeBareResults(t *testing.T) {
+	// version 1 published the trace results of accepted blocks as is
+	got, err := Decode([]byte(`[{"txHash":"0x07","result":{"calls":[]}}]`))
+	require.NoError(t, err)
+	require.Equal(t, Trace{Type: EventAccepted, Result: []byte(`[{"txHash":"0x07","result":{"calls":[]}}]`)}, got)
+}
+
+func TestDecodeInvalidEnvelope(t *testing.T) {
+	for _, b := range [][]byte{
+		nil,
+		{EnvelopeVersion, encodingBinary},
+		{1, encodingBinary, compressionNone},
+		{EnvelopeVersion + 1, encodingBinary, compressionNone},
+		{EnvelopeVersion, 9, compressionNone},
+		{EnvelopeVersion, encodingBinary, 9},
+		{EnvelopeVersion, encodingBinary, compressionNone, 0},
+		append([]byte{EnvelopeVersion, encodingBinary, compressionNone}, append(make([]byte, binaryFixedSize), 0, 0, 0, 9, '{')...),
+		append([]byte{EnvelopeVersion, encodingBinary, compressionNone}, append(make([]byte, binaryFixedSize), 0, 0, 0, 0, 0, 0)...),
+		append([]byte{EnvelopeVersion, encodingBinary, compressionNone, 9}, make([]byte, binaryFixedSize-1+8)...),
+		{EnvelopeVersion, encodingJSON, compressionSnappy, 0xff},
+		[]byte(`{"version":1,"type":"accepted"}`),
+		[]byte(`{"version":3,"type":"accepted"}`),
+		[]byte(`{"version":2,"type":"forked"}`),
+		[]byte(`[{"txHash":`),
+	} {
+		_, err := Decode(b)
+		require.ErrorIs(t, err, ErrInvalidEnvelope, "%x", b)
+	}
+	_, err := Codec{Encoding: "xml"}.Encode(Trace{})
+	require.ErrorIs(t, err, ErrInvalidEncoding)
+}
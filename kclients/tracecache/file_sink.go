@@ -0,0 +1,191 @@
+package tracecache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var _ TraceSink = (*fileSink)(nil)
+
+// supported values of Config.FileFormat
+const (
+	FormatJSONL          = "jsonl"
+	FormatLengthPrefixed = "lp"
+)
+
+const defaultFileMaxSize = 256 * 1024 * 1024
+
+var (
+	ErrInvalidFileDir    = errors.New("invalid trace file directory")
+	ErrInvalidFileFormat = errors.New("invalid trace file format")
+)
+
+// size of the length preceding every envelope of the length-prefixed format
+const lpHeaderSize = 4
+
+// traceFile is the file written by a fileSink, implemented by *os.File
+type traceFile interface {
+	io.WriteSeeker
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+}
+
+// fileSink appends traces to local files and starts a new file once the
+// current one exceeds maxSize. Each file is named after the first block
+// number it contains, so files sort in block order.
+//
+// In the jsonl format every record is the uncompressed JSON envelope on one
+// line. In the length-prefixed format every record is a 4 byte big-endian
+// length followed by the envelope encoded by the configured Codec.
+type fileSink struct {
+	dir     string
+	format  string
+	maxSize int64
+	codec   Codec
+
+	f       traceFile
+	size    int64 // size of the complete records in f
+	partial bool  // whether f ends with a partially written record
+}
+
+// NewFileSink returns a TraceSink writing rotating files into dir. A maxSize
+// of zero selects the default of 256 MiB. [codec] applies to the
+// length-prefixed format only.
+func NewFileSink(dir string, format string, maxSize int64, codec Codec) (TraceSink, error) {
+	if dir == "" {
+		return nil, ErrInvalidFileDir
+	}
+	switch format {
+	case "":
+		format = FormatJSONL
+	case FormatJSONL, FormatLengthPrefixed:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFileFormat, format)
+	}
+	if err := codec.Validate(); err != nil {
+		return nil, err
+	}
+	if maxSize <= 0 {
+		maxSize = defaultFileMaxSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileSink{
+		dir:     dir,
+		format:  format,
+		maxSize: maxSize,
+		codec:   codec,
+	}, nil
+}
+
+func (s *fileSink) Send(_ context.Context, t Trace) error {
+	record, err := s.encode(t)
+	if err != nil {
+		return err
+	}
+	if s.partial {
+		if err := s.truncate(); err != nil {
+			return err
+		}
+	}
+	if s.f == nil || s.size+int64(len(record)) > s.maxSize {
+		if err := s.rotate(t.BlockNumber); err != nil {
+			return err
+		}
+	}
+	if _, err := s.f.Write(record); err != nil {
+		// the queue retries the whole record, so the bytes written are
+		// dropped first
+		s.partial = true
+		if err := s.truncate(); err != nil {
+			log.Error("Failed to drop partial trace record", "blockNumber", t.BlockNumber, "err", err)
+		}
+		return err
+	}
+	s.size += int64(len(record))
+	fileBytesCounter.Inc(int64(len(record)))
+	return nil
+}
+
+// truncate cuts the current file back to the end of the last complete record
+func (s *fileSink) truncate() error {
+	if err := s.f.Truncate(s.size); err != nil {
+		return err
+	}
+	if _, err := s.f.Seek(s.size, io.SeekStart); err != nil {
+		return err
+	}
+	s.partial = false
+	return nil
+}
+
+func (s *fileSink) encode(t Trace) ([]byte, error) {
+	if s.format == FormatLengthPrefixed {
+		envelope, err := s.codec.Encode(t)
+		if err != nil {
+			return nil, err
+		}
+		record := binary.BigEndian.AppendUint32(make([]byte, 0, lpHeaderSize+len(envelope)), uint32(len(envelope)))
+		return append(record, envelope...), nil
+	}
+	record, err := encodeJSON(t)
+	if err != nil {
+		return nil, err
+	}
+	return append(record, '\n'), nil
+}
+
+// rotate closes the current file, if any, and opens the file for a segment
+// starting at [blockNumber]. An existing file of that name is appended to.
+func (s *fileSink) rotate(blockNumber int64) error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, fmt.Sprintf("traces-%020d.%s", blockNumber, s.format))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.f = f
+	s.size = info.Size()
+	log.Info("Opened trace file", "name", name, "size", s.size)
+	return nil
+}
+
+func (s *fileSink) closeFile() error {
+	if s.f == nil {
+		return nil
+	}
+	var err error
+	if s.partial {
+		err = s.truncate()
+	}
+	f := s.f
+	s.f, s.size, s.partial = nil, 0, false
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *fileSink) Close() error {
+	return s.closeFile()
+}
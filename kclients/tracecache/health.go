@@ -0,0 +1,36 @@
+package tracecache
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxSendStall is how long the oldest queued trace may wait without any trace
+// being delivered before the service reports itself unhealthy.
+const maxSendStall = time.Minute
+
+// Health reports the state of the service. It returns an error if the sink
+// has not accepted a trace for longer than maxSendStall while traces are
+// queued, eg. because redis is down.
+func Health() (map[string]interface{}, error) {
+	c := current()
+	if c == nil {
+		return map[string]interface{}{"started": false}, nil
+	}
+	c.lock.Lock()
+	lastErr := c.lastErr
+	c.lock.Unlock()
+	stalled := c.q.stalled()
+	details := map[string]interface{}{
+		"started":    true,
+		"queueDepth": c.q.len(),
+		"stalled":    stalled.String(),
+	}
+	if lastErr != nil {
+		details["lastError"] = lastErr.Error()
+	}
+	if stalled > maxSendStall {
+		return details, fmt.Errorf("trace sink stalled for %s with %d traces queued: %v", stalled.Truncate(time.Second), c.q.len(), lastErr)
+	}
+	return details, nil
+}
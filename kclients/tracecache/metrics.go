@@ -0,0 +1,18 @@
+package tracecache
+
+import "github.com/ava-labs/libevm/metrics"
+
+var (
+	queueDepthGauge    = metrics.NewRegisteredGauge("tracecache/queue/depth", nil)
+	sendTimer          = metrics.NewRegisteredTimer("tracecache/send", nil)
+	sendErrorsCounter  = metrics.NewRegisteredCounter("tracecache/send/errors", nil)
+	sentCounter        = metrics.NewRegisteredCounter("tracecache/sent", nil)
+	droppedCounter     = metrics.NewRegisteredCounter("tracecache/dropped", nil)
+	redisErrorsCounter = metrics.NewRegisteredCounter("tracecache/redis/errors", nil)
+	fileBytesCounter   = metrics.NewRegisteredCounter("tracecache/file/bytes", nil)
+
+	retainedBlocksGauge = metrics.NewRegisteredGauge("tracecache/redis/retained/blocks", nil)
+	retainedBytesGauge  = metrics.NewRegisteredGauge("tracecache/redis/retained/bytes", nil)
+	// committedGauge is the highest block acknowledged by every consumer group
+	committedGauge = metrics.NewRegisteredGauge("tracecache/redis/committed", nil)
+)
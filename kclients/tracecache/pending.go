@@ -0,0 +1,118 @@
+package tracecache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// pending holds the traces of blocks that passed verification but were not
+// yet decided by consensus, keyed by block hash. Only blocks that are
+// accepted get their traces published, so a sink never sees data of a
+// block which did not end up in the canonical chain.
+var pending = struct {
+	sync.Mutex
+	traces map[common.Hash]Trace
+}{
+	traces: make(map[common.Hash]Trace),
+}
+
+// Buffer holds the traces produced by [tracer] and the traced atomic txs of a
+// verified block until Accept or Reject is called with the same block hash.
+func Buffer(header *types.Header, tracer string, traceResult []byte, atomicTxs []byte) {
+	pending.Lock()
+	defer pending.Unlock()
+	t := newTrace(header, tracer, traceResult, atomicTxs)
+	t.StateDiff = pending.traces[header.Hash()].StateDiff
+	pending.traces[header.Hash()] = t
+}
+
+// BufferStateDiff holds the JSON encoded state diff of a verified block, to
+// be published along with its traces.
+func BufferStateDiff(header *types.Header, diff []byte) {
+	pending.Lock()
+	defer pending.Unlock()
+	t, ok := pending.traces[header.Hash()]
+	if !ok {
+		t = newTrace(header, "", nil, nil)
+	}
+	t.StateDiff = diff
+	pending.traces[header.Hash()] = t
+}
+
+func newTrace(header *types.Header, tracer string, traceResult []byte, atomicTxs []byte) Trace {
+	return Trace{
+		Type:        EventAccepted,
+		BlockNumber: header.Number.Int64(),
+		BlockHash:   header.Hash(),
+		ParentHash:  header.ParentHash,
+		Timestamp:   header.Time,
+		Tracer:      tracer,
+		Result:      traceResult,
+		AtomicTxs:   atomicTxs,
+	}
+}
+
+// Accept publishes the buffered traces of an accepted block. It is a no-op
+// if the service is not started.
+func Accept(blockHash common.Hash, blockNumber int64) {
+	pending.Lock()
+	t, ok := pending.traces[blockHash]
+	delete(pending.traces, blockHash)
+	pending.Unlock()
+	if !Started() {
+		return
+	}
+	if !ok {
+		log.Warn("No traces buffered for accepted block", "blockNumber", blockNumber, "blockHash", blockHash)
+		return
+	}
+	write(t)
+}
+
+// Reject drops the buffered traces of a rejected block and publishes a
+// rejected event for it. It is a no-op if the service is not started.
+func Reject(blockHash common.Hash, blockNumber int64) {
+	pending.Lock()
+	delete(pending.traces, blockHash)
+	pending.Unlock()
+	if !Started() {
+		return
+	}
+	write(Trace{
+		Type:        EventRejected,
+		BlockNumber: blockNumber,
+		BlockHash:   blockHash,
+	})
+}
+
+// Publish queues the traces of an already accepted block, bypassing the
+// buffer of undecided blocks. It is used to backfill traces of historical
+// blocks and waits while the sink is far behind, until [ctx] is done.
+func Publish(ctx context.Context, header *types.Header, tracer string, traceResult []byte, atomicTxs []byte) error {
+	c := current()
+	if c == nil {
+		return ErrNotStarted
+	}
+	for c.q.len() >= maxPublishBacklog {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return ErrNotStarted
+		case <-time.After(minRetryBackoff):
+		}
+	}
+	return c.q.push(newTrace(header, tracer, traceResult, atomicTxs))
+}
+
+// numPending returns the number of blocks waiting for a decision
+func numPending() int {
+	pending.Lock()
+	defer pending.Unlock()
+	return len(pending.traces)
+}
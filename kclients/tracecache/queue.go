@@ -0,0 +1,144 @@
+package tracecache
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// queue is a write-ahead buffer of the traces waiting for the sink. Every
+// trace is persisted under an increasing sequence number before it is sent
+// and deleted only once the sink acknowledged it, so traces which could not
+// be delivered before a shutdown are replayed on the next start.
+type queue struct {
+	db ethdb.KeyValueStore
+
+	lock sync.Mutex
+	head uint64 // sequence number of the oldest queued trace
+	tail uint64 // sequence number of the next queued trace
+	// progress is the last time the queue became non-empty or a trace was
+	// delivered, so the oldest queued trace waits since at most then.
+	progress time.Time
+
+	// notify is signalled whenever a trace is pushed
+	notify chan struct{}
+}
+
+// newQueue opens the queue persisted in [db]. [db] must not be shared with
+// other data, eg. it is a table of the node database.
+func newQueue(db ethdb.KeyValueStore) (*queue, error) {
+	q := &queue{
+		db:       db,
+		progress: time.Now(),
+		notify:   make(chan struct{}, 1),
+	}
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	first := true
+	for it.Next() {
+		if len(it.Key()) != 8 {
+			log.Warn("Unexpected key in the trace queue", "key", it.Key())
+			continue
+		}
+		seq := binary.BigEndian.Uint64(it.Key())
+		if first {
+			q.head, first = seq, false
+		}
+		q.tail = seq + 1
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	n := q.len()
+	queueDepthGauge.Update(int64(n))
+	if n > 0 {
+		log.Info("Replaying undelivered traces", "count", n)
+	}
+	return q, nil
+}
+
+func queueKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
+
+// recordCodec encodes the queued traces
+var recordCodec = Codec{Encoding: EncodingBinary}
+
+// push persists [t] at the end of the queue
+func (q *queue) push(t Trace) error {
+	record, err := recordCodec.Encode(t)
+	if err != nil {
+		return err
+	}
+	q.lock.Lock()
+	err = q.db.Put(queueKey(q.tail), record)
+	if err == nil {
+		if q.head == q.tail {
+			q.progress = time.Now()
+		}
+		q.tail++
+		queueDepthGauge.Update(int64(q.tail - q.head))
+	}
+	q.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// peek returns the oldest queued trace, or false if the queue is empty.
+func (q *queue) peek() (Trace, bool, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.head == q.tail {
+		return Trace{}, false, nil
+	}
+	record, err := q.db.Get(queueKey(q.head))
+	if err != nil {
+		return Trace{}, true, err
+	}
+	t, err := Decode(record)
+	return t, true, err
+}
+
+// pop deletes the oldest queued trace
+func (q *queue) pop() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.head == q.tail {
+		return nil
+	}
+	if err := q.db.Delete(queueKey(q.head)); err != nil {
+		return err
+	}
+	q.head++
+	q.progress = time.Now()
+	queueDepthGauge.Update(int64(q.tail - q.head))
+	return nil
+}
+
+// len returns the number of queued traces
+func (q *queue) len() uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.tail - q.head
+}
+
+// stalled returns how long the oldest queued trace has waited without any
+// trace being delivered, or zero if the queue is empty.
+func (q *queue) stalled() time.Duration {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.head == q.tail {
+		return 0
+	}
+	return time.Since(q.progress)
+}
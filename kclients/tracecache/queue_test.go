@@ -0,0 +1,108 @@
+package tracecache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ava-labs/libevm/ethdb/memorydb"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySink fails the first [failures] sends and then delivers to [C]
+type flakySink struct {
+	*ChanSink
+
+	lock     sync.Mutex
+	failures int
+}
+
+func (s *flakySink) Send(ctx context.Context, t Trace) error {
+	s.lock.Lock()
+	if s.failures > 0 {
+		s.failures--
+		s.lock.Unlock()
+		return errors.New("sink unavailable")
+	}
+	s.lock.Unlock()
+	return s.ChanSink.Send(ctx, t)
+}
+
+func TestQueueRetriesFailedSends(t *testing.T) {
+	sink := &flakySink{ChanSink: NewChanSink(4), failures: 3}
+	require.NoError(t, StartWithSink(context.Background(), sink, nil))
+	defer Stop()
+
+	header := testHeader(1, 0)
+	hash := header.Hash()
+	Buffer(header, "", []byte(`{}`), nil)
+	Accept(hash, 1)
+
+	got := <-sink.C
+	require.Equal(t, hash, got.BlockHash)
+	require.Zero(t, sink.failures)
+	require.Zero(t, current().q.len())
+}
+
+func TestQueueReplaysUndeliveredTraces(t *testing.T) {
+	db := memorydb.New()
+
+	// The sink is down for the whole first run, traces stay in the queue
+	down := &flakySink{ChanSink: NewChanSink(4), failures: 1 << 30}
+	require.NoError(t, StartWithSink(context.Background(), down, db))
+	for i := int64(1); i <= 3; i++ {
+		header := testHeader(i, 0)
+		hash := header.Hash()
+		Buffer(header, "", []byte(`{}`), nil)
+		Accept(hash, i)
+	}
+	Stop()
+	require.Empty(t, down.C)
+
+	sink := NewChanSink(4)
+	require.NoError(t, StartWithSink(context.Background(), sink, db))
+	header := testHeader(4, 0)
+	hash := header.Hash()
+	Buffer(header, "", []byte(`{}`), nil)
+	Accept(hash, 4)
+	Stop()
+
+	var heights []int64
+	for tr := range sink.C {
+		heights = append(heights, tr.BlockNumber)
+	}
+	require.Equal(t, []int64{1, 2, 3, 4}, heights)
+
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+	require.False(t, it.Next(), "delivered traces must be deleted")
+}
+
+func TestHealthReportsStalledSink(t *testing.T) {
+	details, err := Health()
+	require.NoError(t, err)
+	require.Equal(t, false, details["started"])
+
+	down := &flakySink{ChanSink: NewChanSink(4), failures: 1 << 30}
+	require.NoError(t, StartWithSink(context.Background(), down, nil))
+	defer Stop()
+
+	_, err = Health()
+	require.NoError(t, err)
+
+	header := testHeader(1, 0)
+	hash := header.Hash()
+	Buffer(header, "", []byte(`{}`), nil)
+	Accept(hash, 1)
+	_, err = Health()
+	require.NoError(t, err)
+
+	c := current()
+	c.q.lock.Lock()
+	c.q.progress = c.q.progress.Add(-2 * maxSendStall)
+	c.q.lock.Unlock()
+	details, err = Health()
+	require.Error(t, err)
+	require.Equal(t, uint64(1), details["queueDepth"])
+}
@@ -0,0 +1,176 @@
+package tracecache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/go-redis/redis/v8"
+)
+
+var _ TraceSink = (*redisCache)(nil)
+
+// redisCache publishes the traces of accepted blocks into a single redis hash
+// keyed by block number. Rejected blocks are recorded in the hash
+// "<key>:rejected", keyed by block hash with the block number as value.
+// Traces are deleted once acknowledged by the consumers, see retention.
+type redisCache struct {
+	rdb      *redis.Client
+	key      string // redis中的key
+	endpoint string
+	db       int
+
+	retention *retention
+	loaded    bool // whether retention knows the traces already in redis
+
+	codec Codec
+}
+
+func newRedisCache(endpoint string, db int, key string, groups []string, maxBlocks, maxBytes int64, codec Codec) *redisCache {
+	rdb := redis.NewClient(&redis.Options{
+		Addr: endpoint,
+		DB:   db,
+	})
+	return &redisCache{
+		rdb:       rdb,
+		key:       key,
+		endpoint:  endpoint,
+		db:        db,
+		retention: newRetention(groups, maxBlocks, maxBytes),
+		codec:     codec,
+	}
+}
+
+func (c *redisCache) Send(ctx context.Context, t Trace) (err error) {
+	defer func() {
+		if err != nil {
+			redisErrorsCounter.Inc(1)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	if t.Type == EventRejected {
+		return c.rdb.HSet(
+			ctx,
+			c.key+":rejected",
+			t.BlockHash.Hex(),
+			t.BlockNumber,
+		).Err()
+	}
+	envelope, err := c.codec.Encode(t)
+	if err != nil {
+		return err
+	}
+	if err := c.load(ctx); err != nil {
+		return err
+	}
+	if err := c.prune(ctx); err != nil {
+		return err
+	}
+	if err := c.retention.check(t.BlockNumber, int64(len(envelope))); err != nil {
+		return err
+	}
+	err = c.rdb.HSet(
+		ctx,
+		c.key,
+		t.BlockNumber,
+		envelope,
+	).Err()
+	if err != nil {
+		return err
+	}
+	c.retention.add(t.BlockNumber, int64(len(envelope)))
+	return nil
+}
+
+// load reads the sizes of the traces left in redis by the last run
+func (c *redisCache) load(ctx context.Context) error {
+	if c.loaded {
+		return nil
+	}
+	fields, err := c.rdb.HKeys(ctx, c.key).Result()
+	if err != nil {
+		return err
+	}
+	pipe := c.rdb.Pipeline()
+	sizes := make(map[int64]*redis.Cmd, len(fields))
+	for _, field := range fields {
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			log.Warn("Invalid retained trace field", "key", c.key, "field", field)
+			continue
+		}
+		sizes[n] = pipe.Do(ctx, "HSTRLEN", c.key, field)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return err
+	}
+	for n, cmd := range sizes {
+		size, err := cmd.Int64()
+		if err != nil {
+			return err
+		}
+		c.retention.add(n, size)
+	}
+	c.loaded = true
+	log.Info("Loaded retained traces", "key", c.key, "blocks", len(c.retention.sizes), "bytes", c.retention.bytes)
+	return nil
+}
+
+// Prune deletes the traces acknowledged by every consumer group now instead
+// of on the next Send
+func (c *redisCache) Prune(ctx context.Context) error {
+	if err := c.load(ctx); err != nil {
+		return err
+	}
+	return c.prune(ctx)
+}
+
+// prune deletes the traces acknowledged by every consumer group
+func (c *redisCache) prune(ctx context.Context) error {
+	acks, err := c.rdb.HGetAll(ctx, c.key+":acks").Result()
+	if err != nil {
+		return err
+	}
+	committed, ok, err := c.retention.committed(acks)
+	if err != nil || !ok {
+		return err
+	}
+	committedGauge.Update(committed)
+	c.retention.observe(committed)
+	numbers := c.retention.acknowledged()
+	if len(numbers) == 0 {
+		return nil
+	}
+	fields := make([]string, len(numbers))
+	for i, n := range numbers {
+		fields[i] = strconv.FormatInt(n, 10)
+	}
+	if err := c.rdb.HDel(ctx, c.key, fields...).Err(); err != nil {
+		return err
+	}
+	c.retention.remove(numbers)
+
+	rejected, err := c.rdb.HGetAll(ctx, c.key+":rejected").Result()
+	if err != nil {
+		return err
+	}
+	var hashes []string
+	for hash, v := range rejected {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n <= committed {
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) > 0 {
+		if err := c.rdb.HDel(ctx, c.key+":rejected", hashes...).Err(); err != nil {
+			return err
+		}
+	}
+	log.Debug("Pruned retained traces", "key", c.key, "traces", len(numbers), "rejections", len(hashes), "committed", committed)
+	return nil
+}
+
+func (c *redisCache) Close() error {
+	return c.rdb.Close()
+}
@@ -0,0 +1,146 @@
+package tracecache
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ErrRetentionFull is returned by the redis sink while publishing a trace
+// would exceed the retention limits. The trace stays queued and is retried
+// once the consumers acknowledged older traces.
+var ErrRetentionFull = errors.New("trace retention limit reached")
+
+// retention decides which traces held in redis may be deleted. Consumers
+// acknowledge traces per consumer group by setting the field of their group
+// in the hash "<key>:acks" to the highest block number they processed, eg.
+//
+//	HSET <key>:acks indexer 1234
+//
+// A trace is deleted once every consumer group acknowledged it. Since traces
+// are backfilled below the acknowledged height, a trace only counts as
+// acknowledged by an acknowledgement which changed after it was published.
+// Traces are never deleted to make room: once the limits are reached
+// publishing waits for the consumers instead.
+type retention struct {
+	// groups must all acknowledge a trace before it is deleted. If empty,
+	// every group found in the acks hash must.
+	groups    []string
+	maxBlocks int64 // unlimited if zero
+	maxBytes  int64 // unlimited if zero
+
+	sizes map[int64]int64 // size of every retained envelope by block number
+	bytes int64           // total size of the retained envelopes
+
+	// every retained envelope is numbered in publishing order
+	seq  uint64
+	seqs map[int64]uint64
+
+	ackHeight int64  // last height acknowledged by every consumer group
+	observed  bool   // whether ackHeight was observed
+	checked   uint64 // seq when ackHeight was last observed
+	acked     uint64 // envelopes up to acked were published before ackHeight changed
+}
+
+func newRetention(groups []string, maxBlocks, maxBytes int64) *retention {
+	return &retention{
+		groups:    groups,
+		maxBlocks: maxBlocks,
+		maxBytes:  maxBytes,
+		sizes:     make(map[int64]int64),
+		seqs:      make(map[int64]uint64),
+	}
+}
+
+// committed returns the highest block number acknowledged by every consumer
+// group in [acks], which maps groups to block numbers. It returns false if a
+// required group has not acknowledged any trace yet.
+func (r *retention) committed(acks map[string]string) (int64, bool, error) {
+	groups := r.groups
+	if len(groups) == 0 {
+		for group := range acks {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 {
+		return 0, false, nil
+	}
+	committed := int64(math.MaxInt64)
+	for _, group := range groups {
+		v, ok := acks[group]
+		if !ok {
+			return 0, false, nil
+		}
+		height, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid acknowledgement %q of consumer group %q", v, group)
+		}
+		committed = min(committed, height)
+	}
+	return committed, true, nil
+}
+
+// add records the envelope of [blockNumber] of [size] bytes, replacing the
+// previous one if any.
+func (r *retention) add(blockNumber int64, size int64) {
+	r.bytes += size - r.sizes[blockNumber]
+	r.sizes[blockNumber] = size
+	r.seq++
+	r.seqs[blockNumber] = r.seq
+	retainedBlocksGauge.Update(int64(len(r.sizes)))
+	retainedBytesGauge.Update(r.bytes)
+}
+
+// remove forgets the envelopes of [blockNumbers]
+func (r *retention) remove(blockNumbers []int64) {
+	for _, n := range blockNumbers {
+		r.bytes -= r.sizes[n]
+		delete(r.sizes, n)
+		delete(r.seqs, n)
+	}
+	retainedBlocksGauge.Update(int64(len(r.sizes)))
+	retainedBytesGauge.Update(r.bytes)
+}
+
+// observe records [committed] as read from the acks hash. If it changed
+// since the last observation, the consumers acknowledged it after every
+// envelope published before the last observation.
+func (r *retention) observe(committed int64) {
+	if r.observed && committed != r.ackHeight {
+		r.acked = r.checked
+	}
+	r.ackHeight, r.observed = committed, true
+	r.checked = r.seq
+}
+
+// acknowledged returns the retained block numbers at or below the committed
+// height which were published before the consumers acknowledged it.
+func (r *retention) acknowledged() []int64 {
+	var numbers []int64
+	for n := range r.sizes {
+		if n <= r.ackHeight && r.seqs[n] <= r.acked {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// check returns ErrRetentionFull if the envelope of [blockNumber] of [size]
+// bytes does not fit into the limits.
+func (r *retention) check(blockNumber int64, size int64) error {
+	prev, replaced := r.sizes[blockNumber]
+	blocks := int64(len(r.sizes))
+	if !replaced {
+		blocks++
+	}
+	if r.maxBlocks > 0 && blocks > r.maxBlocks {
+		return fmt.Errorf("%w: %d blocks retained", ErrRetentionFull, len(r.sizes))
+	}
+	// a single envelope larger than the limit is let through once nothing
+	// else is retained, it would block the queue forever otherwise
+	if bytes := r.bytes - prev + size; r.maxBytes > 0 && bytes > r.maxBytes && len(r.sizes) > 0 {
+		return fmt.Errorf("%w: %d bytes retained", ErrRetentionFull, r.bytes)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package tracecache
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionCommitted(t *testing.T) {
+	tests := []struct {
+		name      string
+		groups    []string
+		acks      map[string]string
+		committed int64
+		ok        bool
+		err       bool
+	}{
+		{name: "no acknowledgements"},
+		{name: "any group", acks: map[string]string{"a": "7", "b": "5"}, committed: 5, ok: true},
+		{name: "required groups", groups: []string{"a"}, acks: map[string]string{"a": "7", "b": "5"}, committed: 7, ok: true},
+		{name: "missing group", groups: []string{"a", "c"}, acks: map[string]string{"a": "7"}},
+		{name: "invalid acknowledgement", acks: map[string]string{"a": "x"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRetention(tt.groups, 0, 0)
+			committed, ok, err := r.committed(tt.acks)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.committed, committed)
+		})
+	}
+}
+
+func TestRetentionLimits(t *testing.T) {
+	r := newRetention(nil, 3, 100)
+	require.NoError(t, r.check(1, 200), "a single oversized trace must pass")
+	r.add(1, 200)
+	require.ErrorIs(t, r.check(2, 10), ErrRetentionFull)
+
+	r.remove([]int64{1})
+	require.Zero(t, r.bytes)
+	for n := int64(2); n <= 4; n++ {
+		require.NoError(t, r.check(n, 10))
+		r.add(n, 10)
+	}
+	require.ErrorIs(t, r.check(5, 10), ErrRetentionFull)
+	require.NoError(t, r.check(4, 70), "a replaced trace must not count twice")
+	r.add(4, 70)
+	require.Equal(t, int64(90), r.bytes)
+	require.ErrorIs(t, r.check(4, 90), ErrRetentionFull)
+
+	r.remove([]int64{2, 3})
+	require.Equal(t, int64(70), r.bytes)
+	require.NoError(t, r.check(5, 10))
+}
+
+func TestRetentionBackfill(t *testing.T) {
+	acknowledged := func(r *retention) []int64 {
+		numbers := r.acknowledged()
+		sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+		return numbers
+	}
+	r := newRetention(nil, 0, 0)
+	r.add(5, 10)
+	r.add(6, 10)
+	r.observe(4)
+	require.Empty(t, acknowledged(r))
+	r.observe(6)
+	require.Equal(t, []int64{5, 6}, acknowledged(r))
+	r.remove(acknowledged(r))
+
+	// a backfill below the committed height is kept until the consumers
+	// acknowledge again
+	r.add(2, 10)
+	r.observe(6)
+	require.Empty(t, acknowledged(r))
+	r.add(3, 10)
+	r.observe(7)
+	require.Equal(t, []int64{2}, acknowledged(r))
+	r.remove(acknowledged(r))
+	r.add(3, 10) // republished
+	r.observe(8)
+	require.Empty(t, acknowledged(r))
+	r.observe(9)
+	require.Equal(t, []int64{3}, acknowledged(r))
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	c := newRedisCache("127.0.0.1:1", 0, "key", nil, 10, 0, Codec{})
+	defer c.Close()
+	require.Error(t, c.Send(context.Background(), Trace{BlockNumber: 1, Result: []byte(`[]`)}))
+	require.False(t, c.loaded)
+	require.Empty(t, c.retention.sizes)
+}
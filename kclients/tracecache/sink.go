@@ -0,0 +1,64 @@
+package tracecache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ava-labs/libevm/common"
+)
+
+// EventType tells consumers how a block was decided by consensus.
+type EventType uint8
+
+const (
+	// EventAccepted carries the traces of a block accepted by consensus.
+	EventAccepted EventType = iota
+	// EventRejected notifies that a verified block was rejected. It carries
+	// no traces.
+	EventRejected
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventAccepted:
+		return "accepted"
+	case EventRejected:
+		return "rejected"
+	default:
+		return "unknown"
+	}
+}
+
+// Trace is a single unit of work published by the trace cache: the encoded
+// trace results of all transactions in one block, or the notification that
+// the block was rejected. Sinks publish it encoded by a Codec.
+type Trace struct {
+	Type        EventType
+	BlockNumber int64
+	BlockHash   common.Hash
+	ParentHash  common.Hash // lets consumers check the continuity of the chain
+	Timestamp   uint64
+	Tracer      string // name of the tracer producing Result
+	Result      []byte
+	StateDiff   []byte // JSON encoded state changes of the block, if exported
+	AtomicTxs   []byte // JSON encoded atomic txs of the block, if any
+}
+
+// TraceSink is the destination of live traces. Implementations must be safe
+// to call from the single cache loop goroutine; they are not required to be
+// safe for concurrent use.
+type TraceSink interface {
+	// Send publishes a trace. A non-nil error means the trace was not
+	// delivered.
+	Send(ctx context.Context, t Trace) error
+	// Close releases any resources held by the sink. No Send may follow.
+	Close() error
+}
+
+// supported values of Config.Sink
+const (
+	SinkRedis = "redis"
+	SinkFile  = "file"
+)
+
+var ErrUnknownSink = errors.New("unknown trace sink")
@@ -0,0 +1,227 @@
+package tracecache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanSinkPipeline(t *testing.T) {
+	sink := NewChanSink(4)
+	require.NoError(t, StartWithSink(context.Background(), sink, nil))
+	defer Stop()
+
+	var (
+		header1  = testHeader(1, 0)
+		header2a = testHeader(2, 'a')
+		header2b = testHeader(2, 'b')
+		hash1    = header1.Hash()
+		hash2a   = header2a.Hash()
+		hash2b   = header2b.Hash()
+	)
+	// the state diff is buffered while the block is inserted, before the
+	// traces are collected
+	BufferStateDiff(header1, []byte(`{"accounts":[]}`))
+	Buffer(header1, "callTracer", []byte(`[{"txHash":"0x01"}]`), nil)
+	Buffer(header2a, "callTracer", []byte(`[{"txHash":"0x2a"}]`), nil)
+	Buffer(header2b, "callTracer", []byte(`[{"txHash":"0x2b"}]`), nil)
+	require.Equal(t, 3, numPending())
+	require.Empty(t, sink.C, "traces must not be published before accept")
+
+	Accept(hash1, 1)
+	Reject(hash2a, 2)
+	Accept(hash2b, 2)
+	require.Zero(t, numPending())
+
+	got := <-sink.C
+	require.Equal(t, EventAccepted, got.Type)
+	require.Equal(t, int64(1), got.BlockNumber)
+	require.Equal(t, hash1, got.BlockHash)
+	require.Equal(t, header1.ParentHash, got.ParentHash)
+	require.Equal(t, header1.Time, got.Timestamp)
+	require.Equal(t, "callTracer", got.Tracer)
+	require.JSONEq(t, `[{"txHash":"0x01"}]`, string(got.Result))
+	require.JSONEq(t, `{"accounts":[]}`, string(got.StateDiff))
+
+	got = <-sink.C
+	require.Equal(t, EventRejected, got.Type)
+	require.Equal(t, hash2a, got.BlockHash)
+	require.Empty(t, got.Result)
+
+	got = <-sink.C
+	require.Equal(t, EventAccepted, got.Type)
+	require.Equal(t, hash2b, got.BlockHash)
+	require.JSONEq(t, `[{"txHash":"0x2b"}]`, string(got.Result))
+	require.Empty(t, got.StateDiff)
+}
+
+func TestStopFlushesQueue(t *testing.T) {
+	sink := NewChanSink(8)
+	require.NoError(t, StartWithSink(context.Background(), sink, nil))
+	for i := int64(1); i <= 5; i++ {
+		header := testHeader(i, 0)
+		hash := header.Hash()
+		Buffer(header, "", []byte(`{}`), nil)
+		Accept(hash, i)
+	}
+	Stop()
+	require.False(t, Started())
+
+	var heights []int64
+	for tr := range sink.C {
+		heights = append(heights, tr.BlockNumber)
+	}
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, heights)
+}
+
+func TestFileSinkJSONLRotation(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, FormatJSONL, 600, Codec{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	payload := []byte(`{"calls":[1,2,3]}`)
+	for i := int64(10); i < 14; i++ {
+		require.NoError(t, sink.Send(ctx, Trace{BlockNumber: i, Result: payload}))
+	}
+	require.NoError(t, sink.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "traces-*.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "traces-00000000000000000010.jsonl", filepath.Base(files[0]))
+	require.Equal(t, "traces-00000000000000000012.jsonl", filepath.Base(files[1]))
+
+	f, err := os.Open(files[1])
+	require.NoError(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var lines []jsonEnvelope
+	for scanner.Scan() {
+		var line jsonEnvelope
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	require.Equal(t, uint8(EnvelopeVersion), lines[0].Version)
+	require.Equal(t, "accepted", lines[0].Type)
+	require.Equal(t, int64(12), lines[0].BlockNumber)
+	require.JSONEq(t, string(payload), string(lines[0].Result))
+}
+
+func TestFileSinkLengthPrefixed(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, FormatLengthPrefixed, 0, Codec{Encoding: EncodingBinary, Compression: CompressionZstd})
+	require.NoError(t, err)
+	want := []Trace{
+		{BlockNumber: 7, BlockHash: common.Hash{7}, ParentHash: common.Hash{6}, Tracer: "callTracer", Result: []byte("[]")},
+		{Type: EventRejected, BlockNumber: 8, BlockHash: common.Hash{8}, ParentHash: common.Hash{7}},
+	}
+	for _, tr := range want {
+		require.NoError(t, sink.Send(context.Background(), tr))
+	}
+	require.NoError(t, sink.Close())
+
+	b, err := os.ReadFile(filepath.Join(dir, "traces-00000000000000000007.lp"))
+	require.NoError(t, err)
+	var got []Trace
+	for len(b) > 0 {
+		require.GreaterOrEqual(t, len(b), lpHeaderSize)
+		size := int(binary.BigEndian.Uint32(b[:lpHeaderSize]))
+		tr, err := Decode(b[lpHeaderSize : lpHeaderSize+size])
+		require.NoError(t, err)
+		got = append(got, tr)
+		b = b[lpHeaderSize+size:]
+	}
+	require.Equal(t, want, got)
+}
+
+func TestNewFileSinkInvalid(t *testing.T) {
+	_, err := NewFileSink("", FormatJSONL, 0, Codec{})
+	require.ErrorIs(t, err, ErrInvalidFileDir)
+	_, err = NewFileSink(t.TempDir(), "xml", 0, Codec{})
+	require.ErrorIs(t, err, ErrInvalidFileFormat)
+	_, err = NewFileSink(t.TempDir(), FormatLengthPrefixed, 0, Codec{Compression: "lz4"})
+	require.ErrorIs(t, err, ErrInvalidCompression)
+}
+
+// testHeader returns a header at [number], told apart from other headers at
+// the same height by [fork]
+func testHeader(number int64, fork byte) *types.Header {
+	return &types.Header{
+		ParentHash: common.Hash{byte(number - 1)},
+		Number:     big.NewInt(number),
+		Time:       uint64(number),
+		Extra:      []byte{fork},
+	}
+}
+
+// failingFile writes the first [n] bytes of the next write and fails it
+type failingFile struct {
+	traceFile
+	n int
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	n, err := f.traceFile.Write(b[:f.n])
+	if err != nil {
+		return n, err
+	}
+	return n, errors.New("disk full")
+}
+
+func TestFileSinkFailedWrite(t *testing.T) {
+	for _, format := range []string{FormatJSONL, FormatLengthPrefixed} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			sink, err := NewFileSink(dir, format, 0, Codec{})
+			require.NoError(t, err)
+			fs := sink.(*fileSink)
+
+			ctx := context.Background()
+			want := []Trace{
+				{BlockNumber: 7, BlockHash: common.Hash{7}, Result: []byte(`[]`)},
+				{BlockNumber: 8, BlockHash: common.Hash{8}, Result: []byte(`[{"calls":[]}]`)},
+			}
+			require.NoError(t, sink.Send(ctx, want[0]))
+			f := fs.f
+			fs.f = &failingFile{traceFile: f, n: 10}
+			require.Error(t, sink.Send(ctx, want[1]))
+			// the queue retries the trace once the disk recovers
+			fs.f = f
+			require.NoError(t, sink.Send(ctx, want[1]))
+			require.NoError(t, sink.Close())
+
+			b, err := os.ReadFile(filepath.Join(dir, "traces-00000000000000000007."+format))
+			require.NoError(t, err)
+			var got []Trace
+			for len(b) > 0 {
+				var record []byte
+				if format == FormatJSONL {
+					i := bytes.IndexByte(b, '\n')
+					require.Positive(t, i)
+					record, b = b[:i], b[i+1:]
+				} else {
+					require.GreaterOrEqual(t, len(b), lpHeaderSize)
+					size := int(binary.BigEndian.Uint32(b[:lpHeaderSize]))
+					record, b = b[lpHeaderSize:lpHeaderSize+size], b[lpHeaderSize+size:]
+				}
+				tr, err := Decode(record)
+				require.NoError(t, err)
+				got = append(got, tr)
+			}
+			require.Equal(t, want, got)
+		})
+	}
+}
@@ -4,180 +4,331 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/ava-labs/coreth/kclients/syncstatus"
+	"sync"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-
-	"github.com/ava-labs/coreth/kclients/util/env"
-	"github.com/ava-labs/coreth/kclients/util/sig"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/log"
 )
 
-type kv struct {
-	blockNumber int64
-	traceResult []byte
-}
+// traceCache persists the traces produced on the consensus path into a
+// write-ahead queue and forwards them to a TraceSink from a single background
+// goroutine, retrying with backoff until the sink acknowledges them.
+type traceCache struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
 
-type redisCache struct {
-	ctx      context.Context
-	cancel   context.CancelFunc
-	rdb      *redis.Client
-	key      string // redis中的key
-	size     int64  // redis缓存的大小
-	endpoint string
-	db       int
+	// sinkLock is held while the sink is used, so that it is not replaced
+	// by SetSink during a send
+	sinkLock sync.Mutex
+	sink     TraceSink
+	config   Config // configuration of sink, zero if set by StartWithSink
 
-	cut    int64 // height cut last time
-	synced bool
+	q *queue
 
-	ch chan kv
+	lock          sync.Mutex
+	lastErr       error // outcome of the last send
+	lastPublished int64 // number of the last accepted block sent, -1 if none
 }
 
-var rc *redisCache
-var (
-	untilStart chan struct{}
-)
-
-func (c *redisCache) send(v kv) error {
-	ctx, cancel := context.WithTimeout(c.ctx, 60*time.Second)
-	defer cancel()
-	err := c.rdb.HSet(
-		ctx,
-		c.key,
-		v.blockNumber,
-		v.traceResult,
-	).Err()
-	if err != nil {
-		return err
-	}
+const (
+	minRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff = 30 * time.Second
 
-	// removed synced data in synced mode
-	if c.synced {
-		go c.Cut(syncstatus.RedisHeight())
-	}
+	// maxPublishBacklog is the number of queued traces above which Publish
+	// waits for the sink to catch up.
+	maxPublishBacklog = 1024
+)
 
-	if v.blockNumber > c.size {
-		err = c.rdb.HDel(
-			ctx,
-			c.key,
-			fmt.Sprint(v.blockNumber-c.size),
-		).Err()
-	}
-	return err
-}
+var (
+	tc      *traceCache
+	startMu sync.Mutex
+)
 
-func (c *redisCache) loop() {
+func (c *traceCache) loop() {
+	defer close(c.done)
+	backoff := minRetryBackoff
 	for {
+		t, ok, err := c.q.peek()
+		switch {
+		case errors.Is(err, ErrInvalidEnvelope):
+			// a record which cannot be decoded would block the queue forever
+			log.Error("Dropping unreadable trace", "err", err)
+			droppedCounter.Inc(1)
+			if err := c.q.pop(); err != nil {
+				log.Error("Failed to delete trace", "err", err)
+			}
+			continue
+		case err != nil:
+			log.Error("Failed to read trace, retrying", "backoff", backoff, "err", err)
+		case !ok:
+			select {
+			case <-c.ctx.Done():
+				log.Debug("Trace cache loop stopped")
+				return
+			case <-c.q.notify:
+			}
+			continue
+		default:
+			// in-flight traces are not aborted by Stop, which waits for them
+			err = c.send(t)
+			if err == nil {
+				backoff = minRetryBackoff
+				if err := c.q.pop(); err != nil {
+					log.Error("Failed to delete sent trace", "blockNumber", t.BlockNumber, "err", err)
+				}
+				continue
+			}
+			log.Error("Failed to send trace, retrying", "blockNumber", t.BlockNumber, "backoff", backoff, "err", err)
+		}
 		select {
 		case <-c.ctx.Done():
-			log.Info("### DEBUG ### rediscache loop exit", "remain", len(c.ch))
+			log.Debug("Trace cache loop stopped", "remaining", c.q.len())
 			return
-		case v := <-c.ch:
-			err := c.send(v)
-			if err != nil {
-				log.Error("### DEBUG ### [redisCache.loop]", "err", err)
-			}
+		case <-time.After(backoff):
 		}
+		backoff = min(2*backoff, maxRetryBackoff)
 	}
 }
 
+// send sends [t] to the sink, recording the outcome in the metrics
+func (c *traceCache) send(t Trace) error {
+	c.sinkLock.Lock()
+	start := time.Now()
+	err := c.sink.Send(context.Background(), t)
+	sendTimer.UpdateSince(start)
+	c.sinkLock.Unlock()
+	c.lock.Lock()
+	c.lastErr = err
+	if err == nil && t.Type == EventAccepted {
+		c.lastPublished = t.BlockNumber
+	}
+	c.lock.Unlock()
+	if err != nil {
+		sendErrorsCounter.Inc(1)
+		return err
+	}
+	sentCounter.Inc(1)
+	return nil
+}
+
+// current returns the running service, or nil if it is not started.
+func current() *traceCache {
+	startMu.Lock()
+	defer startMu.Unlock()
+	return tc
+}
+
 func Started() bool {
-	return rc != nil
+	return current() != nil
 }
 
-func Start(ctx context.Context) {
-	if Started() {
-		return
+// Config selects the sink of the live traces
+type Config struct {
+	// Sink is SinkRedis (default) or SinkFile
+	Sink string
+
+	// redis sink, disabled if Endpoint is empty
+	Endpoint string
+	DB       int
+	Key      string
+	// Groups are the consumer groups which must acknowledge a trace before
+	// it is deleted, every group acknowledging in redis if empty.
+	Groups []string
+	// Size is the number of blocks retained in the redis hash, and MaxBytes
+	// their total size (unlimited if zero), above which publishing waits for
+	// the consumers.
+	Size     int64
+	MaxBytes int64
+
+	// file sink
+	FileDir     string
+	FileFormat  string // FormatJSONL (default) or FormatLengthPrefixed
+	FileMaxSize int64  // 256 MiB if zero
+
+	// Encoding and Compression select the Codec of the published envelopes.
+	// The jsonl file format is always uncompressed JSON.
+	Encoding    string
+	Compression string
+}
+
+// Codec returns the codec of the published envelopes
+func (c Config) Codec() Codec {
+	return Codec{Encoding: c.Encoding, Compression: c.Compression}
+}
+
+// Enabled returns whether [c] configures a sink
+func (c Config) Enabled() bool {
+	return c.Sink == SinkFile || c.Endpoint != ""
+}
+
+// Validate returns an error if [c] configures an invalid sink
+func (c Config) Validate() error {
+	if err := c.Codec().Validate(); err != nil {
+		return err
 	}
-	untilStart = make(chan struct{})
-	cacheSize := env.LoadEnvInt64(env.EnvTraceCacheSize)
-	chanSize := env.LoadEnvInt(env.EnvTraceCacheChanSize)
-	endpoint := env.LoadEnvString(env.EnvTraceCacheEndpoint)
-	db := env.LoadEnvInt(env.EnvTraceCacheDB)
-	key := env.LoadEnvString(env.EnvTraceCacheKey)
-	synced := env.LoadEnvBool(env.EnvTraceCacheSynced)
+	switch c.Sink {
+	case "", SinkRedis:
+		switch {
+		case c.Endpoint == "":
+		case c.DB < 0:
+			return ErrInvalidRedisDB
+		case c.Key == "":
+			return ErrInvalidRedisCacheKey
+		case c.Size <= 0:
+			return fmt.Errorf("%w: %d", ErrInvalidRedisCacheSize, c.Size)
+		case c.MaxBytes < 0:
+			return fmt.Errorf("%w: max bytes %d", ErrInvalidRedisCacheSize, c.MaxBytes)
+		}
+	case SinkFile:
+		switch {
+		case c.FileDir == "":
+			return ErrInvalidFileDir
+		case c.FileFormat != "" && c.FileFormat != FormatJSONL && c.FileFormat != FormatLengthPrefixed:
+			return fmt.Errorf("%w: %q", ErrInvalidFileFormat, c.FileFormat)
+		case c.FileMaxSize < 0:
+			return fmt.Errorf("%w: %d", ErrInvalidFileMaxSize, c.FileMaxSize)
+		case (c.FileFormat == "" || c.FileFormat == FormatJSONL) && !c.Codec().plainJSON():
+			return fmt.Errorf("%w: the jsonl file format requires uncompressed json", ErrInvalidFileFormat)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownSink, c.Sink)
+	}
+	return nil
+}
 
-	if endpoint == "" {
-		return
+// Start starts the service publishing to the sink configured by [c],
+// queueing traces in [db]. Traces left in [db] by the last run are sent
+// first. The service stays disabled if no sink is configured.
+func Start(ctx context.Context, c Config, db ethdb.KeyValueStore) error {
+	if err := c.Validate(); err != nil {
+		return err
 	}
-	if cacheSize == env.WrongInt {
-		sig.Int(ErrInvalidRedisCacheSize.Error())
-		return
+	if !c.Enabled() {
+		log.Info("Trace cache service is not enabled")
+		return nil
 	}
-	if chanSize == env.WrongInt {
-		sig.Int(ErrInvalidRedisCacheChanSize.Error())
-		return
+	startMu.Lock()
+	defer startMu.Unlock()
+	if tc != nil {
+		return nil
 	}
-	if db == env.WrongInt {
-		sig.Int(ErrInvalidRedisDB.Error())
-		return
+	sink, err := newSink(c)
+	if err != nil {
+		return err
 	}
-	if key == "" {
-		sig.Int(ErrInvalidRedisCacheKey.Error())
-		return
+	if err := start(ctx, sink, db); err != nil {
+		return err
+	}
+	tc.config = c
+	return nil
+}
+
+// StartWithSink starts the service publishing to [sink] instead of the sink
+// configured in the environment. A nil [db] queues traces in memory. It is a
+// no-op if the service is running.
+func StartWithSink(ctx context.Context, sink TraceSink, db ethdb.KeyValueStore) error {
+	startMu.Lock()
+	defer startMu.Unlock()
+	if tc != nil {
+		return nil
+	}
+	return start(ctx, sink, db)
+}
+
+// start assumes startMu is held
+func start(ctx context.Context, sink TraceSink, db ethdb.KeyValueStore) error {
+	if db == nil {
+		db = memorydb.New()
+	}
+	q, err := newQueue(db)
+	if err != nil {
+		return fmt.Errorf("failed to open trace queue: %w", err)
 	}
-	rdb := redis.NewClient(&redis.Options{
-		Addr: endpoint,
-		DB:   db,
-	})
 	_ctx, cancel := context.WithCancel(ctx)
-	c := &redisCache{
-		ctx:      _ctx,
-		cancel:   cancel,
-		rdb:      rdb,
-		key:      key,
-		size:     cacheSize,
-		endpoint: endpoint,
-		db:       db,
+	c := &traceCache{
+		ctx:    _ctx,
+		cancel: cancel,
+		sink:   sink,
+		done:   make(chan struct{}),
 
-		synced: synced,
+		q: q,
 
-		ch: make(chan kv, chanSize),
+		lastPublished: -1,
 	}
-	close(untilStart)
-	c.Sweep(syncstatus.RedisHeight())
 	go c.loop()
-	rc = c
-	log.Info("### DEBUG ### redis cache service started")
+	tc = c
+	log.Info("Trace cache service started")
+	return nil
+}
+
+// newSink returns the sink configured by [c]
+func newSink(c Config) (TraceSink, error) {
+	if c.Sink == SinkFile {
+		return NewFileSink(c.FileDir, c.FileFormat, c.FileMaxSize, c.Codec())
+	}
+	return newRedisCache(c.Endpoint, c.DB, c.Key, c.Groups, c.Size, c.MaxBytes, c.Codec()), nil
 }
 
 func Stop() {
-	if !Started() {
+	startMu.Lock()
+	defer startMu.Unlock()
+	if tc == nil {
 		return
 	}
-	rc.cancel()
+	tc.cancel()
+	<-tc.done
 	flush()
+	tc.sinkLock.Lock()
+	defer tc.sinkLock.Unlock()
+	if err := tc.sink.Close(); err != nil {
+		log.Error("Failed to close trace sink", "err", err)
+	}
+	tc = nil
 }
 
-// flush 终止前将channel中的数据发送完
+// flush 终止前将队列中的数据发送完. Traces which cannot be sent stay in the
+// queue and are replayed on the next start.
 func flush() {
-	if !Started() {
-		return
-	}
-	log.Info("### DEBUG ### [tracecache.Flush]", "remain", len(rc.ch))
-	for len(rc.ch) > 0 {
-		err := rc.send(<-rc.ch)
+	log.Info("Flushing trace cache", "remaining", tc.q.len())
+	for {
+		t, ok, err := tc.q.peek()
+		if !ok {
+			return
+		}
+		if err == nil {
+			err = tc.send(t)
+		}
 		if err != nil {
-			log.Error("### DEBUG ### Stop rediscache loop", "err", err)
+			log.Error("Stopped trace cache loop, keeping undelivered traces", "remaining", tc.q.len(), "err", err)
+			return
+		}
+		if err := tc.q.pop(); err != nil {
+			log.Error("Stopped trace cache loop", "err", err)
+			return
 		}
 	}
 }
 
 var (
-	ErrInvalidRedisDB            = errors.New("invalid redis db")
-	ErrInvalidRedisCacheSize     = errors.New("invalid redis cache size")
-	ErrInvalidRedisCacheChanSize = errors.New("invalid redis cache channel size")
-	ErrInvalidRedisCacheKey      = errors.New("invalid redis cache key")
+	ErrInvalidRedisDB        = errors.New("invalid redis db")
+	ErrInvalidRedisCacheSize = errors.New("invalid redis cache size")
+	ErrInvalidRedisCacheKey  = errors.New("invalid redis cache key")
+	ErrInvalidFileMaxSize    = errors.New("invalid trace file max size")
+	ErrNotStarted            = errors.New("trace cache service is not started")
 )
 
-func Write(blockNumber int64, traceResult []byte) {
-	if !Started() {
-		log.Info("### DEBUG ### [rediscache.Write] write before service start", "blockNumber", blockNumber)
-		log.Info("### DEBUG ### [rediscache.Write] wait until start")
-		Start(context.Background())
-		<-untilStart
-		log.Info("### DEBUG ### [rediscache.Write] started")
+// write persists [t] to be sent to the sink
+func write(t Trace) {
+	c := current()
+	if c == nil {
+		log.Debug("Trace cache service not started, dropping trace", "blockNumber", t.BlockNumber)
+		return
+	}
+	if err := c.q.push(t); err != nil {
+		log.Error("Failed to queue trace, dropping it", "blockNumber", t.BlockNumber, "err", err)
+		droppedCounter.Inc(1)
 	}
-	rc.ch <- kv{blockNumber, traceResult}
 }
@@ -1,12 +0,0 @@
-package env
-
-import (
-	"fmt"
-	"strings"
-	"testing"
-)
-
-func TestName(t *testing.T) {
-	list := strings.Split("", ",")
-	fmt.Println(list, len(list))
-}
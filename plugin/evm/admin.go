@@ -8,7 +8,10 @@ import (
 	"net/http"
 
 	"github.com/ava-labs/avalanchego/api"
+	"github.com/ava-labs/avalanchego/utils/json"
 	"github.com/ava-labs/avalanchego/utils/profiler"
+	"github.com/ava-labs/coreth/kclients/pause"
+	"github.com/ava-labs/coreth/kclients/tracecache"
 	"github.com/ava-labs/coreth/plugin/evm/client"
 	"github.com/ava-labs/libevm/log"
 )
@@ -82,3 +85,108 @@ func (p *Admin) GetVMConfig(_ *http.Request, _ *struct{}, reply *client.ConfigRe
 	reply.Config = &p.vm.config
 	return nil
 }
+
+// StartTraceBackfill re-traces a range of accepted blocks with the live
+// tracers and publishes the results to the trace sink
+func (p *Admin) StartTraceBackfill(_ *http.Request, args *client.TraceBackfillArgs, _ *api.EmptyReply) error {
+	log.Info("Admin: StartTraceBackfill called", "start", args.Start, "end", args.End, "threads", args.Threads)
+
+	return p.vm.traceBackfiller.start(uint64(args.Start), uint64(args.End), args.Threads)
+}
+
+// StopTraceBackfill stops the running trace backfill, which is not resumed
+func (p *Admin) StopTraceBackfill(_ *http.Request, _ *struct{}, _ *api.EmptyReply) error {
+	log.Info("Admin: StopTraceBackfill called")
+
+	return p.vm.traceBackfiller.abort()
+}
+
+// GetTraceBackfillStatus returns the progress of the last trace backfill
+func (p *Admin) GetTraceBackfillStatus(_ *http.Request, _ *struct{}, reply *client.TraceBackfillStatus) error {
+	*reply = p.vm.traceBackfiller.status()
+	return nil
+}
+
+// SetConsumerHeight reports the height of the last block processed by the
+// consumer of the node to the push height source of the pause control
+func (p *Admin) SetConsumerHeight(_ *http.Request, args *client.ConsumerHeightArgs, _ *api.EmptyReply) error {
+	log.Debug("Admin: SetConsumerHeight called", "height", args.Height)
+
+	return pause.Push(int64(args.Height))
+}
+
+// GetETLStatus returns the state of the trace cache, the pause control and
+// the trace backfill
+func (p *Admin) GetETLStatus(_ *http.Request, _ *struct{}, reply *client.ETLStatus) error {
+	traceCache := tracecache.CurrentStatus()
+	pauseStatus := pause.CurrentStatus()
+	*reply = client.ETLStatus{
+		LastAccepted: json.Uint64(p.vm.blockChain.LastAcceptedBlock().NumberU64()),
+
+		TraceCacheStarted: traceCache.Started,
+		QueueSize:         json.Uint64(traceCache.QueueSize),
+		PendingBlocks:     traceCache.Pending,
+		LastPublished:     traceCache.LastPublished,
+		TraceCacheError:   traceCache.LastError,
+
+		PauseStarted:   pauseStatus.Started,
+		ConsumerHeight: pauseStatus.ConsumerHeight,
+		Paused:         pauseStatus.Paused,
+		Held:           pauseStatus.Held,
+		PauseError:     pauseStatus.LastError,
+
+		TraceBackfill: p.vm.traceBackfiller.status(),
+	}
+	return nil
+}
+
+// PauseBlockProcessing pauses block processing until ResumeBlockProcessing is
+// called. It requires the pause control to be enabled.
+func (p *Admin) PauseBlockProcessing(_ *http.Request, _ *struct{}, _ *api.EmptyReply) error {
+	log.Info("Admin: PauseBlockProcessing called")
+
+	return pause.Hold()
+}
+
+// ResumeBlockProcessing resumes block processing paused by
+// PauseBlockProcessing
+func (p *Admin) ResumeBlockProcessing(_ *http.Request, _ *struct{}, _ *api.EmptyReply) error {
+	log.Info("Admin: ResumeBlockProcessing called")
+
+	return pause.Release()
+}
+
+// PruneTraceCache deletes the traces acknowledged by the consumers from the
+// redis trace sink
+func (p *Admin) PruneTraceCache(r *http.Request, _ *struct{}, _ *api.EmptyReply) error {
+	log.Info("Admin: PruneTraceCache called")
+
+	return tracecache.Prune(r.Context())
+}
+
+// SetTraceSink replaces the sink of the live traces. Traces not yet delivered
+// are sent to the new sink. The change is lost on restart.
+func (p *Admin) SetTraceSink(_ *http.Request, args *client.TraceSinkArgs, _ *api.EmptyReply) error {
+	log.Info("Admin: SetTraceSink called", "sink", args.Sink, "endpoint", args.Endpoint, "key", args.Key, "fileDir", args.FileDir)
+
+	cfg, ok := tracecache.SinkConfig()
+	if !ok {
+		cfg = p.vm.config.TraceCacheConfig()
+	}
+	if args.Sink != "" {
+		cfg.Sink = args.Sink
+	}
+	if args.Endpoint != "" {
+		cfg.Endpoint = args.Endpoint
+	}
+	if args.DB != nil {
+		cfg.DB = *args.DB
+	}
+	if args.Key != "" {
+		cfg.Key = args.Key
+	}
+	if args.FileDir != "" {
+		cfg.FileDir = args.FileDir
+	}
+	return tracecache.SetSink(cfg)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/ava-labs/avalanchego/utils/json"
 	"github.com/ava-labs/avalanchego/utils/set"
 	"github.com/ava-labs/coreth/plugin/evm/atomic"
+	atomicstate "github.com/ava-labs/coreth/plugin/evm/atomic/state"
 	"github.com/ava-labs/coreth/plugin/evm/client"
 	"github.com/ava-labs/coreth/plugin/evm/upgrade/ap3"
 	"github.com/ava-labs/libevm/common"
@@ -29,12 +30,16 @@ const (
 
 	// Max number of addresses that can be passed in as argument to GetUTXOs
 	maxGetUTXOsAddrs = 1024
+
+	// Max number of txs returned by GetAtomicTxsByAddress
+	maxGetAtomicTxsByAddress = 1024
 )
 
 var (
 	errNoAddresses   = errors.New("no addresses provided")
 	errNoSourceChain = errors.New("no source chain provided")
 	errNilTxID       = errors.New("nil transaction ID")
+	errNoAddress     = errors.New("no address provided")
 
 	initialBaseFee = big.NewInt(ap3.InitialBaseFee)
 )
@@ -264,3 +269,62 @@ func (service *AvaxAPI) GetAtomicTx(r *http.Request, args *api.GetTxArgs, reply
 	}
 	return nil
 }
+
+// GetAtomicTxsByAddress returns the accepted atomic txs of an EVM address or
+// of a UTXO owner, by increasing height, from the address index
+func (service *AvaxAPI) GetAtomicTxsByAddress(r *http.Request, args *client.GetAtomicTxsByAddressArgs, reply *client.GetAtomicTxsByAddressReply) error {
+	log.Info("EVM: GetAtomicTxsByAddress called", "address", args.Address)
+
+	if args.Address == "" {
+		return errNoAddress
+	}
+	var addr atomicstate.AtomicTxAddress
+	if common.IsHexAddress(args.Address) {
+		addr = atomicstate.EVMAddress(common.HexToAddress(args.Address))
+	} else {
+		shortID, err := ids.ShortFromString(args.Address)
+		if err != nil {
+			if _, shortID, err = service.vm.ParseAddress(args.Address); err != nil {
+				return fmt.Errorf("couldn't parse address %q: %w", args.Address, err)
+			}
+		}
+		addr = atomicstate.ShortIDAddress(shortID)
+	}
+	limit := int(args.Limit)
+	if limit <= 0 || limit > maxGetAtomicTxsByAddress {
+		limit = maxGetAtomicTxsByAddress
+	}
+
+	service.vm.ctx.Lock.Lock()
+	defer service.vm.ctx.Lock.Unlock()
+
+	start := atomicstate.AddressIndexCursor{Height: uint64(args.StartIndex.Height), TxID: args.StartIndex.TxID}
+	txs, next, err := service.vm.atomicTxRepository.GetByAddress(addr, start, limit)
+	if err != nil {
+		return err
+	}
+	// Since chain state updates run asynchronously with VM block acceptance,
+	// the txs above the last accepted block are not returned yet.
+	lastAccepted := service.vm.blockChain.LastAcceptedBlock().NumberU64()
+	reply.Txs = make([]client.FormattedAtomicTx, 0, len(txs))
+	for _, tx := range txs {
+		if tx.Height > lastAccepted {
+			next = &atomicstate.AddressIndexCursor{Height: tx.Height, TxID: tx.Tx.ID()}
+			break
+		}
+		txBytes, err := formatting.Encode(args.Encoding, tx.Tx.SignedBytes())
+		if err != nil {
+			return err
+		}
+		reply.Txs = append(reply.Txs, client.FormattedAtomicTx{
+			TxID:        tx.Tx.ID(),
+			Tx:          txBytes,
+			BlockHeight: json.Uint64(tx.Height),
+		})
+	}
+	if next != nil {
+		reply.EndIndex = &client.AtomicTxIndex{Height: json.Uint64(next.Height), TxID: next.TxID}
+	}
+	reply.Encoding = args.Encoding
+	return nil
+}
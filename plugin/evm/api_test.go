@@ -0,0 +1,68 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade/upgradetest"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/plugin/evm/client"
+)
+
+func TestGetAtomicTxsByAddress(t *testing.T) {
+	require := require.New(t)
+
+	fork := upgradetest.ApricotPhase2
+	tvm := newVM(t, testVMConfig{
+		fork:       &fork,
+		configJSON: `{"atomic-tx-address-index-enabled": true}`,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: 50_000_000,
+		},
+	})
+	defer func() {
+		tvm.vm.ctx.Lock.Lock()
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+	ctx := context.Background()
+	service := &AvaxAPI{tvm.vm}
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[1], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(tvm.vm.mempool.AddLocalTx(importTx))
+	<-tvm.toEngine
+	blk, err := tvm.vm.BuildBlock(ctx)
+	require.NoError(err)
+	require.NoError(blk.Verify(ctx))
+	require.NoError(tvm.vm.SetPreference(ctx, blk.ID()))
+	require.NoError(blk.Accept(ctx))
+	tvm.vm.blockChain.DrainAcceptorQueue()
+	// The API takes the context lock itself
+	tvm.vm.ctx.Lock.Unlock()
+
+	// The import is found by the recipient and by the owner of the UTXO
+	for _, addr := range []string{testEthAddrs[1].Hex(), testShortIDAddrs[0].String()} {
+		reply := &client.GetAtomicTxsByAddressReply{}
+		require.NoError(service.GetAtomicTxsByAddress(nil, &client.GetAtomicTxsByAddressArgs{Address: addr, Encoding: formatting.Hex}, reply))
+		require.Len(reply.Txs, 1)
+		require.Nil(reply.EndIndex)
+		require.Equal(importTx.ID(), reply.Txs[0].TxID)
+		require.EqualValues(blk.Height(), reply.Txs[0].BlockHeight)
+		txBytes, err := formatting.Decode(formatting.Hex, reply.Txs[0].Tx)
+		require.NoError(err)
+		require.Equal(importTx.SignedBytes(), txBytes)
+	}
+
+	reply := &client.GetAtomicTxsByAddressReply{}
+	require.NoError(service.GetAtomicTxsByAddress(nil, &client.GetAtomicTxsByAddressArgs{Address: testEthAddrs[2].Hex(), Encoding: formatting.Hex}, reply))
+	require.Empty(reply.Txs)
+
+	require.ErrorIs(service.GetAtomicTxsByAddress(nil, &client.GetAtomicTxsByAddressArgs{}, reply), errNoAddress)
+}
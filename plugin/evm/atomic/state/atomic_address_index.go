@@ -0,0 +1,254 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/log"
+
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/database/prefixdb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/utils/hashing"
+	"github.com/ava-labs/avalanchego/utils/set"
+	"github.com/ava-labs/avalanchego/utils/wrappers"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/coreth/plugin/evm/atomic"
+)
+
+var (
+	atomicAddressIndexDBPrefix = []byte("atomicAddressIndexDB")
+
+	maxAddressIndexedHeightKey = []byte("maxAddressIndexedAtomicTxHeight")
+
+	errAddressIndexDisabled = errors.New("atomic tx address index is disabled")
+)
+
+// kinds of the addresses in the address index, as an EVM address and a short
+// ID may have the same bytes
+const (
+	evmAddressKind byte = iota
+	shortIDKind
+)
+
+// addressKeyLen is the length of the address part of the address index keys
+const addressKeyLen = 1 + common.AddressLength
+
+// AtomicTxAddress is an address in the address index: an EVM address funding
+// an export or funded by an import, or the short ID of an owner of the UTXOs
+// imported or exported.
+type AtomicTxAddress [addressKeyLen]byte
+
+// EVMAddress returns the AtomicTxAddress of [addr]
+func EVMAddress(addr common.Address) AtomicTxAddress {
+	var a AtomicTxAddress
+	a[0] = evmAddressKind
+	copy(a[1:], addr[:])
+	return a
+}
+
+// ShortIDAddress returns the AtomicTxAddress of the UTXO owner [addr]
+func ShortIDAddress(addr ids.ShortID) AtomicTxAddress {
+	var a AtomicTxAddress
+	a[0] = shortIDKind
+	copy(a[1:], addr[:])
+	return a
+}
+
+// IndexedAtomicTx is an accepted atomic tx with the height of its block
+type IndexedAtomicTx struct {
+	Tx     *atomic.Tx
+	Height uint64
+}
+
+// InitializeAddressIndex enables the address index, indexing the atomic txs
+// written from now on, and indexes the txs accepted up to
+// [lastAcceptedHeight] which are not indexed yet. The index resumes from the
+// last height indexed if it was disabled for a while.
+func (a *AtomicRepository) InitializeAddressIndex(lastAcceptedHeight uint64) error {
+	a.atomicAddressIndexDB = prefixdb.New(atomicAddressIndexDBPrefix, a.db)
+
+	var start uint64
+	switch heightBytes, err := a.atomicRepoMetadataDB.Get(maxAddressIndexedHeightKey); err {
+	case nil:
+		if len(heightBytes) != wrappers.LongLen {
+			return fmt.Errorf("found invalid value at max address indexed height: %v", heightBytes)
+		}
+		start = binary.BigEndian.Uint64(heightBytes) + 1
+	case database.ErrNotFound:
+		log.Info("Initializing atomic tx address index from scratch")
+	default:
+		return err
+	}
+	if start > lastAcceptedHeight {
+		return nil
+	}
+
+	startTime := time.Now()
+	lastLogTime := startTime
+	iter := a.IterateByHeight(start)
+	defer iter.Release()
+
+	indexedTxs := 0
+	pendingBytesApproximation := 0
+	for iter.Next() {
+		heightBytes := iter.Key()
+		if len(heightBytes) != wrappers.LongLen {
+			return fmt.Errorf("atomic tx height index key had invalid length (%d) != (%d)", len(heightBytes), wrappers.LongLen)
+		}
+		height := binary.BigEndian.Uint64(heightBytes)
+		if height > lastAcceptedHeight {
+			break
+		}
+		txs, err := atomic.ExtractAtomicTxsBatch(iter.Value(), a.codec)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			// A tx accepted in a bonus block is indexed at the height it
+			// was first accepted at
+			_, txHeight, err := a.GetByTxID(tx.ID())
+			if err != nil {
+				return err
+			}
+			if txHeight != height {
+				continue
+			}
+			if err := a.indexTxByAddress(heightBytes, tx); err != nil {
+				return err
+			}
+			indexedTxs++
+		}
+		pendingBytesApproximation += len(iter.Value())
+
+		if pendingBytesApproximation > repoCommitSizeCap {
+			if err := a.atomicRepoMetadataDB.Put(maxAddressIndexedHeightKey, heightBytes); err != nil {
+				return err
+			}
+			if err := a.db.Commit(); err != nil {
+				return err
+			}
+			pendingBytesApproximation = 0
+		}
+		if time.Since(lastLogTime) > 15*time.Second {
+			lastLogTime = time.Now()
+			log.Info("Atomic tx address index initialization", "indexedTxs", indexedTxs, "height", height)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("atomic tx height index iterator errored while initializing address index: %w", err)
+	}
+
+	indexedHeight := make([]byte, wrappers.LongLen)
+	binary.BigEndian.PutUint64(indexedHeight, lastAcceptedHeight)
+	if err := a.atomicRepoMetadataDB.Put(maxAddressIndexedHeightKey, indexedHeight); err != nil {
+		return err
+	}
+	log.Info("Completed atomic tx address index initialization", "indexedTxs", indexedTxs, "lastAcceptedHeight", lastAcceptedHeight, "duration", time.Since(startTime))
+	return a.db.Commit()
+}
+
+// AddressIndexCursor is the position of a tx in the address index
+type AddressIndexCursor struct {
+	Height uint64
+	TxID   ids.ID
+}
+
+// GetByAddress returns up to [limit] atomic txs of [addr], by increasing
+// height and then txID, starting from the tx at [start]. It also returns the
+// position of the next tx of [addr], or nil if there is none.
+func (a *AtomicRepository) GetByAddress(addr AtomicTxAddress, start AddressIndexCursor, limit int) ([]IndexedAtomicTx, *AddressIndexCursor, error) {
+	if a.atomicAddressIndexDB == nil {
+		return nil, nil, errAddressIndexDisabled
+	}
+	startKey := make([]byte, 0, addressKeyLen+wrappers.LongLen+ids.IDLen)
+	startKey = append(startKey, addr[:]...)
+	startKey = binary.BigEndian.AppendUint64(startKey, start.Height)
+	startKey = append(startKey, start.TxID[:]...)
+
+	iter := a.atomicAddressIndexDB.NewIteratorWithStartAndPrefix(startKey, addr[:])
+	defer iter.Release()
+
+	var txs []IndexedAtomicTx
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != addressKeyLen+wrappers.LongLen+ids.IDLen {
+			return nil, nil, fmt.Errorf("atomic tx address index key had invalid length (%d)", len(key))
+		}
+		height := binary.BigEndian.Uint64(key[addressKeyLen:])
+		txID, err := ids.ToID(key[addressKeyLen+wrappers.LongLen:])
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(txs) == limit {
+			return txs, &AddressIndexCursor{Height: height, TxID: txID}, nil
+		}
+		tx, _, err := a.GetByTxID(txID)
+		if err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, IndexedAtomicTx{Tx: tx, Height: height})
+	}
+	return txs, nil, iter.Error()
+}
+
+// indexTxByAddress adds [address]+[height]+[txID] to the
+// [atomicAddressIndexDB] for every address of [tx]
+func (a *AtomicRepository) indexTxByAddress(heightBytes []byte, tx *atomic.Tx) error {
+	txID := tx.ID()
+	for addr := range txAddresses(tx) {
+		key := make([]byte, 0, addressKeyLen+wrappers.LongLen+ids.IDLen)
+		key = append(key, addr[:]...)
+		key = append(key, heightBytes...)
+		key = append(key, txID[:]...)
+		if err := a.atomicAddressIndexDB.Put(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// txAddresses returns the addresses of [tx]: the EVM addresses of its inputs
+// and outputs on the C-chain, and the owners of the UTXOs it exports or
+// imports. The owners of imported UTXOs are not part of the tx, they are
+// recovered from the signatures of its credentials.
+func txAddresses(tx *atomic.Tx) set.Set[AtomicTxAddress] {
+	addrs := set.Set[AtomicTxAddress]{}
+	switch utx := tx.UnsignedAtomicTx.(type) {
+	case *atomic.UnsignedImportTx:
+		for _, out := range utx.Outs {
+			addrs.Add(EVMAddress(out.Address))
+		}
+		hash := hashing.ComputeHash256(utx.Bytes())
+		for _, cred := range tx.Creds {
+			cred, ok := cred.(*secp256k1fx.Credential)
+			if !ok {
+				continue
+			}
+			for _, sig := range cred.Sigs {
+				if pk, err := secp256k1.RecoverPublicKeyFromHash(hash, sig[:]); err == nil {
+					addrs.Add(ShortIDAddress(pk.Address()))
+				}
+			}
+		}
+	case *atomic.UnsignedExportTx:
+		for _, in := range utx.Ins {
+			addrs.Add(EVMAddress(in.Address))
+		}
+		for _, out := range utx.ExportedOutputs {
+			if out, ok := out.Out.(*secp256k1fx.TransferOutput); ok {
+				for _, addr := range out.Addrs {
+					addrs.Add(ShortIDAddress(addr))
+				}
+			}
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/ava-labs/avalanchego/database/memdb"
+	"github.com/ava-labs/avalanchego/database/versiondb"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/avalanchego/vms/components/avax"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/libevm/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/plugin/evm/atomic"
+)
+
+func newTestImportTx(t *testing.T, key *secp256k1.PrivateKey, to common.Address) *atomic.Tx {
+	assetID := ids.GenerateTestID()
+	tx := &atomic.Tx{UnsignedAtomicTx: &atomic.UnsignedImportTx{
+		SourceChain: ids.GenerateTestID(),
+		ImportedInputs: []*avax.TransferableInput{{
+			UTXOID: avax.UTXOID{TxID: ids.GenerateTestID()},
+			Asset:  avax.Asset{ID: assetID},
+			In:     &secp256k1fx.TransferInput{Amt: 1, Input: secp256k1fx.Input{SigIndices: []uint32{0}}},
+		}},
+		Outs: []atomic.EVMOutput{{Address: to, Amount: 1, AssetID: assetID}},
+	}}
+	require.NoError(t, tx.Sign(atomic.Codec, [][]*secp256k1.PrivateKey{{key}}))
+	return tx
+}
+
+func newTestExportTx(t *testing.T, key *secp256k1.PrivateKey, to ids.ShortID, nonce uint64) *atomic.Tx {
+	assetID := ids.GenerateTestID()
+	tx := &atomic.Tx{UnsignedAtomicTx: &atomic.UnsignedExportTx{
+		DestinationChain: ids.GenerateTestID(),
+		Ins:              []atomic.EVMInput{{Address: key.EthAddress(), Amount: 1, AssetID: assetID, Nonce: nonce}},
+		ExportedOutputs: []*avax.TransferableOutput{{
+			Asset: avax.Asset{ID: assetID},
+			Out:   &secp256k1fx.TransferOutput{Amt: 1, OutputOwners: secp256k1fx.OutputOwners{Threshold: 1, Addrs: []ids.ShortID{to}}},
+		}},
+	}}
+	require.NoError(t, tx.Sign(atomic.Codec, [][]*secp256k1.PrivateKey{{key}}))
+	return tx
+}
+
+func TestAtomicRepositoryAddressIndex(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		db        = versiondb.New(memdb.New())
+		xKey, _   = secp256k1.NewPrivateKey()
+		cKey, _   = secp256k1.NewPrivateKey()
+		xAddr     = xKey.Address()
+		cAddr     = cKey.EthAddress()
+		importTx  = newTestImportTx(t, xKey, cAddr)
+		exportTx  = newTestExportTx(t, cKey, xAddr, 0)
+		exportTx2 = newTestExportTx(t, cKey, xAddr, 1)
+	)
+	repo, err := NewAtomicTxRepository(db, atomic.Codec, 0)
+	require.NoError(err)
+	_, _, err = repo.GetByAddress(EVMAddress(cAddr), AddressIndexCursor{}, 10)
+	require.ErrorIs(err, errAddressIndexDisabled)
+
+	// The txs written before the index is enabled are indexed on startup,
+	// and the bonus block does not index the import again
+	require.NoError(repo.Write(1, []*atomic.Tx{importTx}))
+	require.NoError(repo.Write(2, []*atomic.Tx{exportTx}))
+	require.NoError(repo.WriteBonus(3, []*atomic.Tx{importTx}))
+	require.NoError(db.Commit())
+
+	repo, err = NewAtomicTxRepository(db, atomic.Codec, 3)
+	require.NoError(err)
+	require.NoError(repo.InitializeAddressIndex(3))
+	require.NoError(repo.Write(4, []*atomic.Tx{exportTx2}))
+
+	txs, next, err := repo.GetByAddress(EVMAddress(cAddr), AddressIndexCursor{}, 10)
+	require.NoError(err)
+	require.Nil(next)
+	require.Len(txs, 3)
+	require.Equal(importTx.ID(), txs[0].Tx.ID())
+	require.Equal(uint64(1), txs[0].Height)
+	require.Equal(exportTx.ID(), txs[1].Tx.ID())
+	require.Equal(exportTx2.ID(), txs[2].Tx.ID())
+	require.Equal(uint64(4), txs[2].Height)
+
+	// The X-chain address owns the imported and exported UTXOs
+	txs, next, err = repo.GetByAddress(ShortIDAddress(xAddr), AddressIndexCursor{}, 2)
+	require.NoError(err)
+	require.Len(txs, 2)
+	require.Equal(importTx.ID(), txs[0].Tx.ID())
+	require.Equal(&AddressIndexCursor{Height: 4, TxID: exportTx2.ID()}, next)
+
+	txs, next, err = repo.GetByAddress(ShortIDAddress(xAddr), *next, 2)
+	require.NoError(err)
+	require.Nil(next)
+	require.Len(txs, 1)
+	require.Equal(exportTx2.ID(), txs[0].Tx.ID())
+
+	// The EVM and X-chain addresses with the same bytes are distinct
+	txs, _, err = repo.GetByAddress(ShortIDAddress(ids.ShortID(cAddr)), AddressIndexCursor{}, 10)
+	require.NoError(err)
+	require.Empty(txs)
+
+	// Initializing again resumes after the last indexed height
+	require.NoError(db.Commit())
+	repo, err = NewAtomicTxRepository(db, atomic.Codec, 4)
+	require.NoError(err)
+	require.NoError(repo.InitializeAddressIndex(4))
+	txs, _, err = repo.GetByAddress(EVMAddress(cAddr), AddressIndexCursor{}, 10)
+	require.NoError(err)
+	require.Len(txs, 3)
+}
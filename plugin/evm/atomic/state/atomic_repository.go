@@ -51,6 +51,10 @@ type AtomicRepository struct {
 	// has indexed.
 	atomicRepoMetadataDB database.Database
 
+	// [atomicAddressIndexDB] maintains an index of [address]+[height]+[txID] => nil for all accepted atomic txs,
+	// if the address index is enabled.
+	atomicAddressIndexDB database.Database
+
 	metadataDB database.Database // Underlying database containing the atomic trie metadata
 
 	atomicTrieDB database.Database // Underlying database containing the atomic trie
@@ -284,14 +288,24 @@ func (a *AtomicRepository) write(height uint64, txs []*atomic.Tx, bonus bool) er
 			if err := a.indexTxByID(heightBytes, tx); err != nil {
 				return err
 			}
+			if a.atomicAddressIndexDB != nil {
+				if err := a.indexTxByAddress(heightBytes, tx); err != nil {
+					return err
+				}
+			}
 		}
 		if err := a.indexTxsAtHeight(heightBytes, txs); err != nil {
 			return err
 		}
 	}
 
-	// Update the index height regardless of if any atomic transactions
+	// Update the index heights regardless of if any atomic transactions
 	// were present at [height].
+	if a.atomicAddressIndexDB != nil {
+		if err := a.atomicRepoMetadataDB.Put(maxAddressIndexedHeightKey, heightBytes); err != nil {
+			return err
+		}
+	}
 	return a.atomicRepoMetadataDB.Put(maxIndexedHeightKey, heightBytes)
 }
 
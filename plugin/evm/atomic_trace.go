@@ -0,0 +1,219 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ava-labs/avalanchego/ids"
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/avalanchego/vms/secp256k1fx"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+
+	"github.com/ava-labs/coreth/plugin/evm/atomic"
+	"github.com/ava-labs/coreth/plugin/evm/customtypes"
+)
+
+// atomicTxTrace is the live trace output of an atomic tx. Amounts are in the
+// X-Chain denomination, nAVAX for AVAX, except the balance deltas which are
+// in the EVM denomination, wei for AVAX.
+type atomicTxTrace struct {
+	TxID ids.ID `json:"txID"`
+	Type string `json:"type"` // import or export
+	// Chain is the source chain of an import or the destination chain of an
+	// export
+	Chain ids.ID `json:"chain"`
+
+	ImportedInputs  []atomicInput      `json:"importedInputs,omitempty"`
+	ExportedOutputs []atomicOutput     `json:"exportedOutputs,omitempty"`
+	EVMInputs       []atomic.EVMInput  `json:"evmInputs,omitempty"`
+	EVMOutputs      []atomic.EVMOutput `json:"evmOutputs,omitempty"`
+
+	// Burned is the fee paid in each asset spent by the tx
+	Burned []assetAmount `json:"burned"`
+	// Addresses are the EVM addresses whose balance or nonce changed
+	Addresses     []common.Address `json:"addresses"`
+	BalanceDeltas []balanceDelta   `json:"balanceDeltas"`
+}
+
+// atomicInput is a UTXO consumed by an import
+type atomicInput struct {
+	UTXOID      ids.ID         `json:"utxoID"`
+	TxID        ids.ID         `json:"txID"`
+	OutputIndex uint32         `json:"outputIndex"`
+	AssetID     ids.ID         `json:"assetID"`
+	Amount      avajson.Uint64 `json:"amount"`
+}
+
+// atomicOutput is a UTXO produced by an export
+type atomicOutput struct {
+	AssetID   ids.ID         `json:"assetID"`
+	Amount    avajson.Uint64 `json:"amount"`
+	Locktime  avajson.Uint64 `json:"locktime"`
+	Threshold avajson.Uint32 `json:"threshold"`
+	Addresses []ids.ShortID  `json:"addresses"`
+}
+
+// assetAmount is an amount of an asset
+type assetAmount struct {
+	AssetID ids.ID         `json:"assetID"`
+	Amount  avajson.Uint64 `json:"amount"`
+}
+
+// balanceDelta is the change of the balance of an EVM address in an asset
+type balanceDelta struct {
+	Address common.Address `json:"address"`
+	AssetID ids.ID         `json:"assetID"`
+	Delta   *hexutil.Big   `json:"delta"`
+}
+
+// atomicTraces returns the JSON encoded live trace output of the atomic txs of
+// [block], or nil if there are none.
+func (vm *VM) atomicTraces(block *types.Block) ([]byte, error) {
+	isApricotPhase5 := vm.chainConfigExtra().IsApricotPhase5(block.Time())
+	txs, err := atomic.ExtractAtomicTxs(customtypes.BlockExtData(block), isApricotPhase5, atomic.Codec)
+	if err != nil || len(txs) == 0 {
+		return nil, err
+	}
+	traces := make([]*atomicTxTrace, len(txs))
+	for i, tx := range txs {
+		if traces[i], err = newAtomicTxTrace(tx, vm.ctx.AVAXAssetID); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(traces)
+}
+
+// atomicTraceError is the live trace output replacing the atomic txs of a
+// block which cannot be traced
+type atomicTraceError struct {
+	Error string `json:"error"`
+}
+
+// atomicTracesError returns the JSON encoded live trace output reporting that
+// the atomic txs of a block could not be traced because of [err].
+func atomicTracesError(err error) []byte {
+	// a single string is always encoded
+	data, _ := json.Marshal([]atomicTraceError{{Error: err.Error()}})
+	return data
+}
+
+// atomicTracesByHeader is atomicTraces for the accepted block of [header]
+func (vm *VM) atomicTracesByHeader(header *types.Header) ([]byte, error) {
+	block := vm.blockChain.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		return nil, fmt.Errorf("block %d (%s) not found", header.Number, header.Hash())
+	}
+	return vm.atomicTraces(block)
+}
+
+func newAtomicTxTrace(tx *atomic.Tx, avaxAssetID ids.ID) (*atomicTxTrace, error) {
+	trace := &atomicTxTrace{
+		TxID: tx.ID(),
+	}
+	deltas := make(map[common.Address]map[ids.ID]*big.Int)
+	addDelta := func(addr common.Address, assetID ids.ID, amount uint64, sign int) {
+		if deltas[addr] == nil {
+			deltas[addr] = make(map[ids.ID]*big.Int)
+		}
+		delta := deltas[addr][assetID]
+		if delta == nil {
+			delta = new(big.Int)
+			deltas[addr][assetID] = delta
+		}
+		value := new(big.Int).SetUint64(amount)
+		if assetID == avaxAssetID {
+			value.Mul(value, atomic.X2CRate.ToBig())
+		}
+		if sign < 0 {
+			value.Neg(value)
+		}
+		delta.Add(delta, value)
+	}
+
+	var assets []ids.ID
+	switch utx := tx.UnsignedAtomicTx.(type) {
+	case *atomic.UnsignedImportTx:
+		trace.Type = "import"
+		trace.Chain = utx.SourceChain
+		trace.EVMOutputs = utx.Outs
+		for _, in := range utx.ImportedInputs {
+			trace.ImportedInputs = append(trace.ImportedInputs, atomicInput{
+				UTXOID:      in.InputID(),
+				TxID:        in.TxID,
+				OutputIndex: in.OutputIndex,
+				AssetID:     in.AssetID(),
+				Amount:      avajson.Uint64(in.In.Amount()),
+			})
+			assets = append(assets, in.AssetID())
+		}
+		for _, out := range utx.Outs {
+			addDelta(out.Address, out.AssetID, out.Amount, 1)
+		}
+	case *atomic.UnsignedExportTx:
+		trace.Type = "export"
+		trace.Chain = utx.DestinationChain
+		trace.EVMInputs = utx.Ins
+		for _, out := range utx.ExportedOutputs {
+			output := atomicOutput{
+				AssetID: out.AssetID(),
+				Amount:  avajson.Uint64(out.Out.Amount()),
+			}
+			if owned, ok := out.Out.(*secp256k1fx.TransferOutput); ok {
+				output.Locktime = avajson.Uint64(owned.Locktime)
+				output.Threshold = avajson.Uint32(owned.Threshold)
+				output.Addresses = owned.Addrs
+			}
+			trace.ExportedOutputs = append(trace.ExportedOutputs, output)
+		}
+		for _, in := range utx.Ins {
+			addDelta(in.Address, in.AssetID, in.Amount, -1)
+			assets = append(assets, in.AssetID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown atomic tx type %T", utx)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return bytes.Compare(assets[i][:], assets[j][:]) < 0
+	})
+	for i, assetID := range assets {
+		if i > 0 && assets[i-1] == assetID {
+			continue
+		}
+		burned, err := tx.Burned(assetID)
+		if err != nil {
+			return nil, err
+		}
+		trace.Burned = append(trace.Burned, assetAmount{AssetID: assetID, Amount: avajson.Uint64(burned)})
+	}
+
+	trace.Addresses = make([]common.Address, 0, len(deltas))
+	for addr := range deltas {
+		trace.Addresses = append(trace.Addresses, addr)
+	}
+	sort.Slice(trace.Addresses, func(i, j int) bool {
+		return bytes.Compare(trace.Addresses[i][:], trace.Addresses[j][:]) < 0
+	})
+	for _, addr := range trace.Addresses {
+		start := len(trace.BalanceDeltas)
+		for assetID, delta := range deltas[addr] {
+			trace.BalanceDeltas = append(trace.BalanceDeltas, balanceDelta{
+				Address: addr,
+				AssetID: assetID,
+				Delta:   (*hexutil.Big)(delta),
+			})
+		}
+		added := trace.BalanceDeltas[start:]
+		sort.Slice(added, func(i, j int) bool {
+			return bytes.Compare(added[i].AssetID[:], added[j].AssetID[:]) < 0
+		})
+	}
+	return trace, nil
+}
@@ -195,7 +195,13 @@ func (b *Block) Accept(context.Context) error {
 
 	// Apply any shared memory changes atomically with other pending changes to
 	// the vm's versionDB.
-	return atomicState.Accept(vdbBatch)
+	if err := atomicState.Accept(vdbBatch); err != nil {
+		return err
+	}
+
+	// Publish the live traces buffered during verification.
+	tracecache.Accept(common.Hash(blkID), int64(b.Height()))
+	return nil
 }
 
 // handlePrecompileAccept calls Accept on any logs generated with an active precompile address that implements
@@ -257,7 +263,13 @@ func (b *Block) Reject(context.Context) error {
 	if err := atomicState.Reject(); err != nil {
 		return err
 	}
-	return b.vm.blockChain.Reject(b.ethBlock)
+	if err := b.vm.blockChain.Reject(b.ethBlock); err != nil {
+		return err
+	}
+
+	// Drop the live traces buffered during verification and notify the sink.
+	tracecache.Reject(common.Hash(blkID), int64(b.Height()))
+	return nil
 }
 
 // Parent implements the snowman.Block interface
@@ -377,11 +389,17 @@ func (b *Block) verify(predicateContext *precompileconfig.PredicateContext, writ
 	if b.vm.State.IsProcessing(b.id) {
 		return nil
 	}
-	evmLoggers := make([]vm.EVMLogger, len(b.ethBlock.Transactions()))
-	for i := 0; i < len(evmLoggers); i++ {
-		evmLoggers[i], _ = tracers.DefaultDirectory.New("callTracer", &tracers.Context{}, nil)
+	// Traces are only collected when they will be published, that is, when
+	// the block is written, live tracing is enabled and a trace sink is
+	// configured.
+	var (
+		txTracers  []vm.EVMLogger
+		tracerErrs []error
+	)
+	if writes && b.vm.liveTracer != nil && tracecache.Started() {
+		txTracers, tracerErrs = b.newTxTracers()
 	}
-	err := b.vm.blockChain.InsertBlockManual(b.ethBlock, writes, evmLoggers)
+	err := b.vm.blockChain.InsertBlockManual(b.ethBlock, writes, txTracers)
 	if err != nil || !writes {
 		// if an error occurred inserting the block into the chain
 		// or if we are not pinning to memory, unpin the atomic trie
@@ -390,23 +408,98 @@ func (b *Block) verify(predicateContext *precompileconfig.PredicateContext, writ
 			_ = atomicState.Reject() // ignore this error so we can return the original error instead.
 		}
 	}
-	results := make([]*txTraceResult, len(b.ethBlock.Transactions()))
-	for i, logger := range evmLoggers {
-		res, err := logger.(tracers.Tracer).GetResult()
+	if err != nil || txTracers == nil {
+		return err
+	}
+	// The traces are held until the block is decided, so that only the
+	// traces of accepted blocks are published.
+	b.bufferTraces(txTracers, tracerErrs)
+	return nil
+}
+
+// newTxTracers returns one live tracer per transaction in the block. A tracer
+// which cannot be created is left nil and its error is returned at the same
+// index, so the other transactions are still traced.
+func (b *Block) newTxTracers() ([]vm.EVMLogger, []error) {
+	txs := b.ethBlock.Transactions()
+	txTracers := make([]vm.EVMLogger, len(txs))
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		tracer, err := b.vm.liveTracer.New(&tracers.Context{
+			BlockHash:   b.ethBlock.Hash(),
+			BlockNumber: b.ethBlock.Number(),
+			TxIndex:     i,
+			TxHash:      tx.Hash(),
+		})
 		if err != nil {
-			return fmt.Errorf("### DEBUG ### %w", err)
+			log.Error("failed to create live tracer", "block", b.ID(), "height", b.Height(), "txHash", tx.Hash(), "err", err)
+			errs[i] = err
+			continue
+		}
+		txTracers[i] = tracer
+	}
+	return txTracers, errs
+}
+
+// bufferTraces collects the results of [txTracers] and hands them to
+// tracecache until the block is accepted or rejected. A tracer failing to be
+// created, as reported by [tracerErrs], or to produce a result is reported in
+// that transaction's entry rather than failing verification of a valid block.
+// Likewise, atomic txs which cannot be traced are reported in the atomic txs
+// of the trace, so consumers can detect the gap.
+func (b *Block) bufferTraces(txTracers []vm.EVMLogger, tracerErrs []error) {
+	txs := b.ethBlock.Transactions()
+	results := make([]*txTraceResult, len(txs))
+	for i, tracer := range txTracers {
+		results[i] = &txTraceResult{TxHash: txs[i].Hash()}
+		if err := tracerErrs[i]; err != nil {
+			results[i].Error = fmt.Sprintf("failed to create tracer: %v", err)
+			continue
 		}
-		results[i] = &txTraceResult{
-			TxHash: b.ethBlock.Transactions()[i].Hash(),
-			Result: res,
+		res, err := tracer.(tracers.Tracer).GetResult()
+		if err != nil {
+			log.Error("failed to get live trace result", "block", b.ID(), "height", b.Height(), "txHash", txs[i].Hash(), "err", err)
+			results[i].Error = err.Error()
+			continue
 		}
+		results[i].Result = res
 	}
 	data, err := json.Marshal(results)
 	if err != nil {
-		return fmt.Errorf("### DEBUG ### %w", err)
+		log.Error("failed to marshal live traces", "block", b.ID(), "height", b.Height(), "err", err)
+		data = marshalTraceResults(results)
 	}
-	tracecache.Write(b.ethBlock.Number().Int64(), data)
-	return err
+	atomicTxs, err := b.vm.atomicTraces(b.ethBlock)
+	if err != nil {
+		log.Error("failed to trace atomic txs", "block", b.ID(), "height", b.Height(), "err", err)
+		atomicTxs = atomicTracesError(err)
+	}
+	tracecache.Buffer(b.ethBlock.Header(), b.vm.liveTracer.name, data, atomicTxs)
+}
+
+// marshalTraceResults encodes [results], replacing each result which cannot
+// be encoded with an error.
+func marshalTraceResults(results []*txTraceResult) []byte {
+	for _, res := range results {
+		if _, err := json.Marshal(res.Result); err != nil {
+			res.Result = nil
+			res.Error = fmt.Sprintf("failed to marshal trace result: %v", err)
+		}
+	}
+	// every remaining result has been encoded above
+	data, _ := json.Marshal(results)
+	return data
+}
+
+// bufferStateDiff hands the state diff of a verified block to tracecache
+// until the block is accepted or rejected.
+func bufferStateDiff(block *types.Block, diff *core.StateDiff) error {
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state diff: %w", err)
+	}
+	tracecache.BufferStateDiff(block.Header(), data)
+	return nil
 }
 
 // verifyPredicates verifies the predicates in the block are valid according to predicateContext.
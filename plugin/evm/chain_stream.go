@@ -0,0 +1,181 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/utils/formatting"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/log"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/internal/ethapi"
+	"github.com/ava-labs/coreth/plugin/evm/atomic"
+	"github.com/ava-labs/coreth/plugin/evm/customtypes"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+var errCursorNotAccepted = errors.New("cursor is not on the accepted chain")
+
+// ChainCursor identifies an accepted block by height and hash. A client
+// passes the cursor of the last block it processed to resume the stream.
+type ChainCursor struct {
+	Height hexutil.Uint64 `json:"height"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+// AcceptedBlockEvent is streamed for every accepted block in height order
+type AcceptedBlockEvent struct {
+	Cursor    ChainCursor            `json:"cursor"`
+	Block     map[string]interface{} `json:"block"`
+	Receipts  types.Receipts         `json:"receipts"`
+	AtomicTxs []StreamedAtomicTx     `json:"atomicTxs"`
+}
+
+// StreamedAtomicTx is an atomic tx of an accepted block, hex encoded
+type StreamedAtomicTx struct {
+	TxID ids.ID `json:"txID"`
+	Tx   string `json:"tx"`
+}
+
+// ChainStreamAPI streams the accepted chain to external indexers. Unlike the
+// eth_subscribe feeds, which drop the events emitted while a client is
+// disconnected, the stream resumes from a client supplied cursor.
+type ChainStreamAPI struct {
+	vm *VM
+}
+
+// AcceptedChain streams the blocks accepted after [cursor] with their
+// receipts, logs and atomic txs. The blocks accepted since [cursor] are
+// replayed from the database before the stream switches to newly accepted
+// blocks, so that no block is skipped or repeated across reconnects. If
+// [cursor] is nil the stream starts after the last accepted block.
+func (api *ChainStreamAPI) AcceptedChain(ctx context.Context, cursor *ChainCursor) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	bc := api.vm.blockChain
+
+	// Subscribe before reading the last accepted block, so that any block
+	// accepted later wakes up the stream.
+	accepted := make(chan core.ChainEvent, 1)
+	sub := bc.SubscribeChainAcceptedEvent(accepted)
+	next, err := api.start(cursor)
+	if err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	// The acceptor blocks while the feed is not read, so a slow client
+	// must not hold it up: events only signal that new blocks are available.
+	wake := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-accepted:
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer func() {
+			sub.Unsubscribe()
+			close(done)
+		}()
+		for {
+			for last := bc.LastAcceptedBlock().NumberU64(); next <= last; next++ {
+				select {
+				case <-rpcSub.Err():
+					return
+				default:
+				}
+				event, err := api.acceptedBlockEvent(next)
+				if err != nil {
+					log.Warn("closing accepted chain stream", "height", next, "err", err)
+					return
+				}
+				if err := notifier.Notify(rpcSub.ID, event); err != nil {
+					return
+				}
+			}
+			select {
+			case <-wake:
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// start returns the height of the first block streamed after [cursor]
+func (api *ChainStreamAPI) start(cursor *ChainCursor) (uint64, error) {
+	bc := api.vm.blockChain
+	last := bc.LastAcceptedBlock().NumberU64()
+	if cursor == nil {
+		return last + 1, nil
+	}
+	height := uint64(cursor.Height)
+	if height > last {
+		return 0, fmt.Errorf("%w: height %d is above the last accepted block %d", errCursorNotAccepted, height, last)
+	}
+	if hash := bc.GetCanonicalHash(height); hash != cursor.Hash {
+		return 0, fmt.Errorf("%w: block %d is %s, not %s", errCursorNotAccepted, height, hash, cursor.Hash)
+	}
+	if height < last && bc.GetBlockByNumber(height+1) == nil {
+		return 0, fmt.Errorf("block %d is not available to replay", height+1)
+	}
+	return height + 1, nil
+}
+
+// acceptedBlockEvent reads the accepted block at [height] from the database
+func (api *ChainStreamAPI) acceptedBlockEvent(height uint64) (*AcceptedBlockEvent, error) {
+	bc := api.vm.blockChain
+	block := bc.GetBlockByNumber(height)
+	if block == nil {
+		return nil, fmt.Errorf("accepted block %d not found", height)
+	}
+	receipts := bc.GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(block.Transactions()) {
+		return nil, fmt.Errorf("receipts of accepted block %d not found", height)
+	}
+	isApricotPhase5 := api.vm.chainConfigExtra().IsApricotPhase5(block.Time())
+	atomicTxs, err := atomic.ExtractAtomicTxs(customtypes.BlockExtData(block), isApricotPhase5, atomic.Codec)
+	if err != nil {
+		return nil, err
+	}
+	event := &AcceptedBlockEvent{
+		Cursor:    ChainCursor{Height: hexutil.Uint64(height), Hash: block.Hash()},
+		Block:     ethapi.RPCMarshalBlock(block, true, true, api.vm.chainConfig),
+		Receipts:  receipts,
+		AtomicTxs: make([]StreamedAtomicTx, len(atomicTxs)),
+	}
+	for i, tx := range atomicTxs {
+		txBytes, err := formatting.Encode(formatting.Hex, tx.SignedBytes())
+		if err != nil {
+			return nil, err
+		}
+		event.AtomicTxs[i] = StreamedAtomicTx{TxID: tx.ID(), Tx: txBytes}
+	}
+	return event, nil
+}
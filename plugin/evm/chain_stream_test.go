@@ -0,0 +1,114 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade/upgradetest"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/plugin/evm/upgrade/ap0"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+func TestChainStreamReplaysThenFollows(t *testing.T) {
+	require := require.New(t)
+
+	fork := upgradetest.ApricotPhase2
+	tvm := newVM(t, testVMConfig{
+		fork: &fork,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: 20000000,
+		},
+	})
+	defer func() {
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+
+	server := rpc.NewServer(0)
+	defer server.Stop()
+	require.NoError(server.RegisterName("etl", &ChainStreamAPI{tvm.vm}))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	tvm.vm.txPool.SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(tvm.vm.mempool.AddLocalTx(importTx))
+	<-tvm.toEngine
+	blk1, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk1.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk1.ID()))
+	require.NoError(blk1.Accept(context.Background()))
+	<-newTxPoolHeadChan
+	tvm.vm.blockChain.DrainAcceptorQueue()
+
+	genesis := tvm.vm.blockChain.Genesis()
+	ctx := context.Background()
+	events := make(chan AcceptedBlockEvent, 4)
+
+	// A cursor off the accepted chain is refused
+	_, err = client.Subscribe(ctx, "etl", events, "acceptedChain", &ChainCursor{Height: 0, Hash: common.Hash{1}})
+	require.ErrorContains(err, errCursorNotAccepted.Error())
+	_, err = client.Subscribe(ctx, "etl", events, "acceptedChain", &ChainCursor{Height: 2})
+	require.ErrorContains(err, errCursorNotAccepted.Error())
+
+	// Block 1 is replayed from the database
+	sub, err := client.Subscribe(ctx, "etl", events, "acceptedChain", &ChainCursor{Height: 0, Hash: genesis.Hash()})
+	require.NoError(err)
+	defer sub.Unsubscribe()
+	event := receiveAcceptedBlock(t, events)
+	require.Equal(ChainCursor{Height: 1, Hash: common.Hash(blk1.ID())}, event.Cursor)
+	require.Len(event.AtomicTxs, 1)
+	require.Equal(importTx.ID(), event.AtomicTxs[0].TxID)
+	require.Empty(event.Receipts)
+
+	// Block 2 is streamed once accepted
+	tx := types.NewTransaction(0, testEthAddrs[1], big.NewInt(10), 21000, big.NewInt(ap0.MinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(tvm.vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	for _, err := range tvm.vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-tvm.toEngine
+	blk2, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk2.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk2.ID()))
+	require.NoError(blk2.Accept(context.Background()))
+
+	event = receiveAcceptedBlock(t, events)
+	require.Equal(ChainCursor{Height: 2, Hash: common.Hash(blk2.ID())}, event.Cursor)
+	require.Empty(event.AtomicTxs)
+	require.Len(event.Receipts, 1)
+	require.Equal(signedTx.Hash(), event.Receipts[0].TxHash)
+
+	// Without a cursor the stream starts after the last accepted block
+	sub2, err := client.Subscribe(ctx, "etl", make(chan AcceptedBlockEvent), "acceptedChain", nil)
+	require.NoError(err)
+	sub2.Unsubscribe()
+}
+
+func receiveAcceptedBlock(t *testing.T, events <-chan AcceptedBlockEvent) AcceptedBlockEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no accepted block streamed")
+		return AcceptedBlockEvent{}
+	}
+}
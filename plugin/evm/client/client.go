@@ -31,12 +31,22 @@ type Client interface {
 	GetAtomicTxStatus(ctx context.Context, txID ids.ID, options ...rpc.Option) (atomic.Status, error)
 	GetAtomicTx(ctx context.Context, txID ids.ID, options ...rpc.Option) ([]byte, error)
 	GetAtomicUTXOs(ctx context.Context, addrs []ids.ShortID, sourceChain string, limit uint32, startAddress ids.ShortID, startUTXOID ids.ID, options ...rpc.Option) ([][]byte, ids.ShortID, ids.ID, error)
+	GetAtomicTxsByAddress(ctx context.Context, addr string, start AtomicTxIndex, limit uint32, options ...rpc.Option) ([]AtomicTxWithHeight, *AtomicTxIndex, error)
 	StartCPUProfiler(ctx context.Context, options ...rpc.Option) error
 	StopCPUProfiler(ctx context.Context, options ...rpc.Option) error
 	MemoryProfile(ctx context.Context, options ...rpc.Option) error
 	LockProfile(ctx context.Context, options ...rpc.Option) error
 	SetLogLevel(ctx context.Context, level slog.Level, options ...rpc.Option) error
 	GetVMConfig(ctx context.Context, options ...rpc.Option) (*config.Config, error)
+	StartTraceBackfill(ctx context.Context, start, end uint64, threads int, options ...rpc.Option) error
+	StopTraceBackfill(ctx context.Context, options ...rpc.Option) error
+	GetTraceBackfillStatus(ctx context.Context, options ...rpc.Option) (*TraceBackfillStatus, error)
+	SetConsumerHeight(ctx context.Context, height uint64, options ...rpc.Option) error
+	GetETLStatus(ctx context.Context, options ...rpc.Option) (*ETLStatus, error)
+	PauseBlockProcessing(ctx context.Context, options ...rpc.Option) error
+	ResumeBlockProcessing(ctx context.Context, options ...rpc.Option) error
+	PruneTraceCache(ctx context.Context, options ...rpc.Option) error
+	SetTraceSink(ctx context.Context, args *TraceSinkArgs, options ...rpc.Option) error
 }
 
 // Client implementation for interacting with EVM [chain]
@@ -135,6 +145,70 @@ func (c *client) GetAtomicUTXOs(ctx context.Context, addrs []ids.ShortID, source
 	return utxos, endAddr, endUTXOID, err
 }
 
+// AtomicTxIndex is the position of an atomic tx in the address index
+type AtomicTxIndex struct {
+	Height json.Uint64 `json:"height"`
+	TxID   ids.ID      `json:"txID"`
+}
+
+// GetAtomicTxsByAddressArgs are the arguments of GetAtomicTxsByAddress.
+// Address is either a hex EVM address or the address of a UTXO owner.
+type GetAtomicTxsByAddressArgs struct {
+	Address    string              `json:"address"`
+	StartIndex AtomicTxIndex       `json:"startIndex"`
+	Limit      json.Uint32         `json:"limit"`
+	Encoding   formatting.Encoding `json:"encoding"`
+}
+
+// FormattedAtomicTx is an encoded atomic tx with the height of its block
+type FormattedAtomicTx struct {
+	TxID        ids.ID      `json:"txID"`
+	Tx          string      `json:"tx"`
+	BlockHeight json.Uint64 `json:"blockHeight"`
+}
+
+// GetAtomicTxsByAddressReply defines the GetAtomicTxsByAddress replies
+// returned from the API. EndIndex is the position of the next tx of the
+// address, if there are more.
+type GetAtomicTxsByAddressReply struct {
+	Txs      []FormattedAtomicTx `json:"txs"`
+	EndIndex *AtomicTxIndex      `json:"endIndex,omitempty"`
+	Encoding formatting.Encoding `json:"encoding"`
+}
+
+// AtomicTxWithHeight is the byte representation of an atomic tx with the
+// height of its block
+type AtomicTxWithHeight struct {
+	Tx     []byte
+	Height uint64
+}
+
+// GetAtomicTxsByAddress returns the byte representation of up to [limit]
+// accepted atomic txs of [addr] from [start], and the position of the next
+// tx of [addr] if there are more
+func (c *client) GetAtomicTxsByAddress(ctx context.Context, addr string, start AtomicTxIndex, limit uint32, options ...rpc.Option) ([]AtomicTxWithHeight, *AtomicTxIndex, error) {
+	res := &GetAtomicTxsByAddressReply{}
+	err := c.requester.SendRequest(ctx, "avax.getAtomicTxsByAddress", &GetAtomicTxsByAddressArgs{
+		Address:    addr,
+		StartIndex: start,
+		Limit:      json.Uint32(limit),
+		Encoding:   formatting.Hex,
+	}, res, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txs := make([]AtomicTxWithHeight, len(res.Txs))
+	for i, tx := range res.Txs {
+		txBytes, err := formatting.Decode(res.Encoding, tx.Tx)
+		if err != nil {
+			return nil, nil, err
+		}
+		txs[i] = AtomicTxWithHeight{Tx: txBytes, Height: uint64(tx.BlockHeight)}
+	}
+	return txs, res.EndIndex, nil
+}
+
 func (c *client) StartCPUProfiler(ctx context.Context, options ...rpc.Option) error {
 	return c.adminRequester.SendRequest(ctx, "admin.startCPUProfiler", struct{}{}, &api.EmptyReply{}, options...)
 }
@@ -172,3 +246,118 @@ func (c *client) GetVMConfig(ctx context.Context, options ...rpc.Option) (*confi
 	err := c.adminRequester.SendRequest(ctx, "admin.getVMConfig", struct{}{}, res, options...)
 	return res.Config, err
 }
+
+// TraceBackfillArgs are the arguments to StartTraceBackfill
+type TraceBackfillArgs struct {
+	Start   json.Uint64 `json:"start"`
+	End     json.Uint64 `json:"end"`
+	Threads int         `json:"threads"`
+}
+
+// TraceBackfillStatus is the progress of the last trace backfill
+type TraceBackfillStatus struct {
+	Running bool        `json:"running"`
+	Start   json.Uint64 `json:"start"`
+	End     json.Uint64 `json:"end"`
+	Next    json.Uint64 `json:"next"`
+	Threads int         `json:"threads"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// StartTraceBackfill re-traces the accepted blocks [start, end] with the live
+// tracers of the node and publishes the results to its trace sink, tracing
+// at most [threads] blocks concurrently.
+func (c *client) StartTraceBackfill(ctx context.Context, start, end uint64, threads int, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.startTraceBackfill", &TraceBackfillArgs{
+		Start:   json.Uint64(start),
+		End:     json.Uint64(end),
+		Threads: threads,
+	}, &api.EmptyReply{}, options...)
+}
+
+// StopTraceBackfill stops the running trace backfill
+func (c *client) StopTraceBackfill(ctx context.Context, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.stopTraceBackfill", struct{}{}, &api.EmptyReply{}, options...)
+}
+
+// GetTraceBackfillStatus returns the progress of the last trace backfill
+func (c *client) GetTraceBackfillStatus(ctx context.Context, options ...rpc.Option) (*TraceBackfillStatus, error) {
+	res := &TraceBackfillStatus{}
+	err := c.adminRequester.SendRequest(ctx, "admin.getTraceBackfillStatus", struct{}{}, res, options...)
+	return res, err
+}
+
+// ConsumerHeightArgs are the arguments to SetConsumerHeight
+type ConsumerHeightArgs struct {
+	Height json.Uint64 `json:"height"`
+}
+
+// SetConsumerHeight reports the height of the last block processed by the
+// consumer of the node to the push height source of the pause control
+func (c *client) SetConsumerHeight(ctx context.Context, height uint64, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.setConsumerHeight", &ConsumerHeightArgs{
+		Height: json.Uint64(height),
+	}, &api.EmptyReply{}, options...)
+}
+
+// ETLStatus is the state of the ETL hooks of the node
+type ETLStatus struct {
+	LastAccepted json.Uint64 `json:"lastAccepted"`
+
+	// trace cache
+	TraceCacheStarted bool        `json:"traceCacheStarted"`
+	QueueSize         json.Uint64 `json:"queueSize"`
+	PendingBlocks     int         `json:"pendingBlocks"`
+	LastPublished     int64       `json:"lastPublished"` // -1 if no trace was published since the start
+	TraceCacheError   string      `json:"traceCacheError,omitempty"`
+
+	// pause control
+	PauseStarted   bool   `json:"pauseStarted"`
+	ConsumerHeight int64  `json:"consumerHeight"`
+	Paused         bool   `json:"paused"`
+	Held           bool   `json:"held"`
+	PauseError     string `json:"pauseError,omitempty"`
+
+	TraceBackfill TraceBackfillStatus `json:"traceBackfill"`
+}
+
+// GetETLStatus returns the state of the trace cache, the pause control and
+// the trace backfill
+func (c *client) GetETLStatus(ctx context.Context, options ...rpc.Option) (*ETLStatus, error) {
+	res := &ETLStatus{}
+	err := c.adminRequester.SendRequest(ctx, "admin.getETLStatus", struct{}{}, res, options...)
+	return res, err
+}
+
+// PauseBlockProcessing pauses block processing until ResumeBlockProcessing
+// is called, whatever the height of the consumer
+func (c *client) PauseBlockProcessing(ctx context.Context, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.pauseBlockProcessing", struct{}{}, &api.EmptyReply{}, options...)
+}
+
+// ResumeBlockProcessing resumes block processing paused by
+// PauseBlockProcessing
+func (c *client) ResumeBlockProcessing(ctx context.Context, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.resumeBlockProcessing", struct{}{}, &api.EmptyReply{}, options...)
+}
+
+// PruneTraceCache deletes the traces acknowledged by the consumers from the
+// redis trace sink
+func (c *client) PruneTraceCache(ctx context.Context, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.pruneTraceCache", struct{}{}, &api.EmptyReply{}, options...)
+}
+
+// TraceSinkArgs are the arguments to SetTraceSink. Empty fields keep the
+// configured value.
+type TraceSinkArgs struct {
+	Sink     string `json:"sink"`
+	Endpoint string `json:"endpoint"`
+	DB       *int   `json:"db"`
+	Key      string `json:"key"`
+	FileDir  string `json:"fileDir"`
+}
+
+// SetTraceSink replaces the sink of the live traces until the next restart
+func (c *client) SetTraceSink(ctx context.Context, args *TraceSinkArgs, options ...rpc.Option) error {
+	return c.adminRequester.SendRequest(ctx, "admin.setTraceSink", args, &api.EmptyReply{}, options...)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/ava-labs/coreth/utils"
 	"github.com/ava-labs/libevm/common"
 	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/rawdb"
 	"github.com/spf13/cast"
 )
 
@@ -45,12 +46,22 @@ const (
 	defaultPullGossipFrequency                    = 1 * time.Second
 	defaultTxRegossipFrequency                    = 30 * time.Second
 	defaultOfflinePruningBloomFilterSize   uint64 = 512 // Default size (MB) for the offline pruner to use
+	defaultOnlinePruningInterval                  = 24 * time.Hour
+	defaultOnlinePruningBloomFilterSize    uint64 = 512 // Default size (MB) for the online pruner to use
+	defaultOnlinePruningBatchDelay                = 100 * time.Millisecond
 	defaultLogLevel                               = "info"
 	defaultLogJSONFormat                          = false
 	defaultMaxOutboundActiveRequests              = 16
 	defaultPopulateMissingTriesParallelism        = 1024
 	defaultStateSyncServerTrieCache               = 64 // MB
 	defaultAcceptedCacheSize                      = 32 // blocks
+	defaultETLPollInterval                        = time.Second
+	defaultTraceCacheSize                         = 10_000 // blocks
+	defaultTokenIndexSectionSize                  = 4096   // blocks
+	defaultAddressIndexSectionSize                = 4096   // blocks
+	defaultStateScheme                            = rawdb.HashScheme
+	defaultStateHistory                    uint64 = 90_000 // blocks
+	defaultHistoricStateDepth              uint64 = 1024   // blocks
 
 	// defaultStateSyncMinBlocks is the minimum number of blocks the blockchain
 	// should be ahead of local last accepted to perform state sync.
@@ -83,12 +94,31 @@ var (
 	defaultAllowUnprotectedTxHashes = []common.Hash{
 		common.HexToHash("0xfefb2da535e927b85fe68eb81cb2e4a5827c905f78381a01ef2322aa9b0aee8e"), // EIP-1820: https://eips.ethereum.org/EIPS/eip-1820
 	}
+	defaultLiveTracers = []LiveTracer{
+		{Name: "callTracer"},
+	}
 )
 
 type Duration struct {
 	time.Duration
 }
 
+// Secret is a string which is redacted when the config is logged or
+// returned by the admin API.
+type Secret string
+
+// LiveTracer selects a tracer run on every transaction of a verified block
+// by the live trace pipeline.
+type LiveTracer struct {
+	// Name is the name of a registered tracer (eg. callTracer,
+	// prestateTracer, flatCallTracer, transferTracer) or the code of a JS tracer.
+	Name string `json:"name"`
+	// Config is the JSON tracer config passed to the tracer, eg.
+	// {"withLog": true} for callTracer or {"diffMode": true} for
+	// prestateTracer.
+	Config json.RawMessage `json:"config,omitempty"`
+}
+
 // Config ...
 type Config struct {
 	// GasTarget is the target gas per second that this node will attempt to use
@@ -138,6 +168,11 @@ type Config struct {
 	PopulateMissingTriesParallelism int     `json:"populate-missing-tries-parallelism"` // Number of concurrent readers to use when re-populating missing tries on startup.
 	PruneWarpDB                     bool    `json:"prune-warp-db-enabled"`              // Determines if the warpDB should be cleared on startup
 
+	// State Scheme Settings
+	StateScheme        string `json:"state-scheme"`         // Scheme used to store the state tries, either "hash" or "path"
+	StateHistory       uint64 `json:"state-history"`        // Number of recent blocks whose state can be rebuilt with the path scheme, 0 keeps all of them
+	HistoricStateDepth uint64 `json:"historic-state-depth"` // Number of state histories a query may revert to rebuild a state with the path scheme, 0 means no limit
+
 	// HistoricalProofQueryWindow is, when running in archive mode only, the number of blocks before the
 	// last accepted block to be accepted for proof state queries.
 	HistoricalProofQueryWindow uint64 `json:"historical-proof-query-window,omitempty"`
@@ -193,6 +228,12 @@ type Config struct {
 	OfflinePruningBloomFilterSize uint64 `json:"offline-pruning-bloom-filter-size"`
 	OfflinePruningDataDirectory   string `json:"offline-pruning-data-directory"`
 
+	// Online Pruning Settings
+	OnlinePruning                bool     `json:"online-pruning-enabled"`           // If enabled, stale trie nodes are pruned while the node is running
+	OnlinePruningInterval        Duration `json:"online-pruning-interval"`          // Minimum time between two online pruning rounds
+	OnlinePruningBloomFilterSize uint64   `json:"online-pruning-bloom-filter-size"` // Size (MB) of the bloom filter of an online pruning round
+	OnlinePruningBatchDelay      Duration `json:"online-pruning-batch-delay"`       // Pause between two batches of deleted trie nodes
+
 	// VM2VM network
 	MaxOutboundActiveRequests int64 `json:"max-outbound-active-requests"`
 
@@ -242,6 +283,65 @@ type Config struct {
 
 	// RPC settings
 	HttpBodyLimit uint64 `json:"http-body-limit"`
+
+	// Token index, the ERC-20/721/1155 transfers of the accepted chain
+	// indexed by token and by address and served by the token API
+	TokenIndexEnabled     bool   `json:"token-index-enabled"`
+	TokenIndexSectionSize uint64 `json:"token-index-section-size"` // Number of blocks indexed at a time by the backfill
+
+	// Address index, the txs of the accepted chain indexed by the addresses
+	// appearing in them and served by the ots API
+	AddressIndexEnabled     bool   `json:"address-index-enabled"`
+	AddressIndexSectionSize uint64 `json:"address-index-section-size"` // Number of blocks indexed at a time by the backfill
+	AddressIndexTraces      bool   `json:"address-index-traces"`       // Also index the addresses touched by internal calls, which traces every block
+
+	// Atomic tx address index, the accepted atomic txs indexed by their EVM
+	// addresses and UTXO owners and served by avax.getAtomicTxsByAddress
+	AtomicTxAddressIndexEnabled bool `json:"atomic-tx-address-index-enabled"`
+
+	// LiveTracers are the tracers run on every transaction of a verified
+	// block, whose results are published by the live trace pipeline. If
+	// more than one is specified, their results are emitted side by side
+	// keyed by tracer name. An empty list disables live tracing.
+	LiveTracers []LiveTracer `json:"live-tracers"`
+
+	// ETL sync status, the consumer height in the redis sentinel hash
+	// chain_latest:timeline. Disabled if ETLRedisAddrs is empty.
+	ETLRedisAddrs      []string `json:"etl-redis-addrs"`
+	ETLRedisMasterName string   `json:"etl-redis-master-name"`
+	ETLRedisPassword   Secret   `json:"etl-redis-password"`
+	ETLRedisDB         int      `json:"etl-redis-db"`
+	ETLChainName       string   `json:"etl-chain-name"`
+	ETLTestHeight      int64    `json:"etl-test-height"` // Reported instead of the redis height if non-zero
+	ETLTestOffset      int64    `json:"etl-test-offset"` // Subtracted from the redis height
+
+	// ETL stream, the resumable stream of accepted blocks served over websocket
+	ETLStreamEnabled bool `json:"etl-stream-enabled"`
+
+	// ETL pause, pauses block processing while the consumer is behind
+	ETLPauseEnabled      bool     `json:"etl-pause-enabled"`
+	ETLAllowBehind       int64    `json:"etl-allow-behind"`        // Number of blocks the node may run ahead of the consumer
+	ETLPollInterval      Duration `json:"etl-poll-interval"`       // Interval the consumer height is polled at
+	ETLHeightSource      string   `json:"etl-height-source"`       // syncstatus (default), redis, sentinel, http, rpc, file or push
+	ETLHeightSourceURL   string   `json:"etl-height-source-url"`   // Redis addresses, http or rpc url, or file path
+	ETLHeightSourceKey   string   `json:"etl-height-source-key"`   // Redis hash key
+	ETLHeightSourceField string   `json:"etl-height-source-field"` // Redis hash field or http json field
+	ETLHeightSourceRPC   string   `json:"etl-height-source-rpc"`   // JSON-RPC method
+
+	// Trace cache, the sink of the live traces
+	TraceCacheSink           string   `json:"trace-cache-sink"`            // redis (default) or file
+	TraceCacheEndpoint       string   `json:"trace-cache-endpoint"`        // Redis endpoint, the redis sink is disabled if empty
+	TraceCacheDB             int      `json:"trace-cache-db"`              // Redis db
+	TraceCacheKey            string   `json:"trace-cache-key"`             // Redis hash key
+	TraceCacheSize           int64    `json:"trace-cache-size"`            // Number of blocks retained in the redis hash before publishing waits for the consumers
+	TraceCacheMaxBytes       int64    `json:"trace-cache-max-bytes"`       // Total size of the traces retained in the redis hash before publishing waits, unlimited if zero
+	TraceCacheConsumerGroups []string `json:"trace-cache-consumer-groups"` // Consumer groups which must acknowledge a trace in "<key>:acks" before it is deleted
+	TraceCacheFileDir        string   `json:"trace-cache-file-dir"`        // Directory of the file sink
+	TraceCacheFileFormat     string   `json:"trace-cache-file-format"`     // jsonl (default) or lp
+	TraceCacheFileMaxSize    int64    `json:"trace-cache-file-max-size"`   // Size in bytes after which trace files are rotated
+	TraceCacheEncoding       string   `json:"trace-cache-encoding"`        // Encoding of the published envelopes: json (default) or binary
+	TraceCacheCompression    string   `json:"trace-cache-compression"`     // Compression of the published envelopes: none (default), snappy or zstd
+	TraceCacheStateDiff      bool     `json:"trace-cache-state-diff"`      // Publish the state diff of every accepted block along with its traces
 }
 
 // TxPoolConfig contains the transaction pool config to be passed
@@ -300,6 +400,9 @@ func (c *Config) SetDefaults(txPoolConfig TxPoolConfig) {
 	c.PullGossipFrequency.Duration = defaultPullGossipFrequency
 	c.RegossipFrequency.Duration = defaultTxRegossipFrequency
 	c.OfflinePruningBloomFilterSize = defaultOfflinePruningBloomFilterSize
+	c.OnlinePruningInterval.Duration = defaultOnlinePruningInterval
+	c.OnlinePruningBloomFilterSize = defaultOnlinePruningBloomFilterSize
+	c.OnlinePruningBatchDelay.Duration = defaultOnlinePruningBatchDelay
 	c.LogLevel = defaultLogLevel
 	c.LogJSONFormat = defaultLogJSONFormat
 	c.MaxOutboundActiveRequests = defaultMaxOutboundActiveRequests
@@ -311,6 +414,14 @@ func (c *Config) SetDefaults(txPoolConfig TxPoolConfig) {
 	c.AllowUnprotectedTxHashes = defaultAllowUnprotectedTxHashes
 	c.AcceptedCacheSize = defaultAcceptedCacheSize
 	c.HistoricalProofQueryWindow = defaultHistoricalProofQueryWindow
+	c.StateScheme = defaultStateScheme
+	c.StateHistory = defaultStateHistory
+	c.HistoricStateDepth = defaultHistoricStateDepth
+	c.LiveTracers = defaultLiveTracers
+	c.ETLPollInterval.Duration = defaultETLPollInterval
+	c.TraceCacheSize = defaultTraceCacheSize
+	c.TokenIndexSectionSize = defaultTokenIndexSectionSize
+	c.AddressIndexSectionSize = defaultAddressIndexSectionSize
 
 	// Price Option Settings
 	c.PriceOptionSlowFeePercentage = defaultPriceOptionSlowFeePercentage
@@ -337,6 +448,18 @@ func (d Duration) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.Duration.String())
 }
 
+// String implements the stringer interface.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "<redacted>"
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
 // Validate returns an error if this is an invalid config.
 func (c *Config) Validate(networkID uint32) error {
 	// Ensure that non-standard commit interval is not allowed for production networks
@@ -364,10 +487,58 @@ func (c *Config) Validate(networkID uint32) error {
 		return fmt.Errorf("cannot use commit interval of 0 with pruning enabled")
 	}
 
+	if c.OnlinePruning {
+		if !c.Pruning {
+			return fmt.Errorf("cannot run online pruning while pruning is disabled")
+		}
+		if c.StateScheme != rawdb.HashScheme {
+			return fmt.Errorf("cannot run online pruning with the %s state scheme", c.StateScheme)
+		}
+		if c.OnlinePruningBloomFilterSize == 0 {
+			return fmt.Errorf("online-pruning-bloom-filter-size must be positive")
+		}
+	}
+
+	switch c.StateScheme {
+	case rawdb.HashScheme:
+	case rawdb.PathScheme:
+		if c.StateSyncEnabled != nil && *c.StateSyncEnabled {
+			return fmt.Errorf("cannot enable state sync with the %s state scheme", c.StateScheme)
+		}
+		if c.OfflinePruning {
+			return fmt.Errorf("cannot run offline pruning with the %s state scheme", c.StateScheme)
+		}
+		if c.PopulateMissingTries != nil {
+			return fmt.Errorf("cannot enable populate missing tries with the %s state scheme", c.StateScheme)
+		}
+	default:
+		return fmt.Errorf("state-scheme must be %q or %q, got %q", rawdb.HashScheme, rawdb.PathScheme, c.StateScheme)
+	}
+
 	if c.PushGossipPercentStake < 0 || c.PushGossipPercentStake > 1 {
 		return fmt.Errorf("push-gossip-percent-stake is %f but must be in the range [0, 1]", c.PushGossipPercentStake)
 	}
-	return nil
+
+	liveTracerNames := make(map[string]struct{}, len(c.LiveTracers))
+	for i, tracer := range c.LiveTracers {
+		if tracer.Name == "" {
+			return fmt.Errorf("live-tracers[%d] must specify a tracer name", i)
+		}
+		if _, ok := liveTracerNames[tracer.Name]; ok {
+			return fmt.Errorf("live-tracers[%d] duplicates tracer %q", i, tracer.Name)
+		}
+		liveTracerNames[tracer.Name] = struct{}{}
+	}
+	if c.TokenIndexEnabled && c.TokenIndexSectionSize == 0 {
+		return fmt.Errorf("token-index-section-size must be positive")
+	}
+	if c.AddressIndexEnabled && c.AddressIndexSectionSize == 0 {
+		return fmt.Errorf("address-index-section-size must be positive")
+	}
+	if c.AddressIndexTraces && !c.AddressIndexEnabled {
+		return fmt.Errorf("address-index-traces requires address-index-enabled")
+	}
+	return c.validateETL()
 }
 
 func (c *Config) Deprecate() string {
@@ -117,6 +117,31 @@ func TestUnmarshalConfig(t *testing.T) {
 			Config{AllowUnprotectedTxHashes: []common.Hash{common.HexToHash("0x803351deb6d745e91545a6a3e1c0ea3e9a6a02a1a4193b70edfcd2f40f71a01c")}},
 			false,
 		},
+		{
+			"live tracers",
+			[]byte(`{"live-tracers": [{"name": "callTracer", "config": {"withLog": true}}, {"name": "prestateTracer"}]}`),
+			Config{LiveTracers: []LiveTracer{
+				{Name: "callTracer", Config: json.RawMessage(`{"withLog": true}`)},
+				{Name: "prestateTracer"},
+			}},
+			false,
+		},
+		{
+			"etl settings",
+			[]byte(`{"etl-redis-addrs": ["127.0.0.1:26379"], "etl-redis-master-name": "mymaster", "etl-redis-password": "pass", "etl-chain-name": "c", "etl-pause-enabled": true, "etl-allow-behind": 64, "etl-poll-interval": "500ms", "trace-cache-sink": "file", "trace-cache-file-dir": "/tmp/traces"}`),
+			Config{
+				ETLRedisAddrs:      []string{"127.0.0.1:26379"},
+				ETLRedisMasterName: "mymaster",
+				ETLRedisPassword:   "pass",
+				ETLChainName:       "c",
+				ETLPauseEnabled:    true,
+				ETLAllowBehind:     64,
+				ETLPollInterval:    Duration{500 * time.Millisecond},
+				TraceCacheSink:     "file",
+				TraceCacheFileDir:  "/tmp/traces",
+			},
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,3 +158,227 @@ func TestUnmarshalConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateLiveTracers(t *testing.T) {
+	tests := []struct {
+		name        string
+		tracers     []LiveTracer
+		expectedErr bool
+	}{
+		{"default", defaultLiveTracers, false},
+		{"disabled", nil, false},
+		{"several tracers", []LiveTracer{{Name: "callTracer"}, {Name: "prestateTracer"}}, false},
+		{"missing name", []LiveTracer{{Config: json.RawMessage(`{}`)}}, true},
+		{"duplicate name", []LiveTracer{{Name: "callTracer"}, {Name: "callTracer"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Config
+			c.SetDefaults(TxPoolConfig{})
+			c.LiveTracers = tt.tracers
+			err := c.Validate(0)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateStateScheme(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Config)
+		expectedErr bool
+	}{
+		{"default", func(*Config) {}, false},
+		{"path", func(c *Config) { c.StateScheme = "path" }, false},
+		{"unknown", func(c *Config) { c.StateScheme = "verkle" }, true},
+		{"path with state sync", func(c *Config) {
+			c.StateScheme = "path"
+			c.StateSyncEnabled = newTrue()
+		}, true},
+		{"path with offline pruning", func(c *Config) {
+			c.StateScheme = "path"
+			c.OfflinePruning = true
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Config
+			c.SetDefaults(TxPoolConfig{})
+			tt.modify(&c)
+			err := c.Validate(0)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateOnlinePruning(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Config)
+		expectedErr bool
+	}{
+		{"disabled", func(*Config) {}, false},
+		{"enabled", func(c *Config) { c.OnlinePruning = true }, false},
+		{"archive", func(c *Config) {
+			c.OnlinePruning = true
+			c.Pruning = false
+		}, true},
+		{"path", func(c *Config) {
+			c.OnlinePruning = true
+			c.StateScheme = "path"
+		}, true},
+		{"empty bloom filter", func(c *Config) {
+			c.OnlinePruning = true
+			c.OnlinePruningBloomFilterSize = 0
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Config
+			c.SetDefaults(TxPoolConfig{})
+			tt.modify(&c)
+			err := c.Validate(0)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateETL(t *testing.T) {
+	syncStatus := func(c *Config) {
+		c.ETLRedisAddrs = []string{"127.0.0.1:26379"}
+		c.ETLRedisMasterName = "mymaster"
+		c.ETLChainName = "c"
+	}
+	tests := []struct {
+		name        string
+		modify      func(*Config)
+		expectedErr string
+	}{
+		{"default", func(*Config) {}, ""},
+		{"sync status", syncStatus, ""},
+		{"sync status without master name", func(c *Config) {
+			syncStatus(c)
+			c.ETLRedisMasterName = ""
+		}, "master name"},
+		{"pause", func(c *Config) {
+			syncStatus(c)
+			c.ETLPauseEnabled = true
+			c.ETLAllowBehind = 64
+		}, ""},
+		{"pause without sync status", func(c *Config) {
+			c.ETLPauseEnabled = true
+			c.ETLAllowBehind = 64
+		}, "requires etl-redis-addrs"},
+		{"pause without allow behind", func(c *Config) {
+			syncStatus(c)
+			c.ETLPauseEnabled = true
+		}, "allow behind"},
+		{"pause with http source", func(c *Config) {
+			c.ETLPauseEnabled = true
+			c.ETLAllowBehind = 64
+			c.ETLHeightSource = "http"
+			c.ETLHeightSourceURL = "http://127.0.0.1:8080/height"
+		}, ""},
+		{"pause with unknown source", func(c *Config) {
+			c.ETLPauseEnabled = true
+			c.ETLAllowBehind = 64
+			c.ETLHeightSource = "kafka"
+		}, "unknown height source"},
+		{"redis trace cache without key", func(c *Config) {
+			c.TraceCacheEndpoint = "127.0.0.1:6379"
+		}, "invalid redis cache key"},
+		{"redis trace cache with consumer groups", func(c *Config) {
+			c.TraceCacheEndpoint = "127.0.0.1:6379"
+			c.TraceCacheKey = "traces"
+			c.TraceCacheConsumerGroups = []string{"indexer", "etl"}
+			c.TraceCacheMaxBytes = 1 << 30
+		}, ""},
+		{"redis trace cache with negative max bytes", func(c *Config) {
+			c.TraceCacheEndpoint = "127.0.0.1:6379"
+			c.TraceCacheKey = "traces"
+			c.TraceCacheMaxBytes = -1
+		}, "invalid redis cache size"},
+		{"file trace cache", func(c *Config) {
+			c.TraceCacheSink = "file"
+			c.TraceCacheFileDir = "/tmp/traces"
+			c.TraceCacheFileFormat = "lp"
+		}, ""},
+		{"file trace cache with unknown format", func(c *Config) {
+			c.TraceCacheSink = "file"
+			c.TraceCacheFileDir = "/tmp/traces"
+			c.TraceCacheFileFormat = "csv"
+		}, "invalid trace file format"},
+		{"compressed binary trace cache", func(c *Config) {
+			c.TraceCacheEndpoint = "127.0.0.1:6379"
+			c.TraceCacheKey = "traces"
+			c.TraceCacheEncoding = "binary"
+			c.TraceCacheCompression = "zstd"
+		}, ""},
+		{"trace cache with unknown compression", func(c *Config) {
+			c.TraceCacheEndpoint = "127.0.0.1:6379"
+			c.TraceCacheKey = "traces"
+			c.TraceCacheCompression = "lz4"
+		}, "invalid trace compression"},
+		{"compressed jsonl trace files", func(c *Config) {
+			c.TraceCacheSink = "file"
+			c.TraceCacheFileDir = "/tmp/traces"
+			c.TraceCacheCompression = "snappy"
+		}, "requires uncompressed json"},
+		{"trace cache with state diffs", func(c *Config) {
+			c.TraceCacheEndpoint = "127.0.0.1:6379"
+			c.TraceCacheKey = "traces"
+			c.TraceCacheStateDiff = true
+		}, ""},
+		{"state diffs without trace sink", func(c *Config) {
+			c.TraceCacheStateDiff = true
+		}, "requires a trace sink"},
+		{"unknown trace cache sink", func(c *Config) {
+			c.TraceCacheSink = "kafka"
+		}, "unknown trace sink"},
+		{"address index with traces", func(c *Config) {
+			c.AddressIndexEnabled = true
+			c.AddressIndexTraces = true
+		}, ""},
+		{"address index traces without address index", func(c *Config) {
+			c.AddressIndexTraces = true
+		}, "requires address-index-enabled"},
+		{"token index without section size", func(c *Config) {
+			c.TokenIndexEnabled = true
+			c.TokenIndexSectionSize = 0
+		}, "token-index-section-size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Config
+			c.SetDefaults(TxPoolConfig{})
+			tt.modify(&c)
+			err := c.Validate(0)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSecretRedacted(t *testing.T) {
+	c := Config{ETLRedisPassword: "hunter2"}
+	b, err := json.Marshal(c)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "hunter2")
+	assert.NotContains(t, fmt.Sprintf("%v", c), "hunter2")
+	assert.Equal(t, "hunter2", c.SyncStatusConfig().Password)
+}
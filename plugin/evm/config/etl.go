@@ -0,0 +1,86 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/coreth/kclients/pause"
+	"github.com/ava-labs/coreth/kclients/syncstatus"
+	"github.com/ava-labs/coreth/kclients/tracecache"
+)
+
+// SyncStatusConfig returns the ETL sync status settings
+func (c *Config) SyncStatusConfig() syncstatus.Config {
+	return syncstatus.Config{
+		Addrs:      c.ETLRedisAddrs,
+		MasterName: c.ETLRedisMasterName,
+		Password:   string(c.ETLRedisPassword),
+		DB:         c.ETLRedisDB,
+		ChainName:  c.ETLChainName,
+		TestHeight: c.ETLTestHeight,
+		TestOffset: c.ETLTestOffset,
+	}
+}
+
+// PauseConfig returns the ETL pause settings. Redis height sources share the
+// credentials of the ETL sync status.
+func (c *Config) PauseConfig() pause.Config {
+	return pause.Config{
+		Enabled:      c.ETLPauseEnabled,
+		AllowBehind:  c.ETLAllowBehind,
+		PollInterval: c.ETLPollInterval.Duration,
+		Source:       c.ETLHeightSource,
+		URL:          c.ETLHeightSourceURL,
+		Key:          c.ETLHeightSourceKey,
+		Field:        c.ETLHeightSourceField,
+		Method:       c.ETLHeightSourceRPC,
+		MasterName:   c.ETLRedisMasterName,
+		Password:     string(c.ETLRedisPassword),
+		DB:           c.ETLRedisDB,
+	}
+}
+
+// TraceCacheConfig returns the live trace sink settings
+func (c *Config) TraceCacheConfig() tracecache.Config {
+	return tracecache.Config{
+		Sink:        c.TraceCacheSink,
+		Endpoint:    c.TraceCacheEndpoint,
+		DB:          c.TraceCacheDB,
+		Key:         c.TraceCacheKey,
+		Groups:      c.TraceCacheConsumerGroups,
+		Size:        c.TraceCacheSize,
+		MaxBytes:    c.TraceCacheMaxBytes,
+		FileDir:     c.TraceCacheFileDir,
+		FileFormat:  c.TraceCacheFileFormat,
+		FileMaxSize: c.TraceCacheFileMaxSize,
+		Encoding:    c.TraceCacheEncoding,
+		Compression: c.TraceCacheCompression,
+	}
+}
+
+// validateETL returns an error if the ETL or trace cache settings are invalid
+func (c *Config) validateETL() error {
+	syncStatus := c.SyncStatusConfig()
+	if err := syncStatus.Validate(); err != nil {
+		return fmt.Errorf("invalid etl sync status config: %w", err)
+	}
+	pauseConfig := c.PauseConfig()
+	if err := pauseConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid etl pause config: %w", err)
+	}
+	usesSyncStatus := pauseConfig.Enabled && (pauseConfig.Source == "" || pauseConfig.Source == pause.SourceSyncStatus)
+	if usesSyncStatus && !syncStatus.Enabled() {
+		return errors.New("etl-pause-enabled with the syncstatus height source requires etl-redis-addrs")
+	}
+	traceCache := c.TraceCacheConfig()
+	if err := traceCache.Validate(); err != nil {
+		return fmt.Errorf("invalid trace cache config: %w", err)
+	}
+	if c.TraceCacheStateDiff && !traceCache.Enabled() {
+		return errors.New("trace-cache-state-diff requires a trace sink")
+	}
+	return nil
+}
@@ -56,6 +56,58 @@ func DeleteOfflinePruning(db ethdb.KeyValueStore) error {
 	return db.Delete(offlinePruningKey)
 }
 
+// WriteOnlinePruning writes a time marker of the last completed round of
+// online pruning.
+func WriteOnlinePruning(db ethdb.KeyValueStore) error {
+	return writeCurrentTimeMarker(db, onlinePruningKey)
+}
+
+// ReadOnlinePruning reads the timestamp of the last completed round of online
+// pruning if present.
+func ReadOnlinePruning(db ethdb.KeyValueStore) (time.Time, error) {
+	return readTimeMarker(db, onlinePruningKey)
+}
+
+// OnlinePruningProgress is the progress of an online pruning round, of which
+// the keys before Next have been swept.
+type OnlinePruningProgress struct {
+	Next    []byte
+	Deleted uint64
+}
+
+// WriteOnlinePruningProgress writes the progress of the running online
+// pruning round.
+func WriteOnlinePruningProgress(db ethdb.KeyValueWriter, progress *OnlinePruningProgress) error {
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	return db.Put(onlinePruningProgressKey, data)
+}
+
+// ReadOnlinePruningProgress reads the progress of the last online pruning
+// round which did not complete. If there is none, nil is returned.
+func ReadOnlinePruningProgress(db ethdb.KeyValueReader) (*OnlinePruningProgress, error) {
+	has, err := db.Has(onlinePruningProgressKey)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := db.Get(onlinePruningProgressKey)
+	if err != nil {
+		return nil, err
+	}
+	progress := new(OnlinePruningProgress)
+	if err := rlp.DecodeBytes(data, progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// DeleteOnlinePruningProgress deletes the progress of the online pruning round.
+func DeleteOnlinePruningProgress(db ethdb.KeyValueWriter) error {
+	return db.Delete(onlinePruningProgressKey)
+}
+
 // WritePopulateMissingTries writes a marker for the current attempt to populate
 // missing tries.
 func WritePopulateMissingTries(db ethdb.KeyValueStore) error {
@@ -112,3 +164,44 @@ func ReadAcceptorTip(db ethdb.KeyValueReader) (common.Hash, error) {
 	}
 	return common.BytesToHash(h), nil
 }
+
+// TraceBackfillProgress is the progress of a trace backfill over the blocks
+// [Start, End], of which the blocks before Next are published.
+type TraceBackfillProgress struct {
+	Start   uint64
+	End     uint64
+	Next    uint64
+	Threads uint64
+}
+
+// WriteTraceBackfillProgress writes the progress of the running trace backfill.
+func WriteTraceBackfillProgress(db ethdb.KeyValueWriter, progress *TraceBackfillProgress) error {
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	return db.Put(traceBackfillKey, data)
+}
+
+// ReadTraceBackfillProgress reads the progress of the last trace backfill
+// which did not complete. If there is none, nil is returned.
+func ReadTraceBackfillProgress(db ethdb.KeyValueReader) (*TraceBackfillProgress, error) {
+	has, err := db.Has(traceBackfillKey)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := db.Get(traceBackfillKey)
+	if err != nil {
+		return nil, err
+	}
+	progress := new(TraceBackfillProgress)
+	if err := rlp.DecodeBytes(data, progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// DeleteTraceBackfillProgress deletes the progress of the trace backfill.
+func DeleteTraceBackfillProgress(db ethdb.KeyValueWriter) error {
+	return db.Delete(traceBackfillKey)
+}
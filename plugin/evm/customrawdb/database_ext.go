@@ -30,7 +30,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 	options := []rawdb.InspectDatabaseOption{
 		rawdb.WithDatabaseMetadataKeys(func(key []byte) bool {
 			return bytes.Equal(key, snapshotBlockHashKey) ||
-				bytes.Equal(key, syncRootKey)
+				bytes.Equal(key, syncRootKey) ||
+				bytes.Equal(key, traceBackfillKey)
 		}),
 		rawdb.WithDatabaseStatRecorder(func(key []byte, size common.StorageSize) bool {
 			for _, s := range stats {
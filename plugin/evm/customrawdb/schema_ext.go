@@ -20,6 +20,15 @@ var (
 	pruningDisabledKey = []byte("PruningDisabled")
 	// acceptorTipKey tracks the tip of the last accepted block that has been fully processed.
 	acceptorTipKey = []byte("AcceptorTipKey")
+	// onlinePruningKey tracks the last completed round of online pruning.
+	onlinePruningKey = []byte("OnlinePruning")
+	// onlinePruningProgressKey tracks the progress of the running online pruning round.
+	onlinePruningProgressKey = []byte("OnlinePruningProgress")
+	// traceBackfillKey tracks the progress of the running trace backfill.
+	traceBackfillKey = []byte("TraceBackfill")
+	// TraceQueuePrefix is the prefix of the live traces waiting to be
+	// delivered to the trace sink.
+	TraceQueuePrefix = []byte("trace_queue")
 )
 
 // State sync progress keys and prefixes
@@ -3,12 +3,38 @@
 
 package evm
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/coreth/kclients/pause"
+	"github.com/ava-labs/coreth/kclients/tracecache"
+)
 
 // Health returns nil if this chain is healthy.
 // Also returns details, which should be one of:
 // string, []byte, map[string]string
+//
+// The chain is unhealthy if the live trace sink or the consumer which block
+// processing is throttled against stalled. The details hold the state of both
+// as JSON objects.
 func (vm *VM) HealthCheck(context.Context) (interface{}, error) {
-	// TODO perform actual health check
-	return nil, nil
+	checks := []struct {
+		name  string
+		check func() (map[string]interface{}, error)
+	}{
+		{"traceCache", tracecache.Health},
+		{"pause", pause.Health},
+	}
+	details := make(map[string]interface{}, len(checks))
+	var errs []error
+	for _, c := range checks {
+		d, err := c.check()
+		details[c.name] = d
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return details, errors.Join(errs...)
 }
@@ -0,0 +1,63 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/plugin/evm/config"
+)
+
+// muxTracerName is the name of the tracer bundled by libevm which runs
+// several tracers on the same execution and keys their results by name.
+const muxTracerName = "muxTracer"
+
+// liveTracer creates the tracers run by the live trace pipeline on each
+// transaction of a verified block.
+type liveTracer struct {
+	name   string
+	config json.RawMessage
+}
+
+// newLiveTracer returns a liveTracer for [cfgs], or nil if [cfgs] is empty.
+// A single tracer is run as is, while several tracers are combined into a
+// muxTracer so that their results are emitted side by side.
+func newLiveTracer(cfgs []config.LiveTracer) (*liveTracer, error) {
+	var t *liveTracer
+	switch len(cfgs) {
+	case 0:
+		return nil, nil
+	case 1:
+		t = &liveTracer{
+			name:   cfgs[0].Name,
+			config: cfgs[0].Config,
+		}
+	default:
+		muxConfig := make(map[string]json.RawMessage, len(cfgs))
+		for _, cfg := range cfgs {
+			muxConfig[cfg.Name] = cfg.Config
+		}
+		rawConfig, err := json.Marshal(muxConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal live tracers config: %w", err)
+		}
+		t = &liveTracer{
+			name:   muxTracerName,
+			config: rawConfig,
+		}
+	}
+	// Construct the tracer once so that an unknown tracer or an invalid
+	// config is reported at initialization rather than on every block.
+	if _, err := t.New(&tracers.Context{}); err != nil {
+		return nil, fmt.Errorf("invalid live tracers config: %w", err)
+	}
+	return t, nil
+}
+
+// New returns a new tracer for the transaction described by [ctx].
+func (t *liveTracer) New(ctx *tracers.Context) (tracers.Tracer, error) {
+	return tracers.DefaultDirectory.New(t.name, ctx, t.config)
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade/upgradetest"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/kclients/tracecache"
+	"github.com/ava-labs/coreth/plugin/evm/config"
+	"github.com/ava-labs/coreth/plugin/evm/upgrade/ap0"
+)
+
+func TestNewLiveTracer(t *testing.T) {
+	require := require.New(t)
+
+	tracer, err := newLiveTracer(nil)
+	require.NoError(err)
+	require.Nil(tracer)
+
+	tracer, err = newLiveTracer([]config.LiveTracer{
+		{Name: "callTracer", Config: json.RawMessage(`{"withLog":true}`)},
+	})
+	require.NoError(err)
+	require.Equal("callTracer", tracer.name)
+
+	tracer, err = newLiveTracer([]config.LiveTracer{
+		{Name: "callTracer", Config: json.RawMessage(`{"withLog":true}`)},
+		{Name: "prestateTracer", Config: json.RawMessage(`{"diffMode":true}`)},
+	})
+	require.NoError(err)
+	require.Equal(muxTracerName, tracer.name)
+	var muxConfig map[string]json.RawMessage
+	require.NoError(json.Unmarshal(tracer.config, &muxConfig))
+	require.JSONEq(`{"withLog":true}`, string(muxConfig["callTracer"]))
+	require.JSONEq(`{"diffMode":true}`, string(muxConfig["prestateTracer"]))
+	_, err = tracer.New(&tracers.Context{TxIndex: 1})
+	require.NoError(err)
+
+	_, err = newLiveTracer([]config.LiveTracer{
+		{Name: "callTracer", Config: json.RawMessage(`{"withLog":"yes"}`)},
+	})
+	require.Error(err)
+}
+
+func TestLiveTracesPublishedOnAccept(t *testing.T) {
+	require := require.New(t)
+
+	sink := tracecache.NewChanSink(16)
+	require.NoError(tracecache.StartWithSink(context.Background(), sink, nil))
+	defer tracecache.Stop()
+
+	importAmount := uint64(20000000)
+	fork := upgradetest.ApricotPhase2
+	tvm := newVM(t, testVMConfig{
+		fork:       &fork,
+		configJSON: `{"live-tracers":[{"name":"callTracer"},{"name":"prestateTracer","config":{"diffMode":true}},{"name":"transferTracer"}]}`,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: importAmount,
+		},
+	})
+	defer func() {
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	tvm.vm.txPool.SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(tvm.vm.mempool.AddLocalTx(importTx))
+	<-tvm.toEngine
+	blk1, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk1.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk1.ID()))
+	require.NoError(blk1.Accept(context.Background()))
+	<-newTxPoolHeadChan
+
+	// blk1 only carries an atomic tx, so its trace list is empty.
+	got := <-sink.C
+	require.Equal(tracecache.EventAccepted, got.Type)
+	require.Equal(common.Hash(blk1.ID()), got.BlockHash)
+	require.JSONEq(`[]`, string(got.Result))
+
+	tx := types.NewTransaction(0, testEthAddrs[1], big.NewInt(10), 21000, big.NewInt(ap0.MinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(tvm.vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	for _, err := range tvm.vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-tvm.toEngine
+	blk2, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk2.Verify(context.Background()))
+	require.Empty(sink.C, "traces must not be published before accept")
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk2.ID()))
+	require.NoError(blk2.Accept(context.Background()))
+
+	got = <-sink.C
+	require.Equal(tracecache.EventAccepted, got.Type)
+	require.Equal(common.Hash(blk2.ID()), got.BlockHash)
+	require.Equal(int64(blk2.Height()), got.BlockNumber)
+	var results []struct {
+		TxHash common.Hash                `json:"txHash"`
+		Result map[string]json.RawMessage `json:"result"`
+	}
+	require.NoError(json.Unmarshal(got.Result, &results))
+	require.Len(results, 1)
+	require.Equal(signedTx.Hash(), results[0].TxHash)
+	require.Contains(results[0].Result, "callTracer")
+	require.Contains(results[0].Result, "prestateTracer")
+	var transfers []map[string]interface{}
+	require.NoError(json.Unmarshal(results[0].Result["transferTracer"], &transfers))
+	require.NotEmpty(transfers)
+	require.Equal("call", transfers[0]["type"])
+	require.Equal("0xa", transfers[0]["amount"])
+}
+
+func TestLiveTracerCreationFailure(t *testing.T) {
+	require := require.New(t)
+
+	sink := tracecache.NewChanSink(16)
+	require.NoError(tracecache.StartWithSink(context.Background(), sink, nil))
+	defer tracecache.Stop()
+
+	importAmount := uint64(20000000)
+	fork := upgradetest.ApricotPhase2
+	tvm := newVM(t, testVMConfig{
+		fork:       &fork,
+		configJSON: `{"live-tracers":[{"name":"callTracer"}]}`,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: importAmount,
+		},
+	})
+	defer func() {
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	tvm.vm.txPool.SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(tvm.vm.mempool.AddLocalTx(importTx))
+	<-tvm.toEngine
+	blk1, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk1.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk1.ID()))
+	require.NoError(blk1.Accept(context.Background()))
+	<-newTxPoolHeadChan
+	<-sink.C
+
+	tx := types.NewTransaction(0, testEthAddrs[1], big.NewInt(10), 21000, big.NewInt(ap0.MinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(tvm.vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	for _, err := range tvm.vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-tvm.toEngine
+	blk2, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+
+	// The tracer of the tx can't be created, which is reported in its entry
+	// rather than leaving a gap in the published traces.
+	tvm.vm.liveTracer = &liveTracer{name: "callTracer", config: json.RawMessage(`{"withLog":"yes"}`)}
+	require.NoError(blk2.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk2.ID()))
+	require.NoError(blk2.Accept(context.Background()))
+
+	got := <-sink.C
+	require.Equal(common.Hash(blk2.ID()), got.BlockHash)
+	var results []txTraceResult
+	require.NoError(json.Unmarshal(got.Result, &results))
+	require.Len(results, 1)
+	require.Equal(signedTx.Hash(), results[0].TxHash)
+	require.Nil(results[0].Result)
+	require.Contains(results[0].Error, "failed to create tracer")
+}
+
+func TestMarshalTraceResultsFallback(t *testing.T) {
+	require := require.New(t)
+
+	results := []*txTraceResult{
+		{TxHash: common.Hash{1}, Result: json.RawMessage(`{"ok":true}`)},
+		{TxHash: common.Hash{2}, Result: json.RawMessage(`{invalid`)},
+	}
+	_, err := json.Marshal(results)
+	require.Error(err)
+
+	// The results which can be encoded are kept, the others are reported.
+	var decoded []txTraceResult
+	require.NoError(json.Unmarshal(marshalTraceResults(results), &decoded))
+	require.Len(decoded, 2)
+	require.Equal(common.Hash{1}, decoded[0].TxHash)
+	require.Equal(map[string]interface{}{"ok": true}, decoded[0].Result)
+	require.Empty(decoded[0].Error)
+	require.Equal(common.Hash{2}, decoded[1].TxHash)
+	require.Nil(decoded[1].Result)
+	require.Contains(decoded[1].Error, "failed to marshal trace result")
+
+	require.JSONEq(`[{"error":"boom"}]`, string(atomicTracesError(errors.New("boom"))))
+}
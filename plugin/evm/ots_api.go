@@ -0,0 +1,310 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+
+	"github.com/ava-labs/coreth/core/addressindex"
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/internal/ethapi"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+// otsAPILevel is the version of the Otterscan API implemented by OtsAPI
+const otsAPILevel = 8
+
+var errZeroPageSize = errors.New("page size must be positive")
+
+// types of the internal operations returned by ots_getInternalOperations
+const (
+	otsOpTransfer     = 0
+	otsOpSelfDestruct = 1
+	otsOpCreate       = 2
+	otsOpCreate2      = 3
+)
+
+// InternalOperation is a value transfer, selfdestruct or contract creation
+// performed by a contract during a tx
+type InternalOperation struct {
+	Type  int            `json:"type"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// ContractCreator is the creation tx and the creator of a contract
+type ContractCreator struct {
+	Hash    common.Hash    `json:"hash"`
+	Creator common.Address `json:"creator"`
+}
+
+// TransactionsWithReceipts is a page of the txs of an address, the most
+// recent first. FirstPage is set on the page of the most recent txs and
+// LastPage on the page of the oldest txs.
+type TransactionsWithReceipts struct {
+	Txs       []*ethapi.RPCTransaction `json:"txs"`
+	Receipts  []map[string]interface{} `json:"receipts"`
+	FirstPage bool                     `json:"firstPage"`
+	LastPage  bool                     `json:"lastPage"`
+}
+
+// otsCallFrame is the part of the callTracer output used by OtsAPI
+type otsCallFrame struct {
+	Type   string         `json:"type"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Output hexutil.Bytes  `json:"output"`
+	Error  string         `json:"error"`
+	Calls  []otsCallFrame `json:"calls"`
+}
+
+// OtsAPI implements the Otterscan API over the address index and the
+// tracers, so that an Otterscan block explorer can run against the node.
+type OtsAPI struct {
+	vm *VM
+}
+
+// GetApiLevel returns the version of the Otterscan API, which Otterscan
+// checks on startup
+func (api *OtsAPI) GetApiLevel() uint64 {
+	return otsAPILevel
+}
+
+// HasCode returns whether [addr] has code at [blockNrOrHash]
+func (api *OtsAPI) HasCode(ctx context.Context, addr common.Address, blockNrOrHash rpc.BlockNumberOrHash) (bool, error) {
+	state, _, err := api.vm.eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return false, err
+	}
+	return state.GetCodeSize(addr) > 0, nil
+}
+
+// GetTransactionError returns the revert data of the tx [hash], which is
+// empty if the tx did not revert
+func (api *OtsAPI) GetTransactionError(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
+	frame, err := api.callTrace(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if frame.Error == "" || frame.Output == nil {
+		return hexutil.Bytes{}, nil
+	}
+	return frame.Output, nil
+}
+
+// GetInternalOperations returns the value transfers, selfdestructs and
+// contract creations performed by the contracts called by the tx [hash]
+func (api *OtsAPI) GetInternalOperations(ctx context.Context, hash common.Hash) ([]*InternalOperation, error) {
+	frame, err := api.callTrace(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	ops := []*InternalOperation{}
+	var walk func(frames []otsCallFrame)
+	walk = func(frames []otsCallFrame) {
+		for _, f := range frames {
+			op := &InternalOperation{From: f.From, To: f.To, Value: f.Value}
+			if op.Value == nil {
+				op.Value = new(hexutil.Big)
+			}
+			switch f.Type {
+			case "CALL":
+				op.Type = otsOpTransfer
+			case "SELFDESTRUCT":
+				op.Type = otsOpSelfDestruct
+			case "CREATE":
+				op.Type = otsOpCreate
+			case "CREATE2":
+				op.Type = otsOpCreate2
+			default:
+				op = nil
+			}
+			if op != nil && (op.Type != otsOpTransfer || op.Value.ToInt().Sign() > 0) {
+				ops = append(ops, op)
+			}
+			walk(f.Calls)
+		}
+	}
+	walk(frame.Calls)
+	return ops, nil
+}
+
+// GetContractCreator returns the creation tx and the creator of the contract
+// at [addr], or nil if there is no contract at [addr]. The creation is looked
+// up in the txs of the first block [addr] appears in, so contracts created
+// by another contract are only found if the address index includes traces.
+func (api *OtsAPI) GetContractCreator(ctx context.Context, addr common.Address) (*ContractCreator, error) {
+	hasCode, err := api.HasCode(ctx, addr, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	if err != nil || !hasCode {
+		return nil, err
+	}
+	appearances, _, err := addressindex.ReadAppearancesAfter(api.vm.chaindb, addr, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range appearances {
+		block := api.vm.blockChain.GetBlockByNumber(a.BlockNumber)
+		if block == nil || a.TxIndex >= uint(len(block.Transactions())) {
+			return nil, fmt.Errorf("tx %d of block %d not found", a.TxIndex, a.BlockNumber)
+		}
+		tx := block.Transactions()[a.TxIndex]
+		if tx.To() == nil {
+			signer := types.MakeSigner(api.vm.chainConfig, block.Number(), block.Time())
+			if from, err := types.Sender(signer, tx); err == nil && crypto.CreateAddress(from, tx.Nonce()) == addr {
+				return &ContractCreator{Hash: tx.Hash(), Creator: from}, nil
+			}
+		}
+		frame, err := api.callTrace(ctx, tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if creator, ok := findCreator(frame.Calls, addr); ok {
+			return &ContractCreator{Hash: tx.Hash(), Creator: creator}, nil
+		}
+	}
+	return nil, nil
+}
+
+// findCreator returns the creator of [addr] in [frames] and their subcalls
+func findCreator(frames []otsCallFrame, addr common.Address) (common.Address, bool) {
+	for _, f := range frames {
+		if (f.Type == "CREATE" || f.Type == "CREATE2") && f.To == addr && f.Error == "" {
+			return f.From, true
+		}
+		if creator, ok := findCreator(f.Calls, addr); ok {
+			return creator, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// SearchTransactionsBefore returns a page of the txs of [addr] in the blocks
+// before [blockNum], or in all the accepted blocks if [blockNum] is 0. A
+// page holds at least [pageSize] txs, unless there are fewer, and always
+// ends with a whole block.
+func (api *OtsAPI) SearchTransactionsBefore(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error) {
+	if pageSize == 0 {
+		return nil, errZeroPageSize
+	}
+	firstPage := blockNum == 0
+	if firstPage {
+		blockNum = api.vm.blockChain.LastAcceptedBlock().NumberU64() + 1
+	}
+	appearances, more, err := addressindex.ReadAppearancesBefore(api.vm.chaindb, addr, blockNum, int(pageSize))
+	if err != nil {
+		return nil, err
+	}
+	page, err := api.transactionsWithReceipts(appearances)
+	if err != nil {
+		return nil, err
+	}
+	page.FirstPage, page.LastPage = firstPage, !more
+	return page, nil
+}
+
+// SearchTransactionsAfter returns a page of the txs of [addr] in the blocks
+// after [blockNum], the most recent first. The page holds the oldest txs if
+// [blockNum] is 0. Like SearchTransactionsBefore, a page always ends with a
+// whole block.
+func (api *OtsAPI) SearchTransactionsAfter(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error) {
+	if pageSize == 0 {
+		return nil, errZeroPageSize
+	}
+	appearances, more, err := addressindex.ReadAppearancesAfter(api.vm.chaindb, addr, blockNum+1, int(pageSize))
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(appearances)-1; i < j; i, j = i+1, j-1 {
+		appearances[i], appearances[j] = appearances[j], appearances[i]
+	}
+	page, err := api.transactionsWithReceipts(appearances)
+	if err != nil {
+		return nil, err
+	}
+	page.FirstPage, page.LastPage = !more, blockNum == 0
+	return page, nil
+}
+
+// transactionsWithReceipts returns the txs of [appearances] and their
+// receipts, with the timestamp of their block
+func (api *OtsAPI) transactionsWithReceipts(appearances []addressindex.Appearance) (*TransactionsWithReceipts, error) {
+	page := &TransactionsWithReceipts{
+		Txs:      make([]*ethapi.RPCTransaction, 0, len(appearances)),
+		Receipts: make([]map[string]interface{}, 0, len(appearances)),
+	}
+	var (
+		block    *types.Block
+		receipts types.Receipts
+	)
+	for _, a := range appearances {
+		if block == nil || block.NumberU64() != a.BlockNumber {
+			block = api.vm.blockChain.GetBlockByNumber(a.BlockNumber)
+			if block == nil {
+				return nil, fmt.Errorf("accepted block %d not found", a.BlockNumber)
+			}
+			receipts = api.vm.blockChain.GetReceiptsByHash(block.Hash())
+		}
+		txs := block.Transactions()
+		if a.TxIndex >= uint(len(txs)) || a.TxIndex >= uint(len(receipts)) {
+			return nil, fmt.Errorf("tx %d of block %d not found", a.TxIndex, a.BlockNumber)
+		}
+		signer := types.MakeSigner(api.vm.chainConfig, block.Number(), block.Time())
+		receipt := ethapi.RPCMarshalReceipt(receipts[a.TxIndex], block.Hash(), block.NumberU64(), signer, txs[a.TxIndex], int(a.TxIndex))
+		receipt["timestamp"] = hexutil.Uint64(block.Time())
+		page.Txs = append(page.Txs, ethapi.NewRPCTransactionFromBlockIndex(block, uint64(a.TxIndex), api.vm.chainConfig))
+		page.Receipts = append(page.Receipts, receipt)
+	}
+	return page, nil
+}
+
+// callTrace returns the callTracer output of the tx [hash]
+func (api *OtsAPI) callTrace(ctx context.Context, hash common.Hash) (*otsCallFrame, error) {
+	tracer := "callTracer"
+	res, err := tracers.NewAPI(api.vm.eth.APIBackend).TraceTransaction(ctx, hash, &tracers.TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected callTracer result %T", res)
+	}
+	var frame otsCallFrame
+	if err := json.Unmarshal(raw, &frame); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// traceAddresses implements addressindex.TraceFunc with the addressTracer
+func (vm *VM) traceAddresses(block *types.Block) ([][]common.Address, error) {
+	tracer := "addressTracer"
+	results, err := tracers.NewAPI(vm.eth.APIBackend).TraceBlockByHash(context.Background(), block.Hash(), &tracers.TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([][]common.Address, len(results))
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("failed to trace tx %s: %s", res.TxHash, res.Error)
+		}
+		raw, ok := res.Result.(json.RawMessage)
+		if !ok {
+			return nil, fmt.Errorf("unexpected addressTracer result %T", res.Result)
+		}
+		if err := json.Unmarshal(raw, &addresses[i]); err != nil {
+			return nil, err
+		}
+	}
+	return addresses, nil
+}
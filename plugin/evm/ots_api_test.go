@@ -0,0 +1,130 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade/upgradetest"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/plugin/evm/upgrade/ap0"
+	"github.com/ava-labs/coreth/rpc"
+)
+
+func TestOtsAPI(t *testing.T) {
+	require := require.New(t)
+
+	fork := upgradetest.ApricotPhase2
+	tvm := newVM(t, testVMConfig{
+		fork:       &fork,
+		configJSON: `{"address-index-enabled": true, "address-index-traces": true}`,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: 1_000_000_000,
+		},
+	})
+	defer func() {
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+	api := &OtsAPI{tvm.vm}
+	ctx := context.Background()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	tvm.vm.txPool.SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(tvm.vm.mempool.AddLocalTx(importTx))
+	<-tvm.toEngine
+	blk1, err := tvm.vm.BuildBlock(ctx)
+	require.NoError(err)
+	require.NoError(blk1.Verify(ctx))
+	require.NoError(tvm.vm.SetPreference(ctx, blk1.ID()))
+	require.NoError(blk1.Accept(ctx))
+	<-newTxPoolHeadChan
+
+	// The deployed contract creates a child contract, both with code 0x00
+	const childInit = "600060005360016000f3"
+	deploy := common.FromHex("69" + childInit + "600052600a60166000f050" + childInit)
+	tx := types.NewContractCreation(0, big.NewInt(0), 300_000, big.NewInt(ap0.MinGasPrice), deploy)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(tvm.vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	for _, err := range tvm.vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-tvm.toEngine
+	blk2, err := tvm.vm.BuildBlock(ctx)
+	require.NoError(err)
+	require.NoError(blk2.Verify(ctx))
+	require.NoError(tvm.vm.SetPreference(ctx, blk2.ID()))
+	require.NoError(blk2.Accept(ctx))
+	<-newTxPoolHeadChan
+	tvm.vm.blockChain.DrainAcceptorQueue()
+
+	var (
+		parent = crypto.CreateAddress(testEthAddrs[0], 0)
+		child  = crypto.CreateAddress(parent, 1)
+	)
+	require.Equal(uint64(otsAPILevel), api.GetApiLevel())
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	for _, addr := range []common.Address{parent, child} {
+		hasCode, err := api.HasCode(ctx, addr, latest)
+		require.NoError(err)
+		require.True(hasCode)
+	}
+
+	// The child only appears in the traces of the tx
+	var page *TransactionsWithReceipts
+	require.Eventually(func() bool {
+		page, err = api.SearchTransactionsBefore(ctx, child, 0, 25)
+		require.NoError(err)
+		return len(page.Txs) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(signedTx.Hash(), page.Txs[0].Hash)
+	require.EqualValues(blk2.Height(), page.Receipts[0]["blockNumber"])
+	require.Contains(page.Receipts[0], "timestamp")
+	require.True(page.FirstPage)
+	require.True(page.LastPage)
+
+	page, err = api.SearchTransactionsAfter(ctx, testEthAddrs[0], 0, 25)
+	require.NoError(err)
+	require.Len(page.Txs, 1)
+	require.True(page.FirstPage)
+	require.True(page.LastPage)
+	page, err = api.SearchTransactionsBefore(ctx, testEthAddrs[0], blk2.Height(), 25)
+	require.NoError(err)
+	require.Empty(page.Txs)
+	_, err = api.SearchTransactionsBefore(ctx, testEthAddrs[0], 0, 0)
+	require.ErrorIs(err, errZeroPageSize)
+
+	ops, err := api.GetInternalOperations(ctx, signedTx.Hash())
+	require.NoError(err)
+	require.Len(ops, 1)
+	require.Equal(otsOpCreate, ops[0].Type)
+	require.Equal(parent, ops[0].From)
+	require.Equal(child, ops[0].To)
+
+	revert, err := api.GetTransactionError(ctx, signedTx.Hash())
+	require.NoError(err)
+	require.Empty(revert)
+
+	creator, err := api.GetContractCreator(ctx, parent)
+	require.NoError(err)
+	require.Equal(&ContractCreator{Hash: signedTx.Hash(), Creator: testEthAddrs[0]}, creator)
+	creator, err = api.GetContractCreator(ctx, child)
+	require.NoError(err)
+	require.Equal(&ContractCreator{Hash: signedTx.Hash(), Creator: parent}, creator)
+	creator, err = api.GetContractCreator(ctx, testEthAddrs[1])
+	require.NoError(err)
+	require.Nil(creator)
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/hexutil"
+
+	"github.com/ava-labs/coreth/core/tokenindex"
+)
+
+const (
+	defaultTokenTransfersLimit = 100
+	maxTokenTransfersLimit     = 1000
+)
+
+// TokenTransfersQuery selects a page of token transfers. The blocks default
+// to the whole accepted chain and the limit to 100 transfers.
+type TokenTransfersQuery struct {
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock"`
+	// Cursor is the next cursor returned with the previous page
+	Cursor hexutil.Bytes `json:"cursor"`
+	Limit  *hexutil.Uint `json:"limit"`
+}
+
+// TokenAPI serves the ERC-20/721/1155 transfers of the accepted chain,
+// which are much faster to page through than eth_getLogs over wide block
+// ranges. The transfers are returned in chain order.
+type TokenAPI struct {
+	vm *VM
+}
+
+// GetTransfersByToken returns the transfers of the [token] contract
+func (api *TokenAPI) GetTransfersByToken(ctx context.Context, token common.Address, query *TokenTransfersQuery) (*tokenindex.Page, error) {
+	from, to, limit, err := api.parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return tokenindex.ReadTransfersByToken(api.vm.chaindb, token, from, to, cursorOf(query), limit)
+}
+
+// GetTransfersByAddress returns the transfers sent or received by [addr]
+func (api *TokenAPI) GetTransfersByAddress(ctx context.Context, addr common.Address, query *TokenTransfersQuery) (*tokenindex.Page, error) {
+	from, to, limit, err := api.parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return tokenindex.ReadTransfersByAddress(api.vm.chaindb, addr, from, to, cursorOf(query), limit)
+}
+
+// IndexStatus returns the progress of the index. The transfers of the
+// blocks neither backfilled nor indexed live are missing until it is
+// complete.
+func (api *TokenAPI) IndexStatus() tokenindex.Status {
+	return api.vm.tokenIndexer.Status()
+}
+
+func (api *TokenAPI) parseQuery(query *TokenTransfersQuery) (uint64, uint64, int, error) {
+	var (
+		from  uint64
+		to    = api.vm.blockChain.LastAcceptedBlock().NumberU64()
+		limit = defaultTokenTransfersLimit
+	)
+	if query == nil {
+		return from, to, limit, nil
+	}
+	if query.FromBlock != nil {
+		from = uint64(*query.FromBlock)
+	}
+	if query.ToBlock != nil && uint64(*query.ToBlock) < to {
+		to = uint64(*query.ToBlock)
+	}
+	if from > to {
+		return 0, 0, 0, fmt.Errorf("fromBlock %d is after toBlock %d", from, to)
+	}
+	if query.Limit != nil {
+		limit = int(*query.Limit)
+		if limit == 0 || limit > maxTokenTransfersLimit {
+			return 0, 0, 0, fmt.Errorf("limit must be in [1, %d]", maxTokenTransfersLimit)
+		}
+	}
+	return from, to, limit, nil
+}
+
+func cursorOf(query *TokenTransfersQuery) []byte {
+	if query == nil || len(query.Cursor) == 0 {
+		return nil
+	}
+	return query.Cursor
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	avajson "github.com/ava-labs/avalanchego/utils/json"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/kclients/tracecache"
+	"github.com/ava-labs/coreth/plugin/evm/client"
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+)
+
+const (
+	defaultTraceBackfillThreads = 4
+	// traceBackfillTimeout bounds the tracing of a single transaction. It is
+	// more generous than the RPC default since backfills run unattended.
+	traceBackfillTimeout = "1m"
+)
+
+var (
+	errTraceBackfillRunning = errors.New("a trace backfill is already running")
+	errLiveTracingDisabled  = errors.New("live tracing is disabled")
+)
+
+// traceBackfiller re-executes ranges of accepted blocks with the live
+// tracers and publishes the results through tracecache, as if they had been
+// produced when the blocks were accepted. The progress is persisted after
+// every block so that an interrupted backfill resumes after a restart.
+type traceBackfiller struct {
+	api          *tracers.API
+	tracer       *liveTracer
+	db           ethdb.KeyValueStore
+	reexec       uint64
+	lastAccepted func() uint64
+	// atomicTraces returns the traced atomic txs of an accepted block
+	atomicTraces func(header *types.Header) ([]byte, error)
+
+	lock     sync.Mutex
+	progress *customrawdb.TraceBackfillProgress // progress of the last backfill
+	running  bool
+	err      error // error which stopped the last backfill
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func newTraceBackfiller(api *tracers.API, tracer *liveTracer, db ethdb.KeyValueStore, reexec uint64, lastAccepted func() uint64, atomicTraces func(*types.Header) ([]byte, error)) *traceBackfiller {
+	return &traceBackfiller{
+		api:          api,
+		tracer:       tracer,
+		db:           db,
+		reexec:       reexec,
+		lastAccepted: lastAccepted,
+		atomicTraces: atomicTraces,
+	}
+}
+
+// start starts a backfill of the blocks [start, end].
+func (b *traceBackfiller) start(start, end uint64, threads int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch {
+	case b.running:
+		return errTraceBackfillRunning
+	case b.tracer == nil:
+		return errLiveTracingDisabled
+	case !tracecache.Started():
+		return tracecache.ErrNotStarted
+	case start == 0:
+		return errors.New("cannot backfill the traces of the genesis block")
+	case start > end:
+		return fmt.Errorf("start block (#%d) must not be after end block (#%d)", start, end)
+	case end > b.lastAccepted():
+		return fmt.Errorf("end block (#%d) is after the last accepted block (#%d)", end, b.lastAccepted())
+	}
+	if threads <= 0 {
+		threads = defaultTraceBackfillThreads
+	}
+	progress := &customrawdb.TraceBackfillProgress{
+		Start:   start,
+		End:     end,
+		Next:    start,
+		Threads: uint64(threads),
+	}
+	if err := customrawdb.WriteTraceBackfillProgress(b.db, progress); err != nil {
+		return err
+	}
+	b.run(progress)
+	return nil
+}
+
+// resume resumes the backfill interrupted by the last shutdown, if any.
+func (b *traceBackfiller) resume() error {
+	progress, err := customrawdb.ReadTraceBackfillProgress(b.db)
+	if err != nil || progress == nil {
+		return err
+	}
+	if progress.Next > progress.End {
+		return customrawdb.DeleteTraceBackfillProgress(b.db)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.running {
+		return nil
+	}
+	if b.tracer == nil || !tracecache.Started() {
+		log.Warn("Not resuming trace backfill without live tracing", "start", progress.Start, "end", progress.End, "next", progress.Next)
+		return nil
+	}
+	log.Info("Resuming trace backfill", "start", progress.Start, "end", progress.End, "next", progress.Next)
+	b.run(progress)
+	return nil
+}
+
+// run starts tracing from [progress.Next] in the background. It assumes the
+// lock is held.
+func (b *traceBackfiller) run(progress *customrawdb.TraceBackfillProgress) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.progress = progress
+	b.running = true
+	b.err = nil
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	timeout := traceBackfillTimeout
+	config := &tracers.TraceConfig{
+		Tracer:       &b.tracer.name,
+		TracerConfig: b.tracer.config,
+		Timeout:      &timeout,
+		Reexec:       &b.reexec,
+	}
+
+	go func() {
+		defer close(b.done)
+		defer cancel()
+
+		err := b.api.TraceChainRange(ctx, progress.Next-1, progress.End, config, int(progress.Threads), func(header *types.Header, traces []byte) error {
+			atomicTxs, err := b.atomicTraces(header)
+			if err != nil {
+				return err
+			}
+			if err := tracecache.Publish(ctx, header, b.tracer.name, traces, atomicTxs); err != nil {
+				return err
+			}
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			b.progress.Next = header.Number.Uint64() + 1
+			return customrawdb.WriteTraceBackfillProgress(b.db, b.progress)
+		})
+
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.running = false
+		b.err = err
+		switch {
+		case err == nil:
+			log.Info("Trace backfill finished", "start", progress.Start, "end", progress.End)
+			if err := customrawdb.DeleteTraceBackfillProgress(b.db); err != nil {
+				log.Error("Failed to delete trace backfill progress", "err", err)
+			}
+		case errors.Is(err, context.Canceled):
+			log.Info("Trace backfill stopped", "start", progress.Start, "end", progress.End, "next", b.progress.Next)
+		default:
+			log.Error("Trace backfill failed", "start", progress.Start, "end", progress.End, "next", b.progress.Next, "err", err)
+		}
+	}()
+}
+
+// stop interrupts the running backfill, if any, and waits for it to exit.
+// The progress is kept so that the backfill resumes after a restart.
+func (b *traceBackfiller) stop() {
+	b.lock.Lock()
+	if !b.running {
+		b.lock.Unlock()
+		return
+	}
+	cancel, done := b.cancel, b.done
+	b.lock.Unlock()
+
+	cancel()
+	<-done
+}
+
+// abort stops the running backfill, if any, and drops its progress so that
+// it is not resumed.
+func (b *traceBackfiller) abort() error {
+	b.stop()
+	return customrawdb.DeleteTraceBackfillProgress(b.db)
+}
+
+// status returns the progress of the last backfill
+func (b *traceBackfiller) status() client.TraceBackfillStatus {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	status := client.TraceBackfillStatus{Running: b.running}
+	if b.progress != nil {
+		status.Start = avajson.Uint64(b.progress.Start)
+		status.End = avajson.Uint64(b.progress.End)
+		status.Next = avajson.Uint64(b.progress.Next)
+		status.Threads = int(b.progress.Threads)
+	}
+	if b.err != nil {
+		status.Error = b.err.Error()
+	}
+	return status
+}
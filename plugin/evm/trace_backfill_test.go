@@ -0,0 +1,189 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/upgrade/upgradetest"
+	"github.com/ava-labs/avalanchego/utils/crypto/secp256k1"
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/kclients/tracecache"
+	"github.com/ava-labs/coreth/plugin/evm/atomic"
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
+	"github.com/ava-labs/coreth/plugin/evm/upgrade/ap0"
+)
+
+func TestTraceBackfill(t *testing.T) {
+	require := require.New(t)
+
+	sink := tracecache.NewChanSink(16)
+	require.NoError(tracecache.StartWithSink(context.Background(), sink, nil))
+	defer tracecache.Stop()
+
+	fork := upgradetest.ApricotPhase2
+	// Archive the state so that blocks with atomic txs are not re-executed
+	tvm := newVM(t, testVMConfig{
+		fork:       &fork,
+		configJSON: `{"pruning-enabled":false}`,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: 20000000,
+		},
+	})
+	defer func() {
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+
+	newTxPoolHeadChan := make(chan core.NewTxPoolReorgEvent, 1)
+	tvm.vm.txPool.SubscribeNewReorgEvent(newTxPoolHeadChan)
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	require.NoError(err)
+	require.NoError(tvm.vm.mempool.AddLocalTx(importTx))
+	<-tvm.toEngine
+	blk1, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk1.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk1.ID()))
+	require.NoError(blk1.Accept(context.Background()))
+	<-newTxPoolHeadChan
+
+	tx := types.NewTransaction(0, testEthAddrs[1], big.NewInt(10), 21000, big.NewInt(ap0.MinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(tvm.vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	for _, err := range tvm.vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-tvm.toEngine
+	blk2, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk2.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk2.ID()))
+	require.NoError(blk2.Accept(context.Background()))
+
+	live := []tracecache.Trace{<-sink.C, <-sink.C}
+	// the import tx of block 1 credits testEthAddrs[0]
+	var atomicTxs []*atomicTxTrace
+	require.NoError(json.Unmarshal(live[0].AtomicTxs, &atomicTxs))
+	require.Len(atomicTxs, 1)
+	require.Equal(importTx.ID(), atomicTxs[0].TxID)
+	require.Equal("import", atomicTxs[0].Type)
+	require.Equal([]common.Address{testEthAddrs[0]}, atomicTxs[0].Addresses)
+	require.Len(atomicTxs[0].BalanceDeltas, 1)
+	credited := new(big.Int).Mul(new(big.Int).SetUint64(importTx.UnsignedAtomicTx.(*atomic.UnsignedImportTx).Outs[0].Amount), atomic.X2CRate.ToBig())
+	require.Equal(credited, atomicTxs[0].BalanceDeltas[0].Delta.ToInt())
+	require.Len(atomicTxs[0].Burned, 1)
+	require.Equal(tvm.vm.ctx.AVAXAssetID, atomicTxs[0].Burned[0].AssetID)
+	require.Empty(live[1].AtomicTxs)
+	tvm.vm.blockChain.DrainAcceptorQueue()
+
+	backfiller := tvm.vm.traceBackfiller
+	require.ErrorContains(backfiller.start(1, 3, 1), "after the last accepted block")
+	require.NoError(backfiller.start(1, 2, 1))
+	for _, want := range live {
+		got := <-sink.C
+		require.Equal(want.BlockHash, got.BlockHash)
+		require.Equal(want.BlockNumber, got.BlockNumber)
+		require.Equal(want.ParentHash, got.ParentHash)
+		require.Equal(want.Timestamp, got.Timestamp)
+		require.Equal(want.Tracer, got.Tracer)
+		require.JSONEq(string(want.Result), string(got.Result))
+		require.Equal(want.AtomicTxs, got.AtomicTxs)
+	}
+	require.Eventually(func() bool {
+		return !backfiller.status().Running
+	}, 5*time.Second, 10*time.Millisecond)
+	status := backfiller.status()
+	require.Empty(status.Error)
+	require.EqualValues(3, status.Next)
+
+	// The progress of a finished backfill is not kept
+	progress, err := customrawdb.ReadTraceBackfillProgress(tvm.vm.chaindb)
+	require.NoError(err)
+	require.Nil(progress)
+
+	// An interrupted backfill resumes from its persisted progress
+	require.NoError(customrawdb.WriteTraceBackfillProgress(tvm.vm.chaindb, &customrawdb.TraceBackfillProgress{
+		Start:   1,
+		End:     2,
+		Next:    2,
+		Threads: 1,
+	}))
+	require.NoError(backfiller.resume())
+	got := <-sink.C
+	require.Equal(live[1].BlockHash, got.BlockHash)
+	backfiller.stop()
+}
+
+// downSink fails every send
+type downSink struct{}
+
+func (downSink) Send(context.Context, tracecache.Trace) error { return errors.New("sink unavailable") }
+
+func (downSink) Close() error { return nil }
+
+func TestTraceBackfillStopWhileSinkDown(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(tracecache.StartWithSink(context.Background(), downSink{}, nil))
+	defer tracecache.Stop()
+
+	tvm := newVM(t, testVMConfig{configJSON: `{"pruning-enabled":false}`})
+	defer func() {
+		require.NoError(tvm.vm.Shutdown(context.Background()))
+	}()
+
+	tx := types.NewTransaction(0, testEthAddrs[1], big.NewInt(10), 21000, big.NewInt(ap0.MinGasPrice), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(tvm.vm.chainID), testKeys[0].ToECDSA())
+	require.NoError(err)
+	for _, err := range tvm.vm.txPool.AddRemotesSync([]*types.Transaction{signedTx}) {
+		require.NoError(err)
+	}
+	<-tvm.toEngine
+	blk, err := tvm.vm.BuildBlock(context.Background())
+	require.NoError(err)
+	require.NoError(blk.Verify(context.Background()))
+	require.NoError(tvm.vm.SetPreference(context.Background(), blk.ID()))
+	require.NoError(blk.Accept(context.Background()))
+	tvm.vm.blockChain.DrainAcceptorQueue()
+
+	// Fill the backlog so that the backfill waits for the sink
+	header := &types.Header{Number: big.NewInt(1)}
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := tracecache.Publish(ctx, header, "", nil, nil)
+		cancel()
+		if err != nil {
+			require.ErrorIs(err, context.DeadlineExceeded)
+			break
+		}
+	}
+
+	backfiller := tvm.vm.traceBackfiller
+	require.NoError(backfiller.start(1, 1, 1))
+	stopped := make(chan struct{})
+	go func() {
+		backfiller.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		require.FailNow("backfill did not stop")
+	}
+	status := backfiller.status()
+	require.False(status.Running)
+	require.EqualValues(1, status.Next)
+	require.Contains(status.Error, context.Canceled.Error())
+}
@@ -29,10 +29,16 @@ import (
 	"github.com/ava-labs/coreth/consensus/dummy"
 	"github.com/ava-labs/coreth/constants"
 	"github.com/ava-labs/coreth/core"
+	"github.com/ava-labs/coreth/core/addressindex"
 	"github.com/ava-labs/coreth/core/state"
+	"github.com/ava-labs/coreth/core/tokenindex"
 	"github.com/ava-labs/coreth/core/txpool"
 	"github.com/ava-labs/coreth/eth"
 	"github.com/ava-labs/coreth/eth/ethconfig"
+	"github.com/ava-labs/coreth/eth/tracers"
+	"github.com/ava-labs/coreth/kclients/pause"
+	"github.com/ava-labs/coreth/kclients/syncstatus"
+	"github.com/ava-labs/coreth/kclients/tracecache"
 	corethprometheus "github.com/ava-labs/coreth/metrics/prometheus"
 	"github.com/ava-labs/coreth/miner"
 	"github.com/ava-labs/coreth/node"
@@ -42,6 +48,7 @@ import (
 	atomicstate "github.com/ava-labs/coreth/plugin/evm/atomic/state"
 	atomictxpool "github.com/ava-labs/coreth/plugin/evm/atomic/txpool"
 	"github.com/ava-labs/coreth/plugin/evm/config"
+	"github.com/ava-labs/coreth/plugin/evm/customrawdb"
 	customheader "github.com/ava-labs/coreth/plugin/evm/header"
 	corethlog "github.com/ava-labs/coreth/plugin/evm/log"
 	"github.com/ava-labs/coreth/plugin/evm/message"
@@ -69,6 +76,8 @@ import (
 	// inside of cmd/geth.
 	_ "github.com/ava-labs/libevm/eth/tracers/js"
 	_ "github.com/ava-labs/libevm/eth/tracers/native"
+	// coreth's own native tracers, eg. the "transferTracer"
+	_ "github.com/ava-labs/coreth/eth/tracers/native"
 
 	"github.com/ava-labs/coreth/precompile/precompileconfig"
 	// Force-load precompiles to trigger registration
@@ -266,6 +275,18 @@ type VM struct {
 
 	builder *blockBuilder
 
+	// [liveTracer] creates the tracers whose results are published by the
+	// live trace pipeline. It is nil if live tracing is disabled.
+	liveTracer *liveTracer
+	// [traceBackfiller] publishes the live traces of historical blocks
+	traceBackfiller *traceBackfiller
+	// [tokenIndexer] indexes the token transfers of the accepted chain. It
+	// is nil if the token index is disabled.
+	tokenIndexer *tokenindex.Indexer
+	// [addressIndexer] indexes the txs of the accepted chain by address. It
+	// is nil if the address index is disabled.
+	addressIndexer *addressindex.Indexer
+
 	baseCodec codec.Registry
 	clock     mockable.Clock
 	mempool   *atomictxpool.Mempool
@@ -375,6 +396,11 @@ func (vm *VM) Initialize(
 	// Enable debug-level metrics that might impact runtime performance
 	metrics.EnabledExpensive = vm.config.MetricsExpensiveEnabled
 
+	vm.liveTracer, err = newLiveTracer(vm.config.LiveTracers)
+	if err != nil {
+		return err
+	}
+
 	vm.toEngine = toEngine
 	vm.shutdownChan = make(chan struct{}, 1)
 
@@ -392,6 +418,16 @@ func (vm *VM) Initialize(
 		}
 	}
 
+	// Start the ETL services before the chain processes any block
+	syncstatus.Start(vm.config.SyncStatusConfig())
+	traceQueueDB := rawdb.NewTable(vm.chaindb, string(customrawdb.TraceQueuePrefix))
+	if err := tracecache.Start(context.Background(), vm.config.TraceCacheConfig(), traceQueueDB); err != nil {
+		return fmt.Errorf("failed to start trace cache: %w", err)
+	}
+	if err := pause.Start(vm.config.PauseConfig()); err != nil {
+		return fmt.Errorf("failed to start pause control: %w", err)
+	}
+
 	g, err := parseGenesis(chainCtx, genesisBytes)
 	if err != nil {
 		return err
@@ -450,6 +486,9 @@ func (vm *VM) Initialize(
 	vm.ethConfig.AllowUnprotectedTxHashes = vm.config.AllowUnprotectedTxHashes
 	vm.ethConfig.Preimages = vm.config.Preimages
 	vm.ethConfig.Pruning = vm.config.Pruning
+	vm.ethConfig.StateScheme = vm.config.StateScheme
+	vm.ethConfig.StateHistory = vm.config.StateHistory
+	vm.ethConfig.HistoricStateDepth = vm.config.HistoricStateDepth
 	vm.ethConfig.TrieCleanCache = vm.config.TrieCleanCache
 	vm.ethConfig.TrieDirtyCache = vm.config.TrieDirtyCache
 	vm.ethConfig.TrieDirtyCommitTarget = vm.config.TrieDirtyCommitTarget
@@ -466,6 +505,10 @@ func (vm *VM) Initialize(
 	vm.ethConfig.OfflinePruning = vm.config.OfflinePruning
 	vm.ethConfig.OfflinePruningBloomFilterSize = vm.config.OfflinePruningBloomFilterSize
 	vm.ethConfig.OfflinePruningDataDirectory = vm.config.OfflinePruningDataDirectory
+	vm.ethConfig.OnlinePruning = vm.config.OnlinePruning
+	vm.ethConfig.OnlinePruningInterval = vm.config.OnlinePruningInterval.Duration
+	vm.ethConfig.OnlinePruningBloomFilterSize = vm.config.OnlinePruningBloomFilterSize
+	vm.ethConfig.OnlinePruningBatchDelay = vm.config.OnlinePruningBatchDelay.Duration
 	vm.ethConfig.CommitInterval = vm.config.CommitInterval
 	vm.ethConfig.SkipUpgradeCheck = vm.config.SkipUpgradeCheck
 	vm.ethConfig.AcceptedCacheSize = vm.config.AcceptedCacheSize
@@ -528,6 +571,27 @@ func (vm *VM) Initialize(
 	if err := vm.initializeChain(lastAcceptedHash); err != nil {
 		return err
 	}
+	if vm.config.TraceCacheStateDiff && tracecache.Started() {
+		vm.blockChain.SetStateDiffHook(bufferStateDiff)
+	}
+	vm.traceBackfiller = newTraceBackfiller(
+		tracers.NewAPI(vm.eth.APIBackend),
+		vm.liveTracer,
+		vm.chaindb,
+		vm.config.CommitInterval,
+		func() uint64 { return vm.blockChain.LastAcceptedBlock().NumberU64() },
+		vm.atomicTracesByHeader,
+	)
+	if vm.config.TokenIndexEnabled {
+		vm.tokenIndexer = tokenindex.New(vm.chaindb, vm.blockChain, vm.config.TokenIndexSectionSize)
+	}
+	if vm.config.AddressIndexEnabled {
+		var trace addressindex.TraceFunc
+		if vm.config.AddressIndexTraces {
+			trace = vm.traceAddresses
+		}
+		vm.addressIndexer = addressindex.New(vm.chaindb, vm.blockChain, vm.config.AddressIndexSectionSize, trace)
+	}
 	// initialize bonus blocks on mainnet
 	var (
 		bonusBlockHeights map[uint64]ids.ID
@@ -544,6 +608,11 @@ func (vm *VM) Initialize(
 	if err != nil {
 		return fmt.Errorf("failed to create atomic repository: %w", err)
 	}
+	if vm.config.AtomicTxAddressIndexEnabled {
+		if err := vm.atomicTxRepository.InitializeAddressIndex(lastAcceptedHeight); err != nil {
+			return fmt.Errorf("failed to initialize atomic tx address index: %w", err)
+		}
+	}
 	vm.atomicBackend, err = atomicstate.NewAtomicBackend(
 		vm.ctx.SharedMemory, bonusBlockHeights,
 		vm.atomicTxRepository, lastAcceptedHeight, lastAcceptedHash,
@@ -1042,6 +1111,12 @@ func (vm *VM) onNormalOperationsStarted() error {
 	if err := vm.fx.Bootstrapped(); err != nil {
 		return err
 	}
+	// Start online pruning once the state can no longer be written by the state syncer
+	vm.blockChain.StartOnlinePruning()
+	// Resume the trace backfill interrupted by the last shutdown, if any
+	if err := vm.traceBackfiller.resume(); err != nil {
+		return fmt.Errorf("failed to resume trace backfill: %w", err)
+	}
 	// Initialize goroutines related to block building
 	// once we enter normal operation as there is no need to handle mempool gossip before this point.
 	return vm.initBlockBuilding()
@@ -1258,10 +1333,27 @@ func (vm *VM) Shutdown(context.Context) error {
 		log.Error("error stopping state syncer", "err", err)
 	}
 	close(vm.shutdownChan)
+	// Release block processing paused on the consumer
+	pause.Stop()
 	// Stop RPC handlers before eth.Stop which will close the database
 	for _, handler := range vm.rpcHandlers {
 		handler.Stop()
 	}
+	if vm.traceBackfiller != nil {
+		vm.traceBackfiller.stop()
+	}
+	// Flush the queued traces before the database is closed
+	tracecache.Stop()
+	if vm.tokenIndexer != nil {
+		if err := vm.tokenIndexer.Close(); err != nil {
+			log.Error("error stopping token indexer", "err", err)
+		}
+	}
+	if vm.addressIndexer != nil {
+		if err := vm.addressIndexer.Close(); err != nil {
+			log.Error("error stopping address indexer", "err", err)
+		}
+	}
 	vm.eth.Stop()
 	vm.shutdownWg.Wait()
 	return nil
@@ -1469,6 +1561,27 @@ func (vm *VM) CreateHandlers(context.Context) (map[string]http.Handler, error) {
 		enabledAPIs = append(enabledAPIs, "snowman")
 	}
 
+	if vm.config.ETLStreamEnabled {
+		if err := handler.RegisterName("etl", &ChainStreamAPI{vm}); err != nil {
+			return nil, err
+		}
+		enabledAPIs = append(enabledAPIs, "etl")
+	}
+
+	if vm.tokenIndexer != nil {
+		if err := handler.RegisterName("token", &TokenAPI{vm}); err != nil {
+			return nil, err
+		}
+		enabledAPIs = append(enabledAPIs, "token")
+	}
+
+	if vm.addressIndexer != nil {
+		if err := handler.RegisterName("ots", &OtsAPI{vm}); err != nil {
+			return nil, err
+		}
+		enabledAPIs = append(enabledAPIs, "ots")
+	}
+
 	if vm.config.WarpAPIEnabled {
 		warpAPI := warp.NewAPI(vm.ctx, vm.networkCodec, vm.warpBackend, vm.Network, vm.requirePrimaryNetworkSigners)
 		if err := handler.RegisterName("warp", warpAPI); err != nil {
@@ -1810,6 +1923,10 @@ func (vm *VM) stateSyncEnabled(lastAcceptedHeight uint64) bool {
 		// if the config is set, use that
 		return *vm.config.StateSyncEnabled
 	}
+	// the state syncer only writes hash based tries.
+	if vm.config.StateScheme == rawdb.PathScheme {
+		return false
+	}
 
 	// enable state sync by default if the chain is empty.
 	return lastAcceptedHeight == 0
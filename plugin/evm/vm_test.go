@@ -64,6 +64,7 @@ import (
 	atomictxpool "github.com/ava-labs/coreth/plugin/evm/atomic/txpool"
 	"github.com/ava-labs/coreth/plugin/evm/customtypes"
 	"github.com/ava-labs/coreth/rpc"
+	"github.com/ava-labs/libevm/core/rawdb"
 	"github.com/ava-labs/libevm/core/types"
 )
 
@@ -740,6 +741,83 @@ func TestBuildEthTxBlock(t *testing.T) {
 	}
 }
 
+func TestPathStateScheme(t *testing.T) {
+	importAmount := uint64(20000000)
+	fork := upgradetest.ApricotPhase2
+	configJSON := `{"state-scheme":"path","state-sync-enabled":false}`
+	tvm := newVM(t, testVMConfig{
+		fork:       &fork,
+		configJSON: configJSON,
+		utxos: map[ids.ShortID]uint64{
+			testShortIDAddrs[0]: importAmount,
+		},
+	})
+
+	importTx, err := tvm.vm.newImportTx(tvm.vm.ctx.XChainID, testEthAddrs[0], initialBaseFee, []*secp256k1.PrivateKey{testKeys[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tvm.vm.mempool.AddLocalTx(importTx); err != nil {
+		t.Fatal(err)
+	}
+	<-tvm.toEngine
+
+	blk, err := tvm.vm.BuildBlock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.Verify(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := tvm.vm.SetPreference(context.Background(), blk.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := blk.Accept(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if scheme := tvm.vm.blockChain.TrieDB().Scheme(); scheme != rawdb.PathScheme {
+		t.Fatalf("Expected the %s state scheme, found %s", rawdb.PathScheme, scheme)
+	}
+	if err := tvm.vm.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	restartedVM := &VM{}
+	newCTX := snowtest.Context(t, snowtest.CChainID)
+	newCTX.NetworkUpgrades = upgradetest.GetConfig(fork)
+	if err := restartedVM.Initialize(
+		context.Background(),
+		newCTX,
+		tvm.db,
+		[]byte(genesisJSON(forkToChainConfig[fork])),
+		[]byte(""),
+		[]byte(configJSON),
+		tvm.toEngine,
+		[]*commonEng.Fx{},
+		nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := restartedVM.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The accepted state is journaled on shutdown
+	ethBlk := blk.(*chain.BlockWrapper).Block.(*Block).ethBlock
+	if !restartedVM.blockChain.HasState(ethBlk.Root()) {
+		t.Fatalf("Expected the last accepted state to be available after restart")
+	}
+	statedb, err := restartedVM.blockChain.StateAt(ethBlk.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := statedb.GetBalance(testEthAddrs[0]); balance.IsZero() {
+		t.Fatalf("Expected the imported funds to be available after restart")
+	}
+}
+
 func testConflictingImportTxs(t *testing.T, fork upgradetest.Fork) {
 	importAmount := uint64(10000000)
 	tvm := newVM(t, testVMConfig{
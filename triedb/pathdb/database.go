@@ -32,9 +32,11 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/ava-labs/coreth/params"
 	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/common/lru"
 	"github.com/ava-labs/libevm/core/rawdb"
 	"github.com/ava-labs/libevm/core/types"
 	"github.com/ava-labs/libevm/ethdb"
@@ -102,6 +104,13 @@ type Config struct {
 	CleanCacheSize int    // Maximum memory allowance (in bytes) for caching clean nodes
 	DirtyCacheSize int    // Maximum memory allowance (in bytes) for caching dirty nodes
 	ReadOnly       bool   // Flag whether the database is opened in read only mode.
+	HistoricDepth  uint64 // Maximum number of state histories reverted to rebuild a historic state, 0 means no limit
+
+	// ManualFlatten keeps all the diff layers in memory until they are
+	// flattened by [Database.Flatten] or [Database.Commit], instead of
+	// flattening all but the 128 most recent ones on each update. This
+	// ensures only accepted states reach the disk layer.
+	ManualFlatten bool
 }
 
 func (c Config) BackendConstructor(diskdb ethdb.Database) triedb.DBOverride {
@@ -152,8 +161,7 @@ type Database struct {
 	tree       *layerTree     // The group for all known layers
 	lock       sync.RWMutex   // Lock to prevent mutations from happening at the same time
 
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	// freezer    *rawdb.ResettableFreezer // Freezer for storing trie histories, nil possible in tests
+	historics *lru.Cache[common.Hash, *historicLayer] // Recently rebuilt historic states
 }
 
 // New attempts to load an already existing layer from a persistent key-value
@@ -170,52 +178,35 @@ func New(diskdb ethdb.Database, config *Config) *Database {
 		bufferSize: config.DirtyCacheSize,
 		config:     config,
 		diskdb:     diskdb,
+		historics:  lru.NewCache[common.Hash, *historicLayer](historicCacheSize),
 	}
 	// Construct the layer tree by resolving the in-disk singleton state
 	// and in-memory layer journal.
 	db.tree = newLayerTree(db.loadLayers())
 
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	// Open the freezer for state history if the passed database contains an
-	// ancient store. Otherwise, all the relevant functionalities are disabled.
-	//
-	// Because the freezer can only be opened once at the same time, this
-	// mechanism also ensures that at most one **non-readOnly** database
-	// is opened at the same time to prevent accidental mutation.
-	// if ancient, err := diskdb.AncientDatadir(); err == nil && ancient != "" && !db.readOnly {
-	// 	freezer, err := rawdb.NewStateFreezer(ancient, false)
-	// 	if err != nil {
-	// 		log.Crit("Failed to open state history freezer", "err", err)
-	// 	}
-	// 	db.freezer = freezer
-	//
-	// 	diskLayerID := db.tree.bottom().stateID()
-	// 	if diskLayerID == 0 {
-	// 		// Reset the entire state histories in case the trie database is
-	// 		// not initialized yet, as these state histories are not expected.
-	// 		frozen, err := db.freezer.Ancients()
-	// 		if err != nil {
-	// 			log.Crit("Failed to retrieve head of state history", "err", err)
-	// 		}
-	// 		if frozen != 0 {
-	// 			err := db.freezer.Reset()
-	// 			if err != nil {
-	// 				log.Crit("Failed to reset state histories", "err", err)
-	// 			}
-	// 			log.Info("Truncated extraneous state history")
-	// 		}
-	// 	} else {
-	// 		// Truncate the extra state histories above in freezer in case
-	// 		// it's not aligned with the disk layer.
-	// 		pruned, err := truncateFromHead(db.diskdb, freezer, diskLayerID)
-	// 		if err != nil {
-	// 			log.Crit("Failed to truncate extra state histories", "err", err)
-	// 		}
-	// 		if pruned != 0 {
-	// 			log.Warn("Truncated extra state histories", "number", pruned)
-	// 		}
-	// 	}
-	// }
+	// State histories are kept in the key-value store since we do not have
+	// a freezer, see history_store.go.
+	if !db.readOnly {
+		diskLayerID := db.tree.bottom().stateID()
+		if diskLayerID == 0 {
+			// Reset the entire state histories in case the trie database is
+			// not initialized yet, as these state histories are not expected.
+			if err := resetHistories(db.diskdb); err != nil {
+				log.Crit("Failed to reset state histories", "err", err)
+			}
+		} else {
+			// Truncate the extra state histories above in case it's not
+			// aligned with the disk layer.
+			pruned, err := truncateFromHead(db.diskdb, diskLayerID)
+			if err != nil {
+				log.Crit("Failed to truncate extra state histories", "err", err)
+			}
+			if pruned != 0 {
+				log.Warn("Truncated extra state histories", "number", pruned)
+			}
+		}
+	}
+	// NOTE: This is disabled since we don't have SnapSyncStatusFlag.
 	// // Disable database in case node is still in the initial state sync stage.
 	// if rawdb.ReadSnapSyncStatusFlag(diskdb) == rawdb.StateSyncRunning && !db.readOnly {
 	// 	if err := db.Disable(); err != nil {
@@ -254,6 +245,9 @@ func (db *Database) Update(root common.Hash, parentRoot common.Hash, block uint6
 	if err := db.tree.add(root, parentRoot, block, nodes, states); err != nil {
 		return err
 	}
+	if db.config.ManualFlatten {
+		return nil
+	}
 	// Keep 128 diff layers in the memory, persistent layer is 129th.
 	// - head layer is paired with HEAD state
 	// - head-1 layer is paired with HEAD-1 state
@@ -274,9 +268,31 @@ func (db *Database) Commit(root common.Hash, report bool) error {
 	if err := db.modifyAllowed(); err != nil {
 		return err
 	}
+	// Nothing to flatten if the state is already persisted in the disk layer.
+	if db.tree.bottom().rootHash() == types.TrieRootHash(root) {
+		return nil
+	}
 	return db.tree.cap(root, 0)
 }
 
+// Flatten merges the layer with the given root and all the layers below it
+// into the disk layer, keeping the layers built on top of it. Unlike Commit,
+// the dirty nodes are kept in the node buffer until it is full.
+func (db *Database) Flatten(root common.Hash) error {
+	// Hold the lock to prevent concurrent mutations.
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Short circuit if the mutation is not allowed.
+	if err := db.modifyAllowed(); err != nil {
+		return err
+	}
+	if db.tree.bottom().rootHash() == types.TrieRootHash(root) {
+		return nil
+	}
+	return db.tree.flatten(root, false)
+}
+
 // Disable deactivates the database and invalidates all available state layers
 // as stale to prevent access to the persistent state, which is in the syncing
 // stage.
@@ -328,16 +344,12 @@ func (db *Database) Enable(root common.Hash) error {
 	if err := batch.Write(); err != nil {
 		return err
 	}
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	// Clean up all state histories in freezer. Theoretically
-	// all root->id mappings should be removed as well. Since
-	// mappings can be huge and might take a while to clear
-	// them, just leave them in disk and wait for overwriting.
-	// if db.freezer != nil {
-	// 	if err := db.freezer.Reset(); err != nil {
-	// 		return err
-	// 	}
-	// }
+	// Clean up all state histories. Theoretically all root->id mappings
+	// should be removed as well. Since mappings can be huge and might take
+	// a while to clear them, just leave them in disk and wait for overwriting.
+	if err := resetHistories(db.diskdb); err != nil {
+		return err
+	}
 	// Re-construct a new disk layer backed by persistent state
 	// with **empty clean cache and node buffer**.
 	db.tree.reset(newDiskLayer(root, 0, db, nil, newNodeBuffer(db.bufferSize, nil, 0)))
@@ -353,8 +365,44 @@ func (db *Database) Enable(root common.Hash) error {
 // The state is supported as the rollback destination only if it's
 // canonical state and the corresponding trie histories are existent.
 func (db *Database) Recover(root common.Hash, loader triestate.TrieLoader) error {
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	return errors.New("state rollback is non-supported")
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Short circuit if rollback operation is not supported.
+	if err := db.modifyAllowed(); err != nil {
+		return err
+	}
+	// Short circuit if the target state is not recoverable.
+	root = types.TrieRootHash(root)
+	if !db.Recoverable(root) {
+		return errStateUnrecoverable
+	}
+	// Apply the state histories upon the disk layer in order.
+	var (
+		start = time.Now()
+		dl    = db.tree.bottom()
+	)
+	for dl.rootHash() != root {
+		h, err := readHistory(db.diskdb, dl.stateID())
+		if err != nil {
+			return err
+		}
+		dl, err = dl.revert(h, loader)
+		if err != nil {
+			return err
+		}
+		// reset layer with newly created disk layer. It must be
+		// done after each revert operation, otherwise the new
+		// disk layer won't be accessible from outside.
+		db.tree.reset(dl)
+	}
+	rawdb.DeleteTrieJournal(db.diskdb)
+	_, err := truncateFromHead(db.diskdb, dl.stateID())
+	if err != nil {
+		return err
+	}
+	log.Debug("Recovered state", "root", root, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
 }
 
 // Recoverable returns the indicator if the specified state is recoverable.
@@ -372,24 +420,22 @@ func (db *Database) Recoverable(root common.Hash) bool {
 	if *id >= dl.stateID() {
 		return false
 	}
-	return false
-	// NOTE(freezer): This is disabled since we do not have a freezer.
 	// Ensure the requested state is a canonical state and all state
 	// histories in range [id+1, disklayer.ID] are present and complete.
-	// parent := root
-	// return checkHistories(db.freezer, *id+1, dl.stateID()-*id, func(m *meta) error {
-	// 	if m.parent != parent {
-	// 		return errors.New("unexpected state history")
-	// 	}
-	// 	if len(m.incomplete) > 0 {
-	// 		return errors.New("incomplete state history")
-	// 	}
-	// 	parent = m.root
-	// 	return nil
-	// }) == nil
+	parent := root
+	return checkHistories(db.diskdb, *id+1, dl.stateID()-*id, func(m *meta) error {
+		if m.parent != parent {
+			return errors.New("unexpected state history")
+		}
+		if len(m.incomplete) > 0 {
+			return errors.New("incomplete state history")
+		}
+		parent = m.root
+		return nil
+	}) == nil
 }
 
-// Close closes the trie database and the held freezer.
+// Close closes the trie database.
 func (db *Database) Close() error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
@@ -401,12 +447,6 @@ func (db *Database) Close() error {
 	// Release the memory held by clean cache.
 	db.tree.bottom().resetCache()
 
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	// Close the attached state history freezer.
-	//if db.freezer == nil {
-	//	return nil
-	//}
-	//return db.freezer.Close()
 	return nil
 }
 
@@ -29,6 +29,7 @@ package pathdb
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -110,8 +111,6 @@ type tester struct {
 
 func newTester(t *testing.T, historyLimit uint64) *tester {
 	var (
-		// NOTE(freezer): This is disabled since we do not have a freezer.
-		// disk, _ = rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
 		disk = rawdb.NewMemoryDatabase()
 		db   = New(disk, &Config{
 			StateHistory:   historyLimit,
@@ -365,15 +364,45 @@ func (t *tester) bottomIndex() int {
 	return -1
 }
 
+func (t *tester) verifyHistory() error {
+	bottom := t.bottomIndex()
+	for i, root := range t.roots {
+		// The state history related to the state above disk layer should not exist.
+		if i > bottom {
+			_, err := readHistory(t.db.diskdb, uint64(i+1))
+			if err == nil {
+				return errors.New("unexpected state history")
+			}
+			continue
+		}
+		// The state history related to the state below or equal to the disk layer
+		// should exist.
+		obj, err := readHistory(t.db.diskdb, uint64(i+1))
+		if err != nil {
+			return err
+		}
+		parent := types.EmptyRootHash
+		if i != 0 {
+			parent = t.roots[i-1]
+		}
+		if obj.meta.parent != parent {
+			return fmt.Errorf("unexpected parent, want: %x, got: %x", parent, obj.meta.parent)
+		}
+		if obj.meta.root != root {
+			return fmt.Errorf("unexpected root, want: %x, got: %x", root, obj.meta.root)
+		}
+	}
+	return nil
+}
+
 func TestDatabaseRollback(t *testing.T) {
 	// Verify state histories
 	tester := newTester(t, 0)
 	defer tester.release()
 
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	// if err := tester.verifyHistory(); err != nil {
-	// 	t.Fatalf("Invalid state history, err: %v", err)
-	// }
+	if err := tester.verifyHistory(); err != nil {
+		t.Fatalf("Invalid state history, err: %v", err)
+	}
 	// Revert database from top to bottom
 	for i := tester.bottomIndex(); i >= 0; i-- {
 		root := tester.roots[i]
@@ -382,17 +411,91 @@ func TestDatabaseRollback(t *testing.T) {
 			parent = tester.roots[i-1]
 		}
 		loader := newHashLoader(tester.snapAccounts[root], tester.snapStorages[root])
-		// NOTE(freezer): This is disabled since we do not have a freezer.
-		// if err := tester.db.Recover(parent, loader); err != nil {
-		// 	t.Fatalf("Failed to revert db, err: %v", err)
-		// }
-		require.ErrorContains(t, tester.db.Recover(parent, loader), "state rollback is non-supported")
+		if err := tester.db.Recover(parent, loader); err != nil {
+			t.Fatalf("Failed to revert db, err: %v", err)
+		}
 		tester.verifyState(parent)
 	}
-	// NOTE(freezer): This is disabled since we do not have a freezer.
-	// if tester.db.tree.len() != 1 {
-	// 	t.Fatal("Only disk layer is expected")
-	// }
+	if tester.db.tree.len() != 1 {
+		t.Fatal("Only disk layer is expected")
+	}
+}
+
+// OpenTrie implements [triestate.TrieLoader] on the state snapshots.
+func (t *tester) OpenTrie(root common.Hash) (triestate.Trie, error) {
+	return newHashLoader(t.snapAccounts[root], nil).OpenTrie(root)
+}
+
+// OpenStorageTrie implements [triestate.TrieLoader] on the state snapshots.
+func (t *tester) OpenStorageTrie(stateRoot common.Hash, addrHash, root common.Hash) (triestate.Trie, error) {
+	return newHashLoader(nil, t.snapStorages[stateRoot]).OpenStorageTrie(stateRoot, addrHash, root)
+}
+
+func TestDatabaseHistoric(t *testing.T) {
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	index := tester.bottomIndex()
+	for i := index - 1; i >= index-5; i-- {
+		root := tester.roots[i]
+		hdb, err := tester.db.historic(context.Background(), root, tester)
+		require.NoError(t, err)
+
+		// Verify the historic state through the rolled back database.
+		live := tester.db
+		tester.db = hdb
+		require.NoError(t, tester.verifyState(root))
+		tester.db = live
+	}
+	// The live database is left untouched.
+	require.Equal(t, tester.roots[index], tester.db.tree.bottom().rootHash())
+	require.NoError(t, tester.verifyState(tester.roots[index]))
+	require.NoError(t, tester.verifyHistory())
+
+	// The disk layer itself and unknown states can't be rebuilt.
+	_, err := tester.db.Historic(context.Background(), tester.roots[index])
+	require.ErrorIs(t, err, errStateUnrecoverable)
+	_, err = tester.db.Historic(context.Background(), common.Hash{0x1})
+	require.ErrorIs(t, err, errStateUnrecoverable)
+}
+
+func TestDatabaseHistoricLimits(t *testing.T) {
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	var (
+		index = tester.bottomIndex()
+		root  = tester.roots[index-3]
+	)
+	// Deeper states than allowed are rejected.
+	tester.db.config.HistoricDepth = 2
+	_, err := tester.db.historic(context.Background(), root, tester)
+	require.ErrorIs(t, err, errHistoricTooDeep)
+
+	// Reverting stops once the context is done.
+	tester.db.config.HistoricDepth = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tester.db.historic(ctx, root, tester)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// A rebuilt state is cached until the disk layer is persisted.
+	hdb, err := tester.db.historic(context.Background(), root, tester)
+	require.NoError(t, err)
+	cached, err := tester.db.historic(context.Background(), root, tester)
+	require.NoError(t, err)
+	require.Same(t, hdb, cached)
+
+	require.NoError(t, tester.db.Commit(tester.roots[index+1], false))
+	tester.db.config.HistoricDepth = 4
+	rebuilt, err := tester.db.historic(context.Background(), root, tester)
+	require.NoError(t, err)
+	require.NotSame(t, hdb, rebuilt)
+
+	live := tester.db
+	tester.db = rebuilt
+	require.NoError(t, tester.verifyState(root))
+	tester.db = live
 }
 
 func TestDatabaseRecoverable(t *testing.T) {
@@ -428,9 +531,7 @@ func TestDatabaseRecoverable(t *testing.T) {
 	}
 	for i, c := range cases {
 		result := tester.db.Recoverable(c.root)
-		// NOTE(freezer): This is disabled since we do not have a freezer.
-		// originally was `result != c.expect`
-		if result != false {
+		if result != c.expect {
 			t.Fatalf("case: %d, unexpected result, want %t, got %t", i, c.expect, result)
 		}
 	}
@@ -455,15 +556,10 @@ func TestDisable(t *testing.T) {
 	if blob := rawdb.ReadTrieJournal(tester.db.diskdb); len(blob) != 0 {
 		t.Fatal("Failed to clean journal")
 	}
-	// NOTE(freezer): This is disabled since we do not have a freezer.
 	// Ensure all trie histories are removed
-	// n, err := tester.db.freezer.Ancients()
-	// if err != nil {
-	// 	t.Fatal("Failed to clean state history")
-	// }
-	// if n != 0 {
-	// 	t.Fatal("Failed to clean state history")
-	// }
+	if _, err := readHistory(tester.db.diskdb, 1); err == nil {
+		t.Fatal("Failed to clean state history")
+	}
 	// Verify layer tree structure, single disk layer is expected
 	if tester.db.tree.len() != 1 {
 		t.Fatalf("Extra layer kept %d", tester.db.tree.len())
@@ -491,11 +587,39 @@ func TestCommit(t *testing.T) {
 	if err := tester.verifyState(tester.lastHash()); err != nil {
 		t.Fatalf("State is invalid, err: %v", err)
 	}
-	// NOTE(freezer): This is disabled since we do not have a freezer.
 	// Verify state histories
-	// if err := tester.verifyHistory(); err != nil {
-	// 	t.Fatalf("State history is invalid, err: %v", err)
-	// }
+	if err := tester.verifyHistory(); err != nil {
+		t.Fatalf("State history is invalid, err: %v", err)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	target := len(tester.roots) - 10
+	if err := tester.db.Flatten(tester.roots[target]); err != nil {
+		t.Fatalf("Failed to flatten database, err: %v", err)
+	}
+	// The layers on top of the flattened one are kept.
+	if tester.db.tree.bottom().rootHash() != tester.roots[target] {
+		t.Fatal("Layer tree structure is invalid")
+	}
+	if tester.db.tree.len() != 10 {
+		t.Fatalf("Unexpected layer count, want: 10, got: %d", tester.db.tree.len())
+	}
+	for i := target; i < len(tester.roots); i++ {
+		if err := tester.verifyState(tester.roots[i]); err != nil {
+			t.Fatalf("State is invalid, err: %v", err)
+		}
+	}
+	if err := tester.verifyHistory(); err != nil {
+		t.Fatalf("State history is invalid, err: %v", err)
+	}
+	// Flattening the disk layer is a no-op.
+	if err := tester.db.Flatten(tester.roots[target]); err != nil {
+		t.Fatalf("Failed to flatten disk layer, err: %v", err)
+	}
 }
 
 func TestJournal(t *testing.T) {
@@ -564,22 +688,24 @@ func TestCorruptedJournal(t *testing.T) {
 // In this scenario, it is mandatory to update the persistent state before
 // truncating the tail histories. This ensures that the ID of the persistent state
 // always falls within the range of [oldest-history-id, latest-history-id].
-// func TestTailTruncateHistory(t *testing.T) {
-// 	tester := newTester(t, 10)
-// 	defer tester.release()
-//
-// 	tester.db.Close()
-// 	tester.db = New(tester.db.diskdb, &Config{StateHistory: 10})
-//
-// 	head, err := tester.db.freezer.Ancients()
-// 	if err != nil {
-// 		t.Fatalf("Failed to obtain freezer head")
-// 	}
-// 	stored := rawdb.ReadPersistentStateID(tester.db.diskdb)
-// 	if head != stored {
-// 		t.Fatalf("Failed to truncate excess history object above, stored: %d, head: %d", stored, head)
-// 	}
-// }
+func TestTailTruncateHistory(t *testing.T) {
+	tester := newTester(t, 10)
+	defer tester.release()
+
+	tester.db.Close()
+	tester.db = New(tester.db.diskdb, &Config{StateHistory: 10})
+
+	stored := rawdb.ReadPersistentStateID(tester.db.diskdb)
+	if _, err := readHistory(tester.db.diskdb, stored); err != nil {
+		t.Fatalf("Failed to read the head history, err: %v", err)
+	}
+	if _, err := readHistory(tester.db.diskdb, stored+1); err == nil {
+		t.Fatalf("Failed to truncate excess history object above, stored: %d", stored)
+	}
+	if tail := readHistoryTail(tester.db.diskdb); stored <= tail {
+		t.Fatalf("Persisted state is below the oldest history, stored: %d, tail: %d", stored, tail)
+	}
+}
 
 // copyAccounts returns a deep-copied account set of the provided one.
 func copyAccounts(set map[common.Hash][]byte) map[common.Hash][]byte {
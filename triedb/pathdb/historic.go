@@ -0,0 +1,141 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pathdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/core/types"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/ava-labs/libevm/trie"
+	"github.com/ava-labs/libevm/trie/trienode"
+	"github.com/ava-labs/libevm/trie/triestate"
+	"github.com/ava-labs/libevm/triedb"
+)
+
+// historicCacheSize is the number of recently rebuilt historic states kept
+// in memory, so that consecutive requests on the same state are cheap.
+const historicCacheSize = 4
+
+// historicLayer is a rebuilt historic state, valid as long as the disk holds
+// the state [persisted] it was rebuilt upon.
+type historicLayer struct {
+	db        *Database
+	persisted uint64
+}
+
+// Historic returns a read-only database holding the canonical state [root],
+// which must be below the disk layer. Unlike [Database.Recover], the live
+// database is left untouched: the state histories are applied in reverse on
+// top of a copy of the disk layer's node buffer and the reverted nodes are
+// kept in memory.
+//
+// At most [Config.HistoricDepth] state histories are reverted, and reverting
+// stops once [ctx] is done. The recently rebuilt states are cached until the
+// live node buffer is flushed.
+//
+// The returned database reads the remaining nodes from disk, so it should be
+// short-lived. Once the live node buffer is flushed again, reading a node that
+// has been overwritten fails rather than returning an unexpected value.
+func (db *Database) Historic(ctx context.Context, root common.Hash) (*Database, error) {
+	return db.historic(ctx, root, nil)
+}
+
+// historic implements [Database.Historic]. The reverse state changes are
+// applied upon the tries opened by [loader], or by the database being rebuilt
+// if it is nil.
+func (db *Database) historic(ctx context.Context, root common.Hash, loader triestate.TrieLoader) (*Database, error) {
+	root = types.TrieRootHash(root)
+
+	// Hold the lock only while taking a consistent view of the disk layer,
+	// reverting the histories may take a while.
+	db.lock.RLock()
+	if db.waitSync {
+		db.lock.RUnlock()
+		return nil, errDatabaseWaitSync
+	}
+	persisted := rawdb.ReadPersistentStateID(db.diskdb)
+	if cached, ok := db.historics.Get(root); ok && cached.persisted == persisted {
+		db.lock.RUnlock()
+		return cached.db, nil
+	}
+	if !db.Recoverable(root) {
+		db.lock.RUnlock()
+		return nil, fmt.Errorf("%w: %#x", errStateUnrecoverable, root)
+	}
+	var (
+		dl      = db.tree.bottom()
+		current = dl.rootHash()
+		id      = dl.stateID()
+		nodes   = copyNodes(dl.buffer.nodes)
+	)
+	db.lock.RUnlock()
+
+	// The state id of [root] is known since it is recoverable.
+	target := rawdb.ReadStateID(db.diskdb, root)
+	if limit := db.config.HistoricDepth; target != nil && limit != 0 && id-*target > limit {
+		return nil, fmt.Errorf("%w: %d state histories to revert, limit is %d", errHistoricTooDeep, id-*target, limit)
+	}
+	var (
+		start = time.Now()
+		hdb   = &Database{
+			readOnly: true,
+			config:   &Config{},
+			diskdb:   db.diskdb,
+		}
+	)
+	hdb.tree = newLayerTree(newDiskLayer(current, id, hdb, nil, newNodeBuffer(0, nodes, 0)))
+	if loader == nil {
+		loader = trie.NewMerkleLoader(triedb.NewDatabase(db.diskdb, &triedb.Config{
+			DBOverride: func(ethdb.Database) triedb.DBOverride { return hdb },
+		}))
+	}
+	for current != root {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		h, err := readHistory(db.diskdb, id)
+		if err != nil {
+			return nil, err
+		}
+		if h.meta.root != current {
+			return nil, errUnexpectedHistory
+		}
+		reverted, err := triestate.Apply(h.meta.parent, h.meta.root, h.accounts, h.storages, loader)
+		if err != nil {
+			return nil, err
+		}
+		for owner, subset := range reverted {
+			if _, ok := nodes[owner]; !ok {
+				nodes[owner] = make(map[string]*trienode.Node, len(subset))
+			}
+			for path, n := range subset {
+				nodes[owner][path] = n
+			}
+		}
+		current, id = h.meta.parent, id-1
+		hdb.tree.reset(newDiskLayer(current, id, hdb, nil, newNodeBuffer(0, nodes, 0)))
+	}
+	db.historics.Add(root, &historicLayer{db: hdb, persisted: persisted})
+	log.Debug("Rebuilt historic state", "root", root, "id", id, "elapsed", common.PrettyDuration(time.Since(start)))
+	return hdb, nil
+}
+
+// copyNodes returns a copy of the given node set which can be mutated without
+// affecting the original one. The nodes themselves are shared.
+func copyNodes(nodes map[common.Hash]map[string]*trienode.Node) map[common.Hash]map[string]*trienode.Node {
+	copied := make(map[common.Hash]map[string]*trienode.Node, len(nodes))
+	for owner, subset := range nodes {
+		copied[owner] = make(map[string]*trienode.Node, len(subset))
+		for path, n := range subset {
+			copied[owner][path] = n
+		}
+	}
+	return copied
+}
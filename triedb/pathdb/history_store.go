@@ -0,0 +1,231 @@
+// Copyright (C) 2019-2025, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package pathdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/libevm/common"
+	"github.com/ava-labs/libevm/core/rawdb"
+	"github.com/ava-labs/libevm/ethdb"
+	"github.com/ava-labs/libevm/log"
+	"github.com/ava-labs/libevm/rlp"
+)
+
+// Upstream keeps state histories in the ancient store. We do not have a
+// freezer, so each history is stored as a single entry in the key-value
+// store, keyed by its state id, along with the id of the last pruned one.
+var (
+	stateHistoryPrefix  = []byte("pathdb-state-history-")  // stateHistoryPrefix + id (uint64 big endian) -> state history
+	stateHistoryTailKey = []byte("pathdbStateHistoryTail") // id of the most recently pruned state history
+)
+
+// storedHistory is the on-disk encoding of a state history.
+type storedHistory struct {
+	Meta         []byte
+	AccountIndex []byte
+	StorageIndex []byte
+	Accounts     []byte
+	Storages     []byte
+}
+
+func stateHistoryKey(id uint64) []byte {
+	return binary.BigEndian.AppendUint64(common.CopyBytes(stateHistoryPrefix), id)
+}
+
+// readHistoryTail returns the id of the most recently pruned state history,
+// the histories in (tail, head] are available.
+func readHistoryTail(db ethdb.KeyValueReader) uint64 {
+	blob, _ := db.Get(stateHistoryTailKey)
+	if len(blob) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(blob)
+}
+
+func writeHistoryTail(db ethdb.KeyValueWriter, tail uint64) {
+	if err := db.Put(stateHistoryTailKey, binary.BigEndian.AppendUint64(nil, tail)); err != nil {
+		log.Crit("Failed to store state history tail", "err", err)
+	}
+}
+
+// readStoredHistory retrieves the encoded state history with the given id.
+func readStoredHistory(db ethdb.KeyValueReader, id uint64) (*storedHistory, error) {
+	blob, err := db.Get(stateHistoryKey(id))
+	if err != nil || len(blob) == 0 {
+		return nil, fmt.Errorf("state history not found %d", id)
+	}
+	var stored storedHistory
+	if err := rlp.DecodeBytes(blob, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// readHistoryMeta reads and decodes the meta object of the state history with
+// the given id.
+func readHistoryMeta(db ethdb.KeyValueReader, id uint64) (*meta, error) {
+	stored, err := readStoredHistory(db, id)
+	if err != nil {
+		return nil, err
+	}
+	var m meta
+	if err := m.decode(stored.Meta); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// readHistory reads and decodes the state history object by the given id.
+func readHistory(db ethdb.KeyValueReader, id uint64) (*history, error) {
+	stored, err := readStoredHistory(db, id)
+	if err != nil {
+		return nil, err
+	}
+	var m meta
+	if err := m.decode(stored.Meta); err != nil {
+		return nil, err
+	}
+	dec := history{meta: &m}
+	if err := dec.decode(stored.Accounts, stored.Storages, stored.AccountIndex, stored.StorageIndex); err != nil {
+		return nil, err
+	}
+	return &dec, nil
+}
+
+// writeHistory persists the state history with the provided state set.
+func writeHistory(db ethdb.KeyValueWriter, dl *diffLayer) error {
+	// Short circuit if state set is not available.
+	if dl.states == nil {
+		return errors.New("state change set is not available")
+	}
+	var (
+		start   = time.Now()
+		history = newHistory(dl.rootHash(), dl.parentLayer().rootHash(), dl.block, dl.states)
+	)
+	accountData, storageData, accountIndex, storageIndex := history.encode()
+	dataSize := common.StorageSize(len(accountData) + len(storageData))
+	indexSize := common.StorageSize(len(accountIndex) + len(storageIndex))
+
+	blob, err := rlp.EncodeToBytes(&storedHistory{
+		Meta:         history.meta.encode(),
+		AccountIndex: accountIndex,
+		StorageIndex: storageIndex,
+		Accounts:     accountData,
+		Storages:     storageData,
+	})
+	if err != nil {
+		return err
+	}
+	if err := db.Put(stateHistoryKey(dl.stateID()), blob); err != nil {
+		return err
+	}
+	historyDataBytesMeter.Mark(int64(dataSize))
+	historyIndexBytesMeter.Mark(int64(indexSize))
+	historyBuildTimeMeter.UpdateSince(start)
+	log.Debug("Stored state history", "id", dl.stateID(), "block", dl.block, "data", dataSize, "index", indexSize, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// checkHistories retrieves a batch of meta objects with the specified range
+// and performs the callback on each item.
+func checkHistories(db ethdb.KeyValueReader, start, count uint64, check func(*meta) error) error {
+	for id := start; id < start+count; id++ {
+		m, err := readHistoryMeta(db, id)
+		if err != nil {
+			return err
+		}
+		if err := check(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateFromHead removes the extra state histories above [nhead], which
+// may be left behind by an unclean shutdown. It returns the number of items
+// removed from the head.
+func truncateFromHead(db ethdb.KeyValueStore, nhead uint64) (int, error) {
+	batch := db.NewBatch()
+	pruned := 0
+	for id := nhead + 1; ; id++ {
+		m, err := readHistoryMeta(db, id)
+		if err != nil {
+			break
+		}
+		rawdb.DeleteStateID(batch, m.root)
+		if err := batch.Delete(stateHistoryKey(id)); err != nil {
+			return 0, err
+		}
+		pruned++
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return pruned, nil
+}
+
+// truncateFromTail removes the extra state histories from the tail so the
+// oldest available one is [ntail]+1. It returns the number of items removed
+// from the tail.
+func truncateFromTail(db ethdb.KeyValueStore, ntail uint64) (int, error) {
+	otail := readHistoryTail(db)
+	if otail >= ntail {
+		return 0, nil
+	}
+	batch := db.NewBatch()
+	for id := otail + 1; id <= ntail; id++ {
+		// Histories are not written for the layers committed without a state
+		// set, skip over these gaps.
+		m, err := readHistoryMeta(db, id)
+		if err != nil {
+			continue
+		}
+		rawdb.DeleteStateID(batch, m.root)
+		if err := batch.Delete(stateHistoryKey(id)); err != nil {
+			return 0, err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+		}
+	}
+	writeHistoryTail(batch, ntail)
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return int(ntail - otail), nil
+}
+
+// resetHistories removes all the stored state histories.
+func resetHistories(db ethdb.KeyValueStore) error {
+	it := db.NewIterator(stateHistoryPrefix, nil)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if len(it.Key()) != len(stateHistoryPrefix)+8 {
+			continue
+		}
+		if err := batch.Delete(it.Key()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	writeHistoryTail(batch, 0)
+	return batch.Write()
+}
@@ -138,41 +138,7 @@ func (tree *layerTree) cap(root common.Hash, layers int) error {
 
 	// If full commit was requested, flatten the diffs and merge onto disk
 	if layers == 0 {
-		base, err := diff.persist(true)
-		if err != nil {
-			return err
-		}
-		// Replace the entire layer tree with the flat base
-		// tree.layers = map[common.Hash]layer{base.rootHash(): base}
-		//
-		// Note: The original code above is replaced with the code below
-		// since we need to keep the children of the base layer, as these
-		// layers may be accessed by blocks in processing.
-		children := make(map[common.Hash][]common.Hash)
-		for root, layer := range tree.layers {
-			if dl, ok := layer.(*diffLayer); ok {
-				parent := dl.parentLayer().rootHash()
-				children[parent] = append(children[parent], root)
-				if parent == base.rootHash() {
-					dl.lock.Lock()
-					dl.parent = base
-					dl.lock.Unlock()
-				}
-			}
-		}
-
-		newLayers := map[common.Hash]layer{base.rootHash(): base}
-		var keepChildren func(root common.Hash)
-		keepChildren = func(root common.Hash) {
-			for _, child := range children[root] {
-				childLayer := tree.layers[child]
-				newLayers[child] = childLayer
-				keepChildren(child)
-			}
-		}
-		keepChildren(base.rootHash())
-		tree.layers = newLayers
-		return nil
+		return tree.flattenLocked(diff, true)
 	}
 	// Dive until we run out of layers or reach the persistent database
 	for i := 0; i < layers-1; i++ {
@@ -232,6 +198,64 @@ func (tree *layerTree) cap(root common.Hash, layers int) error {
 	return nil
 }
 
+// flatten merges the layer with the given root and all the layers below it
+// into the disk layer, keeping its descendants. The dirty nodes are only
+// written to disk if [force] is set or the node buffer is full.
+func (tree *layerTree) flatten(root common.Hash, force bool) error {
+	root = types.TrieRootHash(root)
+	l := tree.get(root)
+	if l == nil {
+		return fmt.Errorf("triedb layer [%#x] missing", root)
+	}
+	diff, ok := l.(*diffLayer)
+	if !ok {
+		return fmt.Errorf("triedb layer [%#x] is disk layer", root)
+	}
+	tree.lock.Lock()
+	defer tree.lock.Unlock()
+
+	return tree.flattenLocked(diff, force)
+}
+
+// flattenLocked implements [layerTree.flatten], the tree lock must be held.
+func (tree *layerTree) flattenLocked(diff *diffLayer, force bool) error {
+	base, err := diff.persist(force)
+	if err != nil {
+		return err
+	}
+	// Replace the entire layer tree with the flat base
+	// tree.layers = map[common.Hash]layer{base.rootHash(): base}
+	//
+	// Note: The original code above is replaced with the code below
+	// since we need to keep the children of the base layer, as these
+	// layers may be accessed by blocks in processing.
+	children := make(map[common.Hash][]common.Hash)
+	for root, layer := range tree.layers {
+		if dl, ok := layer.(*diffLayer); ok {
+			parent := dl.parentLayer().rootHash()
+			children[parent] = append(children[parent], root)
+			if parent == base.rootHash() {
+				dl.lock.Lock()
+				dl.parent = base
+				dl.lock.Unlock()
+			}
+		}
+	}
+
+	newLayers := map[common.Hash]layer{base.rootHash(): base}
+	var keepChildren func(root common.Hash)
+	keepChildren = func(root common.Hash) {
+		for _, child := range children[root] {
+			childLayer := tree.layers[child]
+			newLayers[child] = childLayer
+			keepChildren(child)
+		}
+	}
+	keepChildren(base.rootHash())
+	tree.layers = newLayers
+	return nil
+}
+
 // bottom returns the bottom-most disk layer in this tree.
 func (tree *layerTree) bottom() *diskLayer {
 	tree.lock.RLock()